@@ -0,0 +1,173 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Call Graph
+
+Extract the call graph of a program. Direct calls are from jal/c.jal and
+auipc/jalr pairs, tail calls are from j/jr. Indirect calls that can't be
+resolved are listed separately.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// CallEdge is a call from one function to another.
+type CallEdge struct {
+	From uint // calling function address
+	To   uint // called function address
+	Site uint // address of the call instruction
+	Tail bool // tail call
+}
+
+// CallSite is an indirect call with an unknown target.
+type CallSite struct {
+	Func uint // calling function address
+	Site uint // address of the call instruction
+	Tail bool // tail call
+}
+
+// CallGraph is the call graph of a set of functions.
+type CallGraph struct {
+	Funcs    []*Func    // functions sorted by address
+	Edges    []CallEdge // direct calls
+	Indirect []CallSite // unresolved indirect calls
+	syms     *Symbols
+}
+
+// NewCallGraph builds the call graph for a set of functions (see FindFuncs).
+func NewCallGraph(isa *ISA, code *Code, funcs []*Func, syms *Symbols) *CallGraph {
+	cg := &CallGraph{syms: syms}
+	cg.add(NewStackUsage(isa, code, funcs))
+	cg.sort()
+	return cg
+}
+
+// add the calls of a set of functions to the call graph.
+func (cg *CallGraph) add(su *StackUsage) {
+	for _, fs := range su.Funcs {
+		cg.Funcs = append(cg.Funcs, fs.Func)
+		for _, c := range fs.Calls {
+			if c.Indirect {
+				cg.Indirect = append(cg.Indirect, CallSite{fs.Func.Addr, c.Addr, c.Tail})
+			} else {
+				cg.Edges = append(cg.Edges, CallEdge{fs.Func.Addr, c.Target, c.Addr, c.Tail})
+			}
+		}
+	}
+}
+
+// sort the call graph by address.
+func (cg *CallGraph) sort() {
+	sort.Slice(cg.Funcs, func(i, j int) bool { return cg.Funcs[i].Addr < cg.Funcs[j].Addr })
+	sort.Slice(cg.Edges, func(i, j int) bool { return cg.Edges[i].Site < cg.Edges[j].Site })
+	sort.Slice(cg.Indirect, func(i, j int) bool { return cg.Indirect[i].Site < cg.Indirect[j].Site })
+}
+
+// CallGraph builds the call graph for the executable sections of a program.
+// Function starts are the entry point, the function symbols and any that
+// are found by FindFuncs.
+func (p *Program) CallGraph(isa *ISA) *CallGraph {
+	cg := &CallGraph{syms: p.Symbols}
+	entry := append([]uint{p.Entry}, p.Funcs...)
+	for _, code := range p.Code {
+		funcs := FindFuncs(isa, code, entry, p.Symbols)
+		cg.add(NewStackUsage(isa, code, funcs))
+	}
+	cg.sort()
+	return cg
+}
+
+// Name returns the name of a function.
+func (cg *CallGraph) Name(addr uint) string {
+	if name, ok := cg.syms.Name(addr); ok {
+		return name
+	}
+	return funcName(addr)
+}
+
+//-----------------------------------------------------------------------------
+// Export
+
+// DOT returns the call graph in Graphviz DOT format.
+func (cg *CallGraph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph callgraph {\n")
+	sb.WriteString("\tnode [shape=box];\n")
+	for _, f := range cg.Funcs {
+		sb.WriteString(fmt.Sprintf("\t\"%s\";\n", cg.Name(f.Addr)))
+	}
+	done := make(map[string]bool)
+	for _, e := range cg.Edges {
+		attr := ""
+		if e.Tail {
+			attr = " [style=dashed, label=\"tail\"]"
+		}
+		s := fmt.Sprintf("\t\"%s\" -> \"%s\"%s;\n", cg.Name(e.From), cg.Name(e.To), attr)
+		if !done[s] {
+			sb.WriteString(s)
+			done[s] = true
+		}
+	}
+	if len(cg.Indirect) != 0 {
+		sb.WriteString("\t\"<indirect>\" [shape=diamond];\n")
+		for _, c := range cg.Indirect {
+			s := fmt.Sprintf("\t\"%s\" -> \"<indirect>\" [style=dotted];\n", cg.Name(c.Func))
+			if !done[s] {
+				sb.WriteString(s)
+				done[s] = true
+			}
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+type jsonFunc struct {
+	Name string `json:"name"`
+	Addr string `json:"addr"`
+	End  string `json:"end"`
+}
+
+type jsonCall struct {
+	From string `json:"from"`
+	To   string `json:"to,omitempty"`
+	Site string `json:"site"`
+	Tail bool   `json:"tail,omitempty"`
+}
+
+type jsonCallGraph struct {
+	Funcs    []jsonFunc `json:"functions"`
+	Calls    []jsonCall `json:"calls"`
+	Indirect []jsonCall `json:"indirect"`
+}
+
+// MarshalJSON returns the call graph in JSON format.
+func (cg *CallGraph) MarshalJSON() ([]byte, error) {
+	x := jsonCallGraph{
+		Funcs:    []jsonFunc{},
+		Calls:    []jsonCall{},
+		Indirect: []jsonCall{},
+	}
+	for _, f := range cg.Funcs {
+		x.Funcs = append(x.Funcs, jsonFunc{cg.Name(f.Addr), hexAddr(f.Addr), hexAddr(f.End)})
+	}
+	for _, e := range cg.Edges {
+		x.Calls = append(x.Calls, jsonCall{cg.Name(e.From), cg.Name(e.To), hexAddr(e.Site), e.Tail})
+	}
+	for _, c := range cg.Indirect {
+		x.Indirect = append(x.Indirect, jsonCall{From: cg.Name(c.Func), Site: hexAddr(c.Site), Tail: c.Tail})
+	}
+	return json.Marshal(&x)
+}
+
+//-----------------------------------------------------------------------------
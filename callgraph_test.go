@@ -0,0 +1,70 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Call Graph Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_CallGraph(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0, stackTestCode...)
+	roots := append([]uint{0}, TrapHandlers(isa, code)...)
+	syms := NewSymbols()
+	syms.Add(0, "_start")
+	cg := NewCallGraph(isa, code, FindFuncs(isa, code, roots, syms), syms)
+
+	s := []string{}
+	for _, e := range cg.Edges {
+		s = append(s, fmt.Sprintf("%s->%s@%x", cg.Name(e.From), cg.Name(e.To), e.Site))
+	}
+	if strings.Join(s, " ") != "_start->sub_1a@12 sub_1a->sub_2c@22" {
+		t.Errorf("edges %s", strings.Join(s, " "))
+	}
+	if len(cg.Indirect) != 1 || cg.Indirect[0].Func != 0x34 || cg.Indirect[0].Site != 0x36 {
+		t.Errorf("indirect %v (expected [{34 36 false}])", cg.Indirect)
+	}
+
+	dot := cg.DOT()
+	if !strings.Contains(dot, "\"_start\" -> \"sub_1a\";") || !strings.Contains(dot, "\"sub_34\" -> \"<indirect>\"") {
+		t.Errorf("bad dot\n%s", dot)
+	}
+
+	buf, err := json.Marshal(cg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `{"from":"_start","to":"sub_1a","site":"0x12"}`) {
+		t.Errorf("bad json %s", buf)
+	}
+
+	// tail call
+	code = testCode(0,
+		0x2011, // 00: jal ra,4
+		0xa001, // 02: j 2
+		0x1141, // 04: addi sp,sp,-16
+		0x0141, // 06: addi sp,sp,16
+		0xa009, // 08: j a (tail call)
+		0x8082, // 0a: ret
+	)
+	cg = NewCallGraph(isa, code, FindFuncs(isa, code, []uint{0}, nil), nil)
+	if len(cg.Edges) != 2 || !cg.Edges[1].Tail || cg.Edges[1].To != 0xa {
+		t.Errorf("tail call edges %v", cg.Edges)
+	}
+}
+
+//-----------------------------------------------------------------------------
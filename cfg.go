@@ -0,0 +1,324 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Control Flow Graph
+
+Split code into basic blocks and link them with control flow edges.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// EdgeKind is the kind of a control flow graph edge.
+type EdgeKind int
+
+// Control flow graph edge kinds.
+const (
+	EdgeFallthrough EdgeKind = iota // fall through to the next block
+	EdgeTaken                       // taken branch or jump
+	EdgeCall                        // call to a function
+	EdgeReturn                      // return from a function to the call site
+	EdgeLoop                        // hardware loop end back to the loop start
+)
+
+var edgeName = map[EdgeKind]string{
+	EdgeFallthrough: "fallthrough",
+	EdgeTaken:       "taken",
+	EdgeCall:        "call",
+	EdgeReturn:      "return",
+	EdgeLoop:        "loop",
+}
+
+func (k EdgeKind) String() string {
+	return edgeName[k]
+}
+
+// Edge is a control flow graph edge between blocks.
+type Edge struct {
+	From uint     // source block address
+	To   uint     // destination block address
+	Kind EdgeKind // edge kind
+}
+
+// Block is a basic block.
+type Block struct {
+	Addr uint           // start address
+	End  uint           // end address (exclusive)
+	Ins  []*Disassembly // instructions in the block
+}
+
+// Last returns the last instruction of the block.
+func (b *Block) Last() *Disassembly {
+	return b.Ins[len(b.Ins)-1]
+}
+
+// CFG is a control flow graph.
+type CFG struct {
+	Entry  []uint   // entry addresses
+	Blocks []*Block // basic blocks sorted by address
+	Edges  []Edge   // control flow edges
+	index  map[uint]*Block
+	edges  map[Edge]bool   // edge set (for de-duplication)
+	succs  map[uint][]Edge // edges leaving each block
+	preds  map[uint][]Edge // edges entering each block
+}
+
+// Block returns the basic block starting at an address (or nil).
+func (g *CFG) Block(addr uint) *Block {
+	return g.index[addr]
+}
+
+// Succs returns the edges leaving a block.
+func (g *CFG) Succs(addr uint) []Edge {
+	return append([]Edge{}, g.succs[addr]...)
+}
+
+// Preds returns the edges entering a block.
+func (g *CFG) Preds(addr uint) []Edge {
+	return append([]Edge{}, g.preds[addr]...)
+}
+
+// addEdge adds an edge if the destination block exists and the edge is new.
+func (g *CFG) addEdge(from, to uint, kind EdgeKind) {
+	if g.index[to] == nil {
+		return
+	}
+	e := Edge{from, to, kind}
+	if g.edges[e] {
+		return
+	}
+	g.edges[e] = true
+	g.Edges = append(g.Edges, e)
+	g.succs[from] = append(g.succs[from], e)
+	g.preds[to] = append(g.preds[to], e)
+}
+
+//-----------------------------------------------------------------------------
+
+// NewCFG builds a control flow graph for the code reachable from the entry addresses.
+func NewCFG(isa *ISA, code *Code, entry []uint) *CFG {
+	ins := make(map[uint]*Disassembly)
+	leader := make(map[uint]bool)
+	var hwl hwLoops
+
+	// find the reachable instructions and the block leaders
+	work := make([]uint, 0, len(entry))
+	for _, pc := range entry {
+		leader[pc] = true
+		work = append(work, pc)
+	}
+	for len(work) > 0 {
+		pc := work[len(work)-1]
+		work = work[:len(work)-1]
+		for ins[pc] == nil {
+			x, ok := code.Fetch(pc)
+			if !ok {
+				break
+			}
+			da := isa.Disassemble(pc, x)
+			ins[pc] = da
+			if !isa.legal(x) {
+				// illegal instruction
+				break
+			}
+			hwl.set(da)
+			if da.Flow == FlowNext {
+				pc += da.InsLength
+				continue
+			}
+			for _, s := range da.Successors(pc) {
+				leader[s] = true
+				work = append(work, s)
+			}
+			break
+		}
+	}
+
+	g := &CFG{
+		Entry: entry,
+		index: make(map[uint]*Block),
+		edges: make(map[Edge]bool),
+		succs: make(map[uint][]Edge),
+		preds: make(map[uint][]Edge),
+	}
+
+	// hardware loops start and end blocks
+	for _, x := range hwl.loops {
+		leader[x[0]] = true
+		leader[x[1]] = true
+	}
+
+	// build the basic blocks
+	addrs := make([]uint, 0, len(leader))
+	for pc := range leader {
+		if ins[pc] != nil {
+			addrs = append(addrs, pc)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+	for _, pc := range addrs {
+		b := &Block{Addr: pc}
+		for {
+			da := ins[pc]
+			b.Ins = append(b.Ins, da)
+			pc += da.InsLength
+			if da.Flow != FlowNext || !isa.legal(da.Ins) || leader[pc] || ins[pc] == nil {
+				break
+			}
+		}
+		b.End = pc
+		g.Blocks = append(g.Blocks, b)
+		g.index[b.Addr] = b
+	}
+
+	// link the blocks
+	for _, b := range g.Blocks {
+		da := b.Last()
+		switch da.Flow {
+		case FlowNext:
+			if isa.legal(da.Ins) {
+				g.addEdge(b.Addr, b.End, EdgeFallthrough)
+			}
+		case FlowBranch:
+			g.addEdge(b.Addr, da.Target, EdgeTaken)
+			g.addEdge(b.Addr, b.End, EdgeFallthrough)
+		case FlowJump:
+			g.addEdge(b.Addr, da.Target, EdgeTaken)
+		case FlowCall:
+			g.addEdge(b.Addr, da.Target, EdgeCall)
+			g.addEdge(b.Addr, b.End, EdgeFallthrough)
+		case FlowIndirectCall, FlowTrap, FlowWfi:
+			g.addEdge(b.Addr, b.End, EdgeFallthrough)
+		}
+	}
+
+	// link hardware loop ends back to the loop starts
+	for _, x := range hwl.loops {
+		for _, b := range g.Blocks {
+			if b.End == x[1] {
+				g.addEdge(b.Addr, x[0], EdgeLoop)
+			}
+		}
+	}
+
+	// link function returns back to the call sites
+	for _, e := range g.Edges {
+		if e.Kind != EdgeCall {
+			continue
+		}
+		site := g.index[e.From].End
+		for _, r := range g.returns(e.To) {
+			g.addEdge(r, site, EdgeReturn)
+		}
+	}
+
+	return g
+}
+
+// returns returns the blocks ending in a return that are reachable from a
+// function entry without following calls.
+func (g *CFG) returns(entry uint) []uint {
+	ret := []uint{}
+	visited := map[uint]bool{entry: true}
+	work := []uint{entry}
+	for len(work) > 0 {
+		addr := work[len(work)-1]
+		work = work[:len(work)-1]
+		if g.index[addr].Last().Flow == FlowReturn {
+			ret = append(ret, addr)
+		}
+		for _, e := range g.succs[addr] {
+			if (e.Kind == EdgeTaken || e.Kind == EdgeFallthrough || e.Kind == EdgeLoop) && !visited[e.To] {
+				visited[e.To] = true
+				work = append(work, e.To)
+			}
+		}
+	}
+	return ret
+}
+
+//-----------------------------------------------------------------------------
+// Export
+
+// DOT returns the control flow graph in Graphviz DOT format.
+func (g *CFG) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph cfg {\n")
+	sb.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	for _, b := range g.Blocks {
+		lines := make([]string, len(b.Ins))
+		for i, da := range b.Ins {
+			lines[i] = fmt.Sprintf("%x: %s\\l", da.Addr, strings.ReplaceAll(da.Assembly, "\"", "\\\""))
+		}
+		sb.WriteString(fmt.Sprintf("\t\"%x\" [label=\"%s\"];\n", b.Addr, strings.Join(lines, "")))
+	}
+	for _, e := range g.Edges {
+		sb.WriteString(fmt.Sprintf("\t\"%x\" -> \"%x\" [label=\"%s\"];\n", e.From, e.To, e.Kind))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+type jsonIns struct {
+	Addr     string `json:"addr"`
+	Ins      string `json:"ins"`
+	Assembly string `json:"asm"`
+}
+
+type jsonBlock struct {
+	Addr string    `json:"addr"`
+	End  string    `json:"end"`
+	Ins  []jsonIns `json:"ins"`
+}
+
+type jsonEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+type jsonCFG struct {
+	Entry  []string    `json:"entry"`
+	Blocks []jsonBlock `json:"blocks"`
+	Edges  []jsonEdge  `json:"edges"`
+}
+
+// hexAddr returns an address as a JSON friendly hex string.
+func hexAddr(x uint) string {
+	return fmt.Sprintf("0x%x", x)
+}
+
+// MarshalJSON returns the control flow graph in JSON format.
+func (g *CFG) MarshalJSON() ([]byte, error) {
+	x := jsonCFG{
+		Entry:  []string{},
+		Blocks: []jsonBlock{},
+		Edges:  []jsonEdge{},
+	}
+	for _, pc := range g.Entry {
+		x.Entry = append(x.Entry, hexAddr(pc))
+	}
+	for _, b := range g.Blocks {
+		jb := jsonBlock{Addr: hexAddr(b.Addr), End: hexAddr(b.End)}
+		for _, da := range b.Ins {
+			jb.Ins = append(jb.Ins, jsonIns{hexAddr(da.Addr), hexAddr(da.Ins), da.Assembly})
+		}
+		x.Blocks = append(x.Blocks, jb)
+	}
+	for _, e := range g.Edges {
+		x.Edges = append(x.Edges, jsonEdge{hexAddr(e.From), hexAddr(e.To), e.Kind.String()})
+	}
+	return json.Marshal(&x)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,141 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Control Flow Graph Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+// testCode returns a code region containing a sequence of 16/32-bit instructions.
+func testCode(addr uint, ins ...uint) *Code {
+	data := []byte{}
+	for _, x := range ins {
+		n := 4
+		if x&3 != 3 {
+			n = 2
+		}
+		for i := 0; i < n; i++ {
+			data = append(data, byte(x>>(8*i)))
+		}
+	}
+	return &Code{Addr: addr, Data: data}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_CFG(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0x1000,
+		0x010000ef, // 1000: jal ra,1010
+		0x00050463, // 1004: beqz a0,100c
+		0x00150513, // 1008: addi a0,a0,1
+		0x0000006f, // 100c: j 100c
+		0x00500513, // 1010: li a0,5
+		0x00008067, // 1014: ret
+	)
+	g := NewCFG(isa, code, []uint{0x1000})
+
+	blocks := []string{}
+	for _, b := range g.Blocks {
+		blocks = append(blocks, fmt.Sprintf("%x-%x", b.Addr, b.End))
+	}
+	expected := "1000-1004 1004-1008 1008-100c 100c-1010 1010-1018"
+	if strings.Join(blocks, " ") != expected {
+		t.Errorf("blocks %v (expected %s)", blocks, expected)
+	}
+
+	edges := []Edge{
+		{0x1000, 0x1010, EdgeCall},
+		{0x1000, 0x1004, EdgeFallthrough},
+		{0x1004, 0x100c, EdgeTaken},
+		{0x1004, 0x1008, EdgeFallthrough},
+		{0x1008, 0x100c, EdgeFallthrough},
+		{0x100c, 0x100c, EdgeTaken},
+		{0x1010, 0x1004, EdgeReturn},
+	}
+	if fmt.Sprintf("%v", g.Edges) != fmt.Sprintf("%v", edges) {
+		t.Errorf("edges %v (expected %v)", g.Edges, edges)
+	}
+	if x := g.Succs(0x1004); fmt.Sprintf("%v", x) != fmt.Sprintf("%v", edges[2:4]) {
+		t.Errorf("succs %v (expected %v)", x, edges[2:4])
+	}
+	preds := []Edge{edges[2], edges[4], edges[5]}
+	if x := g.Preds(0x100c); fmt.Sprintf("%v", x) != fmt.Sprintf("%v", preds) {
+		t.Errorf("preds %v (expected %v)", x, preds)
+	}
+
+	dot := g.DOT()
+	if !strings.Contains(dot, "\"1010\" -> \"1004\" [label=\"return\"];") {
+		t.Errorf("bad dot output\n%s", dot)
+	}
+
+	buf, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), "{\"from\":\"0x1004\",\"to\":\"0x100c\",\"kind\":\"taken\"}") {
+		t.Errorf("bad json output\n%s", buf)
+	}
+}
+
+func Test_CFGIllegal(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0x1000,
+		0x00150513, // 1000: addi a0,a0,1
+		0x0000,     // 1004: c.illegal
+		0x00150513, // 1006: addi a0,a0,1
+	)
+	g := NewCFG(isa, code, []uint{0x1000})
+	if len(g.Blocks) != 1 || g.Blocks[0].End != 0x1006 || len(g.Edges) != 0 {
+		t.Errorf("blocks %v edges %v (expected a single block 1000-1006)", g.Blocks, g.Edges)
+	}
+}
+
+func Test_CFGHwLoop(t *testing.T) {
+	isa, err := Parse("rv32imc_xcvhwlp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0x1000,
+		0x00a1c62b, // 1000: cv.setupi 0,10,100c
+		0x00150513, // 1004: addi a0,a0,1
+		0x00158593, // 1008: addi a1,a1,1
+		0x00008067, // 100c: ret
+	)
+	g := NewCFG(isa, code, []uint{0x1000})
+	blocks := []string{}
+	for _, b := range g.Blocks {
+		blocks = append(blocks, fmt.Sprintf("%x-%x", b.Addr, b.End))
+	}
+	expected := "1000-1004 1004-100c 100c-1010"
+	if strings.Join(blocks, " ") != expected {
+		t.Errorf("blocks %v (expected %s)", blocks, expected)
+	}
+	edges := []Edge{
+		{0x1000, 0x1004, EdgeFallthrough},
+		{0x1004, 0x100c, EdgeFallthrough},
+		{0x1004, 0x1004, EdgeLoop},
+	}
+	if fmt.Sprintf("%v", g.Edges) != fmt.Sprintf("%v", edges) {
+		t.Errorf("edges %v (expected %v)", g.Edges, edges)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,178 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Control Flow Integrity
+
+Zicfilp landing pads (lpad) mark the valid targets of indirect jumps and
+calls. Zicfiss shadow stacks (sspush, sspopchk) hold a second copy of the
+return addresses at the address in CSR ssp.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+// shadow stacks
+
+// memTypeSSPush is a shadow stack push (below ssp).
+func memTypeSSPush(m *MemAccess, ins, mxlen uint) {
+	m.Size, m.Align = mxlen>>3, mxlen>>3
+	m.Base, m.Offset = regSSP, -int(m.Size)
+}
+
+// memTypeSSPop is a shadow stack pop (at ssp).
+func memTypeSSPop(m *MemAccess, ins, mxlen uint) {
+	m.Size, m.Align = mxlen>>3, mxlen>>3
+	m.Base = regSSP
+}
+
+//-----------------------------------------------------------------------------
+// landing pads
+
+// isLandingPad returns true if the instruction is a landing pad.
+func (da *Disassembly) isLandingPad() bool {
+	return da.id() == "lpad"
+}
+
+// needsLandingPad returns true if the indirect jump/call requires its target
+// to be a landing pad. Jumps via ra/t0 (returns, calls) and t2 (software
+// guarded) don't.
+func (da *Disassembly) needsLandingPad() bool {
+	var rs1 uint
+	switch da.id() {
+	case "jalr":
+		_, rs1, _ = decodeIa(da.Ins)
+	case "c.jr", "c.jalr":
+		rs1, _ = decodeCR(da.Ins)
+	default:
+		return false
+	}
+	return !isLink(rs1) && rs1 != 7
+}
+
+// CFIKind is how an audited target is reached.
+type CFIKind int
+
+// Landing pad target kinds.
+const (
+	CFIEntry    CFIKind = iota // exported function entry
+	CFIIndirect                // target of an auipc/jalr pair
+	CFIAddr                    // address taken by an auipc/addi pair (la)
+	CFIData                    // code pointer in data (e.g. a jump table)
+)
+
+var cfiKindName = map[CFIKind]string{
+	CFIEntry:    "exported entry",
+	CFIIndirect: "indirect target",
+	CFIAddr:     "address taken",
+	CFIData:     "code pointer",
+}
+
+func (k CFIKind) String() string {
+	return cfiKindName[k]
+}
+
+// CFIViolation is an indirectly reachable target without a landing pad.
+type CFIViolation struct {
+	Kind CFIKind // how the target is reached
+	Func uint    // function containing the site (0 if none)
+	Addr uint    // target address
+	Site uint    // address of the jump/call, la pair or code pointer (the entry if exported)
+}
+
+func (v CFIViolation) String() string {
+	switch v.Kind {
+	case CFIEntry:
+		return fmt.Sprintf("%x: exported entry without lpad", v.Addr)
+	case CFIIndirect:
+		return fmt.Sprintf("%x: indirect target of %x without lpad", v.Addr, v.Site)
+	}
+	return fmt.Sprintf("%x: %s at %x without lpad", v.Addr, v.Kind, v.Site)
+}
+
+// funcAt returns the address of the function containing an address (or 0).
+func funcAt(funcs []*Func, pc uint) uint {
+	var addr uint
+	for _, f := range funcs {
+		if pc >= f.Addr && pc < f.End {
+			addr = f.Addr
+		}
+	}
+	return addr
+}
+
+// AuditLandingPads checks that the targets reachable by indirect jumps and
+// calls begin with an lpad instruction. The audited targets are the targets
+// of auipc/jalr pairs, the addresses taken by auipc/addi pairs (la), the
+// code pointers found in the data regions (e.g. function pointer and jump
+// tables) and the exported entries (e.g. dynamic symbols) that may be called
+// indirectly from outside the code. Functions that are only called directly
+// don't need a landing pad. Addresses taken and code pointers must decode as
+// instructions in the code region. Jumps via ra/t0 (returns, calls) and t2
+// (software guarded) don't need a landing pad. The function set (see
+// FindFuncs) locates the sites. Each target is reported once (in address
+// order).
+func AuditLandingPads(isa *ISA, code *Code, funcs []*Func, exported []uint, data ...*Code) []CFIViolation {
+	ff := &funcFinder{
+		isa:  isa,
+		code: code,
+		ins:  make(map[uint]*Disassembly),
+	}
+	var v []CFIViolation
+	done := make(map[uint]bool)
+	check := func(kind CFIKind, fn, target, site uint) {
+		if done[target] {
+			return
+		}
+		done[target] = true
+		if lp := ff.decode(target); lp == nil || !lp.isLandingPad() {
+			v = append(v, CFIViolation{kind, fn, target, site})
+		}
+	}
+
+	// auipc pairs
+	lines := Sweep(isa, code).Lines
+	for i := 0; i+1 < len(lines); i++ {
+		line, next := lines[i], lines[i+1]
+		if line.Da == nil || next.Da == nil {
+			continue
+		}
+		addr, ok := isa.auipcPair(line.Da, next.Da)
+		if !ok {
+			continue
+		}
+		switch {
+		case next.Da.needsLandingPad():
+			check(CFIIndirect, funcAt(funcs, next.Addr), addr, next.Addr)
+		case next.Da.id() == "addi" && ff.decode(addr) != nil:
+			check(CFIAddr, funcAt(funcs, line.Addr), addr, line.Addr)
+		}
+	}
+
+	// code pointers
+	n := isa.mxlen >> 3
+	for _, d := range data {
+		for addr := (d.Addr + n - 1) &^ (n - 1); addr+n <= d.End(); addr += n {
+			x, _ := d.read(addr, n)
+			if x != 0 && x%isa.ialign() == 0 && ff.decode(x) != nil {
+				check(CFIData, 0, x, addr)
+			}
+		}
+	}
+
+	// exported entries
+	for _, pc := range exported {
+		check(CFIEntry, pc, pc, pc)
+	}
+
+	sort.Slice(v, func(i, j int) bool { return v[i].Addr < v[j].Addr })
+	return v
+}
+
+//-----------------------------------------------------------------------------
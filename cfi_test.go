@@ -0,0 +1,170 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Control Flow Integrity Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+var cfiTest = []struct {
+	ins  uint   // instruction code
+	da   string // expected disassembly
+	uses string // expected registers read
+	defs string // expected registers written
+	mem  string // expected memory access
+}{
+	{0x00001017, "lpad 0x1", "{t2}", "{}", "<nil>"},
+	{0x00000017, "lpad 0x0", "{}", "{}", "<nil>"},
+	{0xce104073, "sspush ra", "{ra,ssp}", "{ssp}", "store64 -8(ssp)"},
+	{0xce504073, "sspush t0", "{t0,ssp}", "{ssp}", "store64 -8(ssp)"},
+	{0xcdc2c073, "sspopchk t0", "{t0,ssp}", "{ssp}", "load64 0(ssp)"},
+	{0xcdc04573, "ssrdp a0", "{ssp}", "{a0}", "<nil>"},
+	{0x48b6252f, "ssamoswap.w a0,a1,(a2)", "{a2,a1}", "{a0}", "amo32 0(a2)"},
+	{0x48b6352f, "ssamoswap.d a0,a1,(a2)", "{a2,a1}", "{a0}", "amo64 0(a2)"},
+	{0x6081, "sspush ra", "{ra,ssp}", "{ssp}", "store64 -8(ssp)"},
+	{0x6281, "sspopchk t0", "{t0,ssp}", "{ssp}", "load64 0(ssp)"},
+	{0xcec5c573, "mop.rr.7 a0,a1,a2", "{a1,a2}", "{a0}", "<nil>"},
+	{0x6381, "c.mop.7", "{}", "{}", "<nil>"},
+}
+
+func Test_CFI(t *testing.T) {
+	isa, err := Parse("rv64gc_zicfilp_zicfiss_zcmop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range cfiTest {
+		da := isa.Disassemble(0, v.ins)
+		mem := fmt.Sprintf("%v", da.Mem)
+		if da.Assembly != v.da || da.Uses.String() != v.uses || da.Defs.String() != v.defs || mem != v.mem {
+			t.Errorf("%08x: \"%s\" uses %s defs %s mem %s (expected \"%s\" %s %s %s)",
+				v.ins, da.Assembly, da.Uses, da.Defs, mem, v.da, v.uses, v.defs, v.mem)
+		}
+	}
+	// without the extensions
+	isa, _ = Parse("rv64gc_zimop_zcmop")
+	for _, v := range []daTest{
+		{0, 0x00001017, "auipc zero,0x1"},
+		{0, 0xce104073, "mop.rr.7 zero,zero,ra"},
+		{0, 0x6081, "c.mop.1"},
+		{0, 0x48b6252f, "illegal"},
+	} {
+		if da := isa.Disassemble(v.pc, v.ins); da.Assembly != v.da {
+			t.Errorf("%08x: \"%s\" (expected \"%s\")", v.ins, da.Assembly, v.da)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// cfiTestCode is built with landing pads: main calls two functions via
+// function pointers (the second is missing its lpad) and an exported hand
+// written helper without one, dispatch jumps via a jump table (cfiTestData).
+var cfiTestCode = []uint{
+	0x00000017, // 00: lpad 0x0 (main)
+	0x1141,     // 04: addi sp,sp,-16
+	0xc606,     // 06: sw ra,12(sp)
+	0x00000517, // 08: auipc a0,0x0
+	0x02850513, // 0c: addi a0,a0,40 (la a0,30)
+	0x9502,     // 10: jalr a0
+	0x00000517, // 12: auipc a0,0x0
+	0x02e50513, // 16: addi a0,a0,46 (la a0,40)
+	0x9502,     // 1a: jalr a0
+	0x00000097, // 1c: auipc ra,0x0
+	0x02c080e7, // 20: jalr 44(ra) (call 48)
+	0x40b2,     // 24: lw ra,12(sp)
+	0x0141,     // 26: addi sp,sp,16
+	0x8082,     // 28: ret
+	0x0001,     // 2a: nop
+	0x0001,     // 2c: nop
+	0x0001,     // 2e: nop
+	0x00000017, // 30: lpad 0x0
+	0x4505,     // 34: li a0,1
+	0x8082,     // 36: ret
+	0x0001,     // 38: nop
+	0x0001,     // 3a: nop
+	0x0001,     // 3c: nop
+	0x0001,     // 3e: nop
+	0x4509,     // 40: li a0,2 (address taken, no lpad)
+	0x8082,     // 42: ret
+	0x0001,     // 44: nop
+	0x0001,     // 46: nop
+	0x00300513, // 48: li a0,3 (helper, no lpad)
+	0x8082,     // 4c: ret
+	0x0001,     // 4e: nop
+	0x00000017, // 50: lpad 0x0 (dispatch)
+	0x00000797, // 54: auipc a5,0x0
+	0x0ac78793, // 58: addi a5,a5,172 (la a5,100)
+	0x050a,     // 5c: slli a0,a0,2
+	0x953e,     // 5e: add a0,a0,a5
+	0x4108,     // 60: lw a0,0(a0)
+	0x8502,     // 62: jr a0
+	0x00000017, // 64: lpad 0x0 (case 0)
+	0x8082,     // 68: ret
+	0x4501,     // 6a: li a0,0 (case 1, no lpad)
+	0x8082,     // 6c: ret
+}
+
+// cfiTestData is the dispatch jump table.
+var cfiTestData = &Code{Addr: 0x100, Data: []byte{0x64, 0, 0, 0, 0x6a, 0, 0, 0}}
+
+func Test_AuditLandingPads(t *testing.T) {
+	isa, err := Parse("rv32imac_zicfilp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0, cfiTestCode...)
+	funcs := FindFuncs(isa, code, []uint{0}, NewSymbols())
+	s := []string{}
+	for _, v := range AuditLandingPads(isa, code, funcs, []uint{0, 0x48}, cfiTestData) {
+		s = append(s, v.String())
+	}
+	expected := []string{
+		"40: address taken at 12 without lpad",
+		"48: exported entry without lpad",
+		"6a: code pointer at 104 without lpad",
+	}
+	if strings.Join(s, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("violations\n%s\n(expected)\n%s", strings.Join(s, "\n"), strings.Join(expected, "\n"))
+	}
+
+	// the helper is only called directly when it isn't exported
+	if v := AuditLandingPads(isa, code, funcs, nil, cfiTestData); len(v) != 2 {
+		t.Errorf("%d violations (expected 2)", len(v))
+	}
+
+	// a call via t1 needs a landing pad, a call via t2 doesn't
+	code = testCode(0,
+		0x00000017, // 00: lpad 0x0
+		0x00000317, // 04: auipc t1,0x0
+		0x014300e7, // 08: jalr ra,20(t1)
+		0x00000397, // 0c: auipc t2,0x0
+		0x014380e7, // 10: jalr ra,20(t2)
+		0xa001,     // 14: j 14
+		0x0001,     // 16: nop
+		0x4505,     // 18: li a0,1
+		0x8082,     // 1a: ret
+		0x0001,     // 1c: nop
+		0x0001,     // 1e: nop
+		0x4509,     // 20: li a0,2
+		0x8082,     // 22: ret
+	)
+	s = s[:0]
+	for _, v := range AuditLandingPads(isa, code, nil, nil) {
+		s = append(s, v.String())
+	}
+	if strings.Join(s, "\n") != "18: indirect target of 8 without lpad" {
+		t.Errorf("violations %s (expected 18: indirect target of 8 without lpad)", strings.Join(s, ", "))
+	}
+}
+
+//-----------------------------------------------------------------------------
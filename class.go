@@ -0,0 +1,200 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Instruction Classification
+
+Classify an instruction encoding as normal, a HINT, reserved or illegal.
+HINTs are encodings that don't change the architectural state (mostly
+writes to x0) and are set aside for performance hints. Reserved encodings
+are set aside for future standard or custom use.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+//-----------------------------------------------------------------------------
+
+// Class is the classification of an instruction encoding.
+type Class int
+
+// Instruction classes.
+const (
+	ClassNormal   Class = iota // a normal instruction
+	ClassHint                  // a HINT encoding
+	ClassReserved              // a reserved encoding
+	ClassIllegal               // an illegal encoding
+)
+
+func (c Class) String() string {
+	switch c {
+	case ClassHint:
+		return "hint"
+	case ClassReserved:
+		return "reserved"
+	case ClassIllegal:
+		return "illegal"
+	}
+	return "normal"
+}
+
+//-----------------------------------------------------------------------------
+
+// ntlHints are the Zihintntl hints (add x0,x0,rs2).
+var ntlHints = map[uint]string{
+	2: "ntl.p1",
+	3: "ntl.pall",
+	4: "ntl.s1",
+	5: "ntl.all",
+}
+
+// cntlHints are the compressed Zihintntl hints (c.add x0,rs2).
+var cntlHints = map[uint]string{
+	2: "c.ntl.p1",
+	3: "c.ntl.pall",
+	4: "c.ntl.s1",
+	5: "c.ntl.all",
+}
+
+// classify returns the class of an instruction and the name of the hint
+// (if the hint is a ratified standard hint).
+func (isa *ISA) classify(im *insMeta, ins uint) (Class, string) {
+	if im == nil || im.id == "c.illegal" {
+		return ClassIllegal, ""
+	}
+	if im.n == 16 {
+		return isa.classify16(im, ins)
+	}
+
+	rd := bitUnsigned(ins, 11, 7, 0)
+	rs1 := bitUnsigned(ins, 19, 15, 0)
+	rs2 := bitUnsigned(ins, 24, 20, 0)
+
+	switch im.id {
+	case "fence":
+		fm := bitUnsigned(ins, 31, 28, 0)
+		pred := bitUnsigned(ins, 27, 24, 0)
+		succ := bitUnsigned(ins, 23, 20, 0)
+		if fm == 8 && pred == 3 && succ == 3 {
+			// fence.tso
+			return ClassNormal, ""
+		}
+		if fm != 0 || rd != 0 || rs1 != 0 {
+			return ClassReserved, ""
+		}
+		if pred == 1 && succ == 0 {
+			return ClassHint, "pause"
+		}
+		if pred == 0 || succ == 0 {
+			return ClassHint, ""
+		}
+	case "slli", "srli", "srai":
+		if isa.mxlen == 32 && ins&(1<<25) != 0 {
+			// shamt[5] is reserved for RV32
+			return ClassReserved, ""
+		}
+		if rd == 0 {
+			return ClassHint, ""
+		}
+	case "rori":
+		if isa.mxlen == 32 && ins&(1<<25) != 0 {
+			return ClassReserved, ""
+		}
+	case "aes64ks1i":
+		if bitUnsigned(ins, 23, 20, 0) > 10 {
+			// round numbers 0xb-0xf are reserved
+			return ClassReserved, ""
+		}
+	case "addi":
+		if rd == 0 && (rs1 != 0 || ins>>20 != 0) {
+			return ClassHint, ""
+		}
+	case "add":
+		if rd == 0 {
+			if rs1 == 0 {
+				if name, ok := ntlHints[rs2]; ok {
+					return ClassHint, name
+				}
+			}
+			return ClassHint, ""
+		}
+	case "lui", "auipc", "slti", "sltiu", "xori", "ori", "andi",
+		"sub", "sll", "slt", "sltu", "xor", "srl", "sra", "or", "and",
+		"addiw", "slliw", "srliw", "sraiw", "addw", "subw", "sllw", "srlw", "sraw":
+		if rd == 0 {
+			return ClassHint, ""
+		}
+	}
+	return ClassNormal, ""
+}
+
+// classify16 returns the class of a compressed instruction.
+func (isa *ISA) classify16(im *insMeta, ins uint) (Class, string) {
+	rd := bitUnsigned(ins, 11, 7, 0)
+	rs2 := bitUnsigned(ins, 6, 2, 0)
+	imm := bitUnsigned(ins, 12, 12, 5) + bitUnsigned(ins, 6, 2, 0)
+
+	switch im.id {
+	case "c.nop":
+		if imm != 0 {
+			return ClassHint, ""
+		}
+	case "c.addi":
+		if imm == 0 {
+			return ClassHint, ""
+		}
+	case "c.li":
+		if rd == 0 {
+			return ClassHint, ""
+		}
+	case "c.lui":
+		if imm == 0 {
+			return ClassReserved, ""
+		}
+		if rd == 0 {
+			return ClassHint, ""
+		}
+	case "c.addi16sp":
+		if imm == 0 {
+			return ClassReserved, ""
+		}
+	case "c.addi4spn":
+		if bitUnsigned(ins, 12, 5, 0) == 0 {
+			return ClassReserved, ""
+		}
+	case "c.slli", "c.srli", "c.srai":
+		if isa.mxlen == 32 && ins&(1<<12) != 0 {
+			// shamt[5] is reserved for custom use with RV32C
+			return ClassReserved, ""
+		}
+		if imm == 0 || (im.id == "c.slli" && rd == 0) {
+			return ClassHint, ""
+		}
+	case "c.mv":
+		if rd == 0 {
+			return ClassHint, ""
+		}
+	case "c.add":
+		if rd == 0 {
+			if name, ok := cntlHints[rs2]; ok {
+				return ClassHint, name
+			}
+			return ClassHint, ""
+		}
+	case "c.lwsp", "c.ldsp", "c.jr", "c.addiw":
+		if rd == 0 {
+			return ClassReserved, ""
+		}
+	case "cm.push", "cm.pop", "cm.popret", "cm.popretz":
+		if rlist, _ := decodeCMPP(ins); rlist < 4 {
+			return ClassReserved, ""
+		}
+	case "cm.mvsa01":
+		if r1s, r2s := decodeCMMV(ins); r1s == r2s {
+			return ClassReserved, ""
+		}
+	}
+	return ClassNormal, ""
+}
+
+//-----------------------------------------------------------------------------
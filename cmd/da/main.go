@@ -3,15 +3,25 @@
 
 Example code for the rvda package.
 
+Disassemble the executable sections of a RISC-V ELF file, or (with no file)
+a set of random instructions.
+
+da [-i isa] [-m mode] [-e addr,...] [-a abi] [-p] [-c] [-s] file
+da callgraph [-f dot|json] [-i isa] file
+
 */
 //-----------------------------------------------------------------------------
 
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/deadsy/rvda"
 )
@@ -36,8 +46,182 @@ func disassemble() error {
 
 //-----------------------------------------------------------------------------
 
+// parseAddrs parses a comma separated list of addresses.
+func parseAddrs(s string) ([]uint, error) {
+	addrs := []uint{}
+	if s == "" {
+		return addrs, nil
+	}
+	for _, x := range strings.Split(s, ",") {
+		addr, err := strconv.ParseUint(strings.TrimSpace(x), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad address \"%s\"", x)
+		}
+		addrs = append(addrs, uint(addr))
+	}
+	return addrs, nil
+}
+
+// newISA returns the ISA for a program. The ISA string (if any) overrides
+// the ISA string from the ELF attributes.
+func newISA(p *rvda.Program, arch string) (*rvda.ISA, error) {
+	var isa *rvda.ISA
+	var err error
+	switch {
+	case arch != "":
+		isa, err = rvda.Parse(arch)
+	case p.Arch != "":
+		isa, err = rvda.ParseArch(p.Arch)
+	case p.Mxlen == 64:
+		isa, err = rvda.New(64, rvda.RV64gc)
+	default:
+		isa, err = rvda.New(32, rvda.RV32gc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.JVT != nil {
+		isa.SetJVT(p.JVT.Addr, p.JVT)
+	}
+	return isa, nil
+}
+
+// disassembleELF disassembles the executable sections of an ELF file.
+func disassembleELF(filename, arch, mode, abi string, entry []uint, pseudo, fold, stack bool) error {
+	p, err := rvda.LoadELF(filename)
+	if err != nil {
+		return err
+	}
+	isa, err := newISA(p, arch)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", isa)
+
+	// entry points: elf entry, function symbols, user addresses
+	entry = append(entry, p.Entry)
+	entry = append(entry, p.Funcs...)
+
+	for _, code := range p.Code {
+		// find functions (and name those without symbols)
+		funcs := rvda.FindFuncs(isa, code, entry, p.Symbols)
+		for _, f := range funcs {
+			entry = append(entry, f.Addr)
+		}
+		var l *rvda.Listing
+		switch mode {
+		case "linear":
+			l = rvda.Sweep(isa, code)
+		case "recursive":
+			l = rvda.Traverse(isa, code, entry)
+		default:
+			return fmt.Errorf("unknown mode \"%s\"", mode)
+		}
+		l.Resolve(isa, p.Symbols, pseudo)
+		l.Fold(isa, p.Symbols, p.Known(), fold)
+		l.Semihosting(isa, p.Symbols)
+		switch abi {
+		case "linux":
+			l.Ecalls(isa, p.Symbols, rvda.EcallLinux)
+		case "sbi":
+			l.Ecalls(isa, p.Symbols, rvda.EcallSBI)
+		case "none":
+		default:
+			return fmt.Errorf("unknown ecall abi \"%s\"", abi)
+		}
+		for _, line := range l.Lines {
+			if name, ok := p.Symbols.Name(line.Addr); ok {
+				fmt.Printf("\n%s:\n", name)
+			}
+			fmt.Printf("%s\n", line)
+		}
+		for _, c := range l.Conflicts {
+			fmt.Fprintf(os.Stderr, "conflict: %s\n", c)
+		}
+		if stack {
+			// roots: elf entry, trap handlers
+			roots := []uint{}
+			for _, addr := range append([]uint{p.Entry}, rvda.TrapHandlers(isa, code)...) {
+				if code.Contains(addr, 2) {
+					roots = append(roots, addr)
+				}
+			}
+			su := rvda.NewStackUsage(isa, code, rvda.FindFuncs(isa, code, roots, p.Symbols))
+			fmt.Printf("\nstack usage:\n%s\n", su.Report(roots, p.Symbols))
+		}
+	}
+	return nil
+}
+
+// callGraph outputs the call graph of an ELF file.
+func callGraph(filename, arch, format string) error {
+	p, err := rvda.LoadELF(filename)
+	if err != nil {
+		return err
+	}
+	isa, err := newISA(p, arch)
+	if err != nil {
+		return err
+	}
+	cg := p.CallGraph(isa)
+	switch format {
+	case "dot":
+		fmt.Printf("%s", cg.DOT())
+	case "json":
+		buf, err := json.MarshalIndent(cg, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", buf)
+	default:
+		return fmt.Errorf("unknown format \"%s\"", format)
+	}
+	return nil
+}
+
+// callGraphCmd is the callgraph subcommand.
+func callGraphCmd(args []string) error {
+	fs := flag.NewFlagSet("callgraph", flag.ExitOnError)
+	format := fs.String("f", "dot", "output format (dot, json)")
+	arch := fs.String("i", "", "ISA string (e.g. rv32imac_zcb_zcmp)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: da callgraph [-f dot|json] [-i isa] file")
+	}
+	return callGraph(fs.Arg(0), *arch, *format)
+}
+
+//-----------------------------------------------------------------------------
+
 func main() {
-	err := disassemble()
+	if len(os.Args) > 1 && os.Args[1] == "callgraph" {
+		if err := callGraphCmd(os.Args[2:]); err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	mode := flag.String("m", "linear", "disassembly mode (linear, recursive)")
+	arch := flag.String("i", "", "ISA string (e.g. rv32imac_zcb_zcmp)")
+	addrs := flag.String("e", "", "additional entry addresses (comma separated)")
+	abi := flag.String("a", "none", "ecall abi (none, linux, sbi)")
+	pseudo := flag.Bool("p", false, "render auipc pairs as call/tail/la pseudo instructions")
+	fold := flag.Bool("c", false, "collapse constant building sequences to li pseudo instructions")
+	stack := flag.Bool("s", false, "report the worst case stack depth")
+	flag.Parse()
+
+	var err error
+	if flag.NArg() == 0 {
+		err = disassemble()
+	} else {
+		var entry []uint
+		entry, err = parseAddrs(*addrs)
+		if err == nil {
+			err = disassembleELF(flag.Arg(0), *arch, *mode, *abi, entry, *pseudo, *fold, *stack)
+		}
+	}
+
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		os.Exit(1)
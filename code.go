@@ -0,0 +1,57 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Code Memory
+
+A region of code bytes at a base address.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+//-----------------------------------------------------------------------------
+
+// Code is a region of code bytes at a base address.
+type Code struct {
+	Addr uint   // base address
+	Data []byte // code bytes (little endian)
+}
+
+// End returns the address after the last code byte.
+func (c *Code) End() uint {
+	return c.Addr + uint(len(c.Data))
+}
+
+// Contains returns true if the address range [addr, addr+n) is within the code.
+func (c *Code) Contains(addr, n uint) bool {
+	return addr >= c.Addr && addr+n <= c.End() && addr+n > addr
+}
+
+// read returns n (2 or 4) bytes at the address.
+func (c *Code) read(addr, n uint) (uint, bool) {
+	if !c.Contains(addr, n) {
+		return 0, false
+	}
+	x := uint(0)
+	for i := n; i > 0; i-- {
+		x = (x << 8) | uint(c.Data[addr-c.Addr+i-1])
+	}
+	return x, true
+}
+
+// Fetch returns the 16/32-bit instruction at the address.
+func (c *Code) Fetch(addr uint) (uint, bool) {
+	ins, ok := c.read(addr, 2)
+	if !ok {
+		return 0, false
+	}
+	if ins&3 != 3 {
+		// 16-bit instruction
+		return ins, true
+	}
+	// 32-bit instruction
+	return c.read(addr, 4)
+}
+
+//-----------------------------------------------------------------------------
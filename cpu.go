@@ -262,4 +262,68 @@ func decodeCB(ins uint) (int, uint) {
 	return imm, rs
 }
 
+func decodeCLB(ins uint) (uint, uint, uint) {
+	uimm := bitUnsigned(ins, 6, 6, 0) // imm[0]
+	uimm += bitUnsigned(ins, 5, 5, 1) // imm[1]
+	rs1 := bitUnsigned(ins, 9, 7, 0) + 8
+	rs2 := bitUnsigned(ins, 4, 2, 0) + 8
+	return uimm, rs1, rs2
+}
+
+func decodeCLH(ins uint) (uint, uint, uint) {
+	uimm := bitUnsigned(ins, 5, 5, 1) // imm[1]
+	rs1 := bitUnsigned(ins, 9, 7, 0) + 8
+	rs2 := bitUnsigned(ins, 4, 2, 0) + 8
+	return uimm, rs1, rs2
+}
+
+func decodeCU(ins uint) uint {
+	return bitUnsigned(ins, 9, 7, 0) + 8
+}
+
+// sreg maps an s register index to a register number (s0-s11).
+func sreg(x uint) uint {
+	if x < 2 {
+		return x + 8
+	}
+	return x + 16
+}
+
+func decodeCMMV(ins uint) (uint, uint) {
+	r1s := sreg(bitUnsigned(ins, 9, 7, 0))
+	r2s := sreg(bitUnsigned(ins, 4, 2, 0))
+	return r1s, r2s
+}
+
+func decodeCMPP(ins uint) (uint, uint) {
+	rlist := bitUnsigned(ins, 7, 4, 0)
+	spimm := bitUnsigned(ins, 3, 2, 4) // spimm[5:4]
+	return rlist, spimm
+}
+
+func decodeCMJT(ins uint) uint {
+	return bitUnsigned(ins, 9, 2, 0)
+}
+
+func decodeV(ins uint) (uint, uint, uint, uint) {
+	vm := bitUnsigned(ins, 25, 25, 0)
+	vs2 := bitUnsigned(ins, 24, 20, 0)
+	vs1 := bitUnsigned(ins, 19, 15, 0) // vs1, rs1 or uimm[4:0]
+	vd := bitUnsigned(ins, 11, 7, 0)
+	return vm, vs2, vs1, vd
+}
+
+func decodeXCVHwlp(ins uint) (uint, uint, uint) {
+	uimm := bitUnsigned(ins, 31, 20, 0)
+	rs1 := bitUnsigned(ins, 19, 15, 0) // rs1 or uimms[4:0]
+	l := bitUnsigned(ins, 7, 7, 0)
+	return uimm, rs1, l
+}
+
+func decodeXCVImm6(ins uint) uint {
+	uimm := bitUnsigned(ins, 25, 25, 0) // imm6[0]
+	uimm += bitUnsigned(ins, 24, 20, 1) // imm6[5:1]
+	return uimm
+}
+
 //-----------------------------------------------------------------------------
@@ -70,9 +70,12 @@ func fmtExt(ext uint) string {
 
 // Register numbers for specific CSRs.
 const (
-	csrFFLAGS = 0x001
-	csrFRM    = 0x002
-	csrFCSR   = 0x003
+	csrFFLAGS   = 0x001
+	csrFRM      = 0x002
+	csrFCSR     = 0x003
+	csrSSP      = 0x011
+	csrJVT      = 0x017
+	csrLPSTART0 = 0xcc0
 )
 
 //-----------------------------------------------------------------------------
@@ -85,6 +88,9 @@ var csrLookup = map[uint]string{
 	0x003: "fcsr",
 	0x004: "uie",
 	0x005: "utvec",
+	0x011: "ssp",
+	0x015: "seed",
+	0x017: "jvt",
 	0x040: "uscratch",
 	0x041: "uepc",
 	0x042: "ucause",
@@ -156,6 +162,15 @@ var csrLookup = map[uint]string{
 	0xc9d: "hpmcounter29h",
 	0xc9e: "hpmcounter30h",
 	0xc9f: "hpmcounter31h",
+	// CORE-V custom user CSRs 0xcc0 - 0xcff (read only)
+	0xcc0: "lpstart0",
+	0xcc1: "lpend0",
+	0xcc2: "lpcount0",
+	0xcc4: "lpstart1",
+	0xcc5: "lpend1",
+	0xcc6: "lpcount1",
+	0xcd0: "uhartid",
+	0xcd1: "privlv",
 	// Supervisor CSRs 0x100 - 0x1ff (read/write)
 	0x100: "sstatus",
 	0x102: "sedeleg",
@@ -318,6 +333,9 @@ var csrLookup = map[uint]string{
 	0x7b0: "dcsr",
 	0x7b1: "dpc",
 	0x7b2: "dscratch",
+	// SiFive custom machine CSRs 0x7c0 - 0x7ff (read/write)
+	0x7c0: "bpm",
+	0x7c1: "featuredisable",
 	// Hypervisor CSRs 0x200 - 0x2ff (read/write)
 	0x200: "hstatus",
 	0x202: "hedeleg",
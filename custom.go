@@ -0,0 +1,260 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Custom Instructions
+
+User defined instructions (e.g. an accelerator in the custom-0..3 opcode
+space) are added to an ISA at runtime. The definition strings have the
+same format as the built-in instruction tables: fixed bits and named fields
+(msb first) followed by the mnemonic. Fields other than the standard ones
+are declared with their bit widths.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// Fields are the values of the named fields of an instruction.
+type Fields map[string]uint
+
+// CustomFunc returns the assembly string of a user defined instruction.
+type CustomFunc func(name string, pc, ins uint, f Fields) string
+
+// CustomIns is a user defined instruction.
+type CustomIns struct {
+	// Defn is the definition string, e.g. "0000 acc rs2 rs1 000 rd 0001011 ACC.MAC".
+	Defn string
+	// Format is the assembly template, e.g. "{name}.{acc} {rd},{rs1},{rs2}".
+	// Register fields (rd, rs1, fd, vs2, ...) are formatted as register
+	// names, other fields as unsigned decimal. "{f:x}" is hex, "{f:s}" is
+	// signed decimal. The default is the name followed by the destination
+	// registers, the source registers (rs1, rs2, rs3) and the other fields.
+	Format string
+	// Func formats the instruction (used instead of Format if not nil).
+	Func CustomFunc
+}
+
+// CustomExt is a set of user defined instructions.
+type CustomExt struct {
+	Name   string         // sub-extension name (e.g. "xacme"), optional
+	Fields map[string]int // bit widths of the non-standard fields
+	Ins    []CustomIns    // instruction definitions
+}
+
+//-----------------------------------------------------------------------------
+
+// customLength returns the bit length of a definition string.
+func customLength(defn string, fields map[string]int) (int, error) {
+	parts := strings.Split(defn, " ")
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("bad instruction definition string \"%s\"", defn)
+	}
+	n := 0
+	for _, x := range parts[:len(parts)-1] {
+		if isBits(x) {
+			n += len(x)
+		} else if w, err := isField(x); err == nil {
+			n += w
+		} else if w, ok := fields[x]; ok {
+			n += w
+		} else {
+			return 0, err
+		}
+	}
+	if n != 16 && n != 32 {
+		return 0, fmt.Errorf("instruction length %d != 16/32 \"%s\"", n, defn)
+	}
+	return n, nil
+}
+
+// fieldString formats the value of an instruction field.
+func (im *insMeta) fieldString(ins uint, f fieldPos, verb string) string {
+	x := bitUnsigned(ins, f.msb, f.lsb, 0)
+	switch verb {
+	case "x":
+		return fmt.Sprintf("0x%x", x)
+	case "s":
+		return fmt.Sprintf("%d", bitSex(int(x), f.msb-f.lsb))
+	}
+	if _, ok := regFields[f.name]; ok {
+		return fieldReg(im.id, f.name, x).String()
+	}
+	return fmt.Sprintf("%d", x)
+}
+
+// splitField splits a template field into a field name and a verb.
+func splitField(s string) (string, string) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 || strings.Contains(s[i:], "]") {
+		return s, ""
+	}
+	return s[:i], s[i+1:]
+}
+
+// findField returns the position of a named field.
+func (im *insMeta) findField(name string) (fieldPos, bool) {
+	for _, f := range im.fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return fieldPos{}, false
+}
+
+// fieldOrder returns the position of a field in the default assembly format:
+// destination registers, source registers (rs1, rs2, rs3) then immediates.
+func fieldOrder(name string) int {
+	rf, ok := regFields[name]
+	if !ok {
+		return roleRs3 << 1
+	}
+	if rf.role&roleRd != 0 {
+		return 0
+	}
+	return rf.role
+}
+
+// customFormat checks an assembly template against the instruction fields.
+func (im *insMeta) customFormat(format string) error {
+	for s := format; ; {
+		i := strings.Index(s, "{")
+		if i < 0 {
+			return nil
+		}
+		j := strings.Index(s[i:], "}")
+		if j < 0 {
+			return fmt.Errorf("unterminated field in format \"%s\"", format)
+		}
+		name, verb := splitField(s[i+1 : i+j])
+		if name != "name" {
+			if _, ok := im.findField(name); !ok {
+				return fmt.Errorf("unknown field \"%s\" in format \"%s\"", name, format)
+			}
+		}
+		if verb != "" && verb != "x" && verb != "s" && verb != "d" {
+			return fmt.Errorf("unknown verb \"%s\" in format \"%s\"", verb, format)
+		}
+		s = s[i+j+1:]
+	}
+}
+
+// customDa returns the disassembly function for a user defined instruction.
+func (im *insMeta) customDa(ci *CustomIns) daFunc {
+	if ci.Func != nil {
+		return func(name string, pc, ins uint) string {
+			f := Fields{}
+			for _, x := range im.fields {
+				f[x.name] = bitUnsigned(ins, x.msb, x.lsb, 0)
+			}
+			return ci.Func(name, pc, ins, f)
+		}
+	}
+	format := ci.Format
+	if format == "" {
+		fields := append([]fieldPos{}, im.fields...)
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fieldOrder(fields[i].name) < fieldOrder(fields[j].name)
+		})
+		s := []string{}
+		for _, x := range fields {
+			s = append(s, "{"+x.name+"}")
+		}
+		format = strings.TrimSpace("{name} " + strings.Join(s, ","))
+	}
+	return func(name string, pc, ins uint) string {
+		var sb strings.Builder
+		for s := format; ; {
+			i := strings.Index(s, "{")
+			if i < 0 {
+				sb.WriteString(s)
+				break
+			}
+			j := strings.Index(s[i:], "}")
+			sb.WriteString(s[:i])
+			fname, verb := splitField(s[i+1 : i+j])
+			if fname == "name" {
+				sb.WriteString(name)
+			} else {
+				f, _ := im.findField(fname)
+				sb.WriteString(im.fieldString(ins, f, verb))
+			}
+			s = s[i+j+1:]
+		}
+		return sb.String()
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// AddCustom adds a set of user defined instructions to the ISA. They take
+// precedence over the built-in instructions with the same encoding.
+func (isa *ISA) AddCustom(ext *CustomExt) error {
+	fields := map[string]int{}
+	for k, n := range ext.Fields {
+		if w, err := isField(k); err == nil && w != n {
+			return fmt.Errorf("field \"%s\" is %d bits (not %d)", k, w, n)
+		}
+		if k == "" || k == "name" || isBits(k) || strings.ContainsAny(k, " {}:") || n < 1 || n > 32 {
+			return fmt.Errorf("bad field \"%s\" (%d bits)", k, n)
+		}
+		fields[k] = n
+	}
+	var ins16, ins32 []*insMeta
+	for _, ci := range ext.Ins {
+		ci := ci
+		n, err := customLength(ci.Defn, fields)
+		if err != nil {
+			return err
+		}
+		id := &insDefn{defn: ci.Defn}
+		im, err := parseDefnFields(id, n, fields)
+		if err != nil {
+			return err
+		}
+		// 32-bit instructions have 11 in the low bits, 16-bit ones don't
+		if im.mask&3 != 3 || (im.val&3 == 3) != (n == 32) {
+			return fmt.Errorf("instruction length %d doesn't match the opcode bits \"%s\"", n, ci.Defn)
+		}
+		if ci.Func == nil {
+			if err := im.customFormat(ci.Format); err != nil {
+				return err
+			}
+		}
+		id.da = im.customDa(&ci)
+		if n == 16 {
+			ins16 = append(ins16, im)
+		} else {
+			ins32 = append(ins32, im)
+		}
+	}
+	isa.ins16 = append(ins16, isa.ins16...)
+	isa.ins32 = append(ins32, isa.ins32...)
+	if ext.Name != "" {
+		isa.addExtension(ext.Name)
+	}
+	return nil
+}
+
+// addExtension enables an extension (e.g. "m") or sub-extension (e.g. "xacme").
+func (isa *ISA) addExtension(name string) {
+	name = strings.ToLower(name)
+	if len(name) == 1 && name[0] >= 'a' && name[0] <= 'z' {
+		isa.ext |= 1 << (name[0] - 'a')
+		return
+	}
+	if !isa.zext[name] {
+		isa.zext[name] = true
+		isa.zname = append(isa.zname, name)
+		sort.Strings(isa.zname)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,86 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Custom Instruction Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+var acmeExt = CustomExt{
+	Name:   "xacme",
+	Fields: map[string]int{"acc": 3},
+	Ins: []CustomIns{
+		{Defn: "0000 acc rs2 rs1 000 rd 0001011 ACC.MAC", Format: "{name}.{acc} {rd},{rs1},{rs2}"},
+		{Defn: "0000 acc rs2 rs1 001 rd 0001011 ACC.ADD"},
+		{Defn: "imm[11:0] rs1 010 rd 0001011 ACC.LD", Format: "{name} {rd},{imm[11:0]:s}({rs1})"},
+		{Defn: "0000000 00000 rs1 011 rd 0001011 ACC.GET"},
+		{Defn: "imm[11:0] 00000 101 rd 0001011 ACC.LI", Format: "{name} {rd},{imm[11:0]:x}"},
+		{Defn: "0010 acc 00000 00000 100 00000 0001011 ACC.SYNC", Func: func(name string, pc, ins uint, f Fields) string {
+			return fmt.Sprintf("%s #%d", name, f["acc"])
+		}},
+	},
+}
+
+func Test_Custom(t *testing.T) {
+	isa, err := Parse("rv64gc_xtheadba")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := isa.AddCustom(&acmeExt); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		ins        uint
+		da         string
+		uses, defs string
+	}{
+		{0x0ac5850b, "acc.mac.5 a0,a1,a2", "{a1,a2}", "{a0}"},
+		{0x04c5950b, "acc.add a0,a1,a2,2", "{a1,a2}", "{a0}"}, // not th.addsl
+		{0xffc5a50b, "acc.ld a0,-4(a1)", "{a1}", "{a0}"},
+		{0x0005b50b, "acc.get a0,a1", "{a1}", "{a0}"},
+		{0x8000550b, "acc.li a0,0x800", "{}", "{a0}"},
+		{0x2400400b, "acc.sync #2", "{}", "{}"},
+		{0x00c58533, "add a0,a1,a2", "{a1,a2}", "{a0}"},
+	} {
+		da := isa.Disassemble(0, v.ins)
+		if da.Assembly != v.da || da.Uses.String() != v.uses || da.Defs.String() != v.defs {
+			t.Errorf("%08x: \"%s\" uses %s defs %s (expected \"%s\" %s %s)",
+				v.ins, da.Assembly, da.Uses, da.Defs, v.da, v.uses, v.defs)
+		}
+	}
+	if !isa.HasExtension("xacme") || !strings.Contains(isa.String(), "xacme") {
+		t.Errorf("%s: xacme not enabled", isa)
+	}
+}
+
+func Test_CustomErrors(t *testing.T) {
+	for _, v := range []CustomExt{
+		{Ins: []CustomIns{{Defn: "0000 acc rs2 rs1 000 rd 0001011 ACC.MAC"}}},
+		{Fields: map[string]int{"acc": 4}, Ins: []CustomIns{{Defn: "0000 acc rs2 rs1 000 rd 0001011 ACC.MAC"}}},
+		{Fields: map[string]int{"rd": 3}},
+		{Fields: map[string]int{"name": 3}},
+		{Ins: []CustomIns{{Defn: "0000000 rs2 rs1 000 rd 0001011 ACC.MAC", Format: "{name} {rd},{acc}"}}},
+		{Ins: []CustomIns{{Defn: "0000000 rs2 rs1 000 rd 0001011 ACC.MAC", Format: "{name} {rd:q}"}}},
+		{Ins: []CustomIns{{Defn: "0000000 rs2 rs1 000 rd 0001011 ACC.MAC", Format: "{name} {rd"}}},
+		{Ins: []CustomIns{{Defn: "0000000 rs2 rs1 000 rd 0001010 ACC.MAC"}}},
+		{Ins: []CustomIns{{Defn: "100 rs1 rd 011 ACC.C"}}},
+		{Ins: []CustomIns{{Defn: "10000 rs1 rs10 rd0 ACC.C"}}},
+	} {
+		isa, _ := Parse("rv32i")
+		if err := isa.AddCustom(&v); err == nil {
+			t.Errorf("%v: expected an error", v)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -8,7 +8,10 @@ RISC-V Disassembler
 
 package rvda
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 //-----------------------------------------------------------------------------
 
@@ -26,13 +29,6 @@ var abiFName = [32]string{
 	"fs8", "fs9", "fs10", "fs11", "ft8", "ft9", "ft10", "ft11",
 }
 
-//-----------------------------------------------------------------------------
-// default decode
-
-func daNone(name string, pc uint, ins uint) string {
-	return fmt.Sprintf("%s TODO", name)
-}
-
 //-----------------------------------------------------------------------------
 // Type I Decodes
 
@@ -149,6 +145,101 @@ func daTypeIk(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%s", name, abiXName[rs2], abiXName[rs1])
 }
 
+// fenceSet returns the iorw string for a fence predecessor/successor set.
+func fenceSet(x uint) string {
+	s := ""
+	for i, c := range "iorw" {
+		if x&(8>>uint(i)) != 0 {
+			s += string(c)
+		}
+	}
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+func daTypeIl(name string, pc uint, ins uint) string {
+	fm := bitUnsigned(ins, 31, 28, 0)
+	pred := bitUnsigned(ins, 27, 24, 0)
+	succ := bitUnsigned(ins, 23, 20, 0)
+	if fm == 8 && pred == 3 && succ == 3 {
+		return "fence.tso"
+	}
+	if pred == 15 && succ == 15 {
+		return name
+	}
+	return fmt.Sprintf("%s %s,%s", name, fenceSet(pred), fenceSet(succ))
+}
+
+func daTypeIm(name string, pc uint, ins uint) string {
+	_, rs1, rd := decodeIa(ins)
+	rnum := bitUnsigned(ins, 23, 20, 0)
+	return fmt.Sprintf("%s %s,%s,0x%x", name, abiXName[rd], abiXName[rs1], rnum)
+}
+
+func daTypeIn(name string, pc uint, ins uint) string {
+	_, rs1, _ := decodeIa(ins)
+	return fmt.Sprintf("%s 0(%s)", name, abiXName[rs1])
+}
+
+func daTypeIo(name string, pc uint, ins uint) string {
+	imm := bitSigned(ins, 31, 25) << 5 // imm[11:5]
+	rs1 := bitUnsigned(ins, 19, 15, 0)
+	return fmt.Sprintf("%s %d(%s)", name, imm, abiXName[rs1])
+}
+
+func daTypeIp(name string, pc uint, ins uint) string {
+	_, rs1, rd := decodeIa(ins)
+	n := bitUnsigned(ins, 30, 30, 4) + bitUnsigned(ins, 27, 26, 2) + bitUnsigned(ins, 21, 20, 0)
+	return fmt.Sprintf("%s.%d %s,%s", name, n, abiXName[rd], abiXName[rs1])
+}
+
+func daTypeIq(name string, pc uint, ins uint) string {
+	_, rs1, _ := decodeIa(ins)
+	return fmt.Sprintf("%s %s", name, abiXName[rs1])
+}
+
+func daTypeIr(name string, pc uint, ins uint) string {
+	_, _, rd := decodeIa(ins)
+	return fmt.Sprintf("%s %s", name, abiXName[rd])
+}
+
+func daTypeIs(name string, pc uint, ins uint) string {
+	_, rs1, rd := decodeIa(ins)
+	msb := bitUnsigned(ins, 31, 26, 0)
+	lsb := bitUnsigned(ins, 25, 20, 0)
+	return fmt.Sprintf("%s %s,%s,%d,%d", name, abiXName[rd], abiXName[rs1], msb, lsb)
+}
+
+func daTypeIt(name string, pc uint, ins uint) string {
+	_, rs1, rd := decodeIa(ins)
+	imm5 := bitSigned(ins, 24, 20)
+	imm2 := bitUnsigned(ins, 26, 25, 0)
+	return fmt.Sprintf("%s %s,(%s),%d,%d", name, abiXName[rd], abiXName[rs1], imm5, imm2)
+}
+
+func daTypeIu(name string, pc uint, ins uint) string {
+	_, rs1, rd := decodeIa(ins)
+	is3 := bitUnsigned(ins, 29, 25, 0)
+	is2 := bitUnsigned(ins, 24, 20, 0)
+	return fmt.Sprintf("%s %s,%s,%d,%d", name, abiXName[rd], abiXName[rs1], is3, is2)
+}
+
+func daTypeIv(name string, pc uint, ins uint) string {
+	_, rs1, rd := decodeIa(ins)
+	is2 := bitUnsigned(ins, 24, 20, 0)
+	return fmt.Sprintf("%s %s,%s,%d", name, abiXName[rd], abiXName[rs1], is2)
+}
+
+func daTypeIw(name string, pc uint, ins uint) string {
+	_, rs1, _ := decodeIa(ins)
+	if rs1 == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s %s", name, abiXName[rs1])
+}
+
 //-----------------------------------------------------------------------------
 // Type U Decodes
 
@@ -157,6 +248,11 @@ func daTypeUa(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,0x%x", name, abiXName[rd], uint(imm)&0xfffff)
 }
 
+func daTypeUb(name string, pc uint, ins uint) string {
+	imm, _ := decodeU(ins)
+	return fmt.Sprintf("%s 0x%x", name, uint(imm)&0xfffff)
+}
+
 //-----------------------------------------------------------------------------
 // Type S Decodes
 
@@ -238,6 +334,58 @@ func daTypeRk(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%s", name, abiXName[rd], abiFName[rs1])
 }
 
+func daTypeRl(name string, pc uint, ins uint) string {
+	_, rs1, _, rd := decodeR(ins)
+	return fmt.Sprintf("%s %s,%s", name, abiXName[rd], abiXName[rs1])
+}
+
+func daTypeRm(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	bs := bitUnsigned(ins, 31, 30, 0)
+	return fmt.Sprintf("%s %s,%s,%s,0x%x", name, abiXName[rd], abiXName[rs1], abiXName[rs2], bs)
+}
+
+func daTypeRn(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	n := bitUnsigned(ins, 30, 30, 2) + bitUnsigned(ins, 27, 26, 0)
+	return fmt.Sprintf("%s.%d %s,%s,%s", name, n, abiXName[rd], abiXName[rs1], abiXName[rs2])
+}
+
+func daTypeRo(name string, pc uint, ins uint) string {
+	rs2, _, _, _ := decodeR(ins)
+	return fmt.Sprintf("%s %s", name, abiXName[rs2])
+}
+
+func daTypeRp(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	imm2 := bitUnsigned(ins, 26, 25, 0)
+	return fmt.Sprintf("%s %s,%s,%s,%d", name, abiXName[rd], abiXName[rs1], abiXName[rs2], imm2)
+}
+
+func daTypeRq(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	imm2 := bitUnsigned(ins, 26, 25, 0)
+	return fmt.Sprintf("%s %s,%s,%s,%d", name, abiFName[rd], abiXName[rs1], abiXName[rs2], imm2)
+}
+
+func daTypeRr(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	imm2 := bitUnsigned(ins, 26, 25, 0)
+	shift := 3 + bitUnsigned(ins, 27, 27, 0) // 4 for the 64-bit pairs
+	return fmt.Sprintf("%s %s,%s,(%s),%d,%d", name, abiXName[rd], abiXName[rs2], abiXName[rs1], imm2, shift)
+}
+
+func daTypeRs(name string, pc uint, ins uint) string {
+	rs2, rs1, _, _ := decodeR(ins)
+	return fmt.Sprintf("%s %s,%s", name, abiXName[rs1], abiXName[rs2])
+}
+
+func daTypeRt(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	is3 := bitUnsigned(ins, 29, 25, 0)
+	return fmt.Sprintf("%s %s,%s,%s,%d", name, abiXName[rd], abiXName[rs1], abiXName[rs2], is3)
+}
+
 //-----------------------------------------------------------------------------
 // Type R4 Decodes
 
@@ -321,6 +469,11 @@ func daTypeCIh(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%d(sp)", name, abiXName[rd], uimm)
 }
 
+func daTypeCIi(name string, pc uint, ins uint) string {
+	uimm, rd := decodeCIg(ins)
+	return fmt.Sprintf("%s %s,%d(sp)", name, abiFName[rd], uimm)
+}
+
 //-----------------------------------------------------------------------------
 // Type CIW Decodes
 
@@ -395,6 +548,16 @@ func daTypeCSc(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%d(%s)", name, abiFName[rs2], uimm, abiXName[rs1])
 }
 
+func daTypeCSd(name string, pc uint, ins uint) string {
+	uimm, rs1, rs2 := decodeCLB(ins)
+	return fmt.Sprintf("%s %s,%d(%s)", name, abiXName[rs2], uimm, abiXName[rs1])
+}
+
+func daTypeCSe(name string, pc uint, ins uint) string {
+	uimm, rs1, rs2 := decodeCLH(ins)
+	return fmt.Sprintf("%s %s,%d(%s)", name, abiXName[rs2], uimm, abiXName[rs1])
+}
+
 //-----------------------------------------------------------------------------
 // Type CSS Decodes
 
@@ -413,6 +576,21 @@ func daTypeCSSc(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%d(sp)", name, abiXName[rs2], uimm)
 }
 
+func daTypeCSSd(name string, pc uint, ins uint) string {
+	uimm, rd := decodeCSSa(ins)
+	return fmt.Sprintf("%s %s,%d(sp)", name, abiFName[rd], uimm)
+}
+
+func daTypeCSSe(name string, pc uint, ins uint) string {
+	imm, rs2 := decodeCSSb(ins)
+	return fmt.Sprintf("%s %s,%d(sp)", name, abiFName[rs2], imm)
+}
+
+func daTypeCSSf(name string, pc uint, ins uint) string {
+	uimm, rs2 := decodeCSSc(ins)
+	return fmt.Sprintf("%s %s,%d(sp)", name, abiFName[rs2], uimm)
+}
+
 //-----------------------------------------------------------------------------
 // Type CB Decodes
 
@@ -422,34 +600,307 @@ func daTypeCBa(name string, pc uint, ins uint) string {
 }
 
 //-----------------------------------------------------------------------------
+// Type CU Decodes
 
-// daInstruction returns the disassembly for a 16/32-bit instruction.
-func (isa *ISA) daInstruction(pc uint, ins uint) string {
-	im := isa.lookup(ins)
+func daTypeCUa(name string, pc uint, ins uint) string {
+	rd := decodeCU(ins)
+	return fmt.Sprintf("%s %s,%s", name, abiXName[rd], abiXName[rd])
+}
+
+//-----------------------------------------------------------------------------
+// Type CM* Decodes
+
+func daTypeCMMV(name string, pc uint, ins uint) string {
+	r1s, r2s := decodeCMMV(ins)
+	return fmt.Sprintf("%s %s,%s", name, abiXName[r1s], abiXName[r2s])
+}
+
+// daTypeCMPP formats a push/pop with the stack adjustment for the register length.
+func daTypeCMPP(name string, ins, mxlen uint) string {
+	rlist, _ := decodeCMPP(ins)
+	adj := int(cmStackAdj(ins, mxlen))
+	if name == "cm.push" {
+		adj = -adj
+	}
+	return fmt.Sprintf("%s %s,%d", name, fmtRlist(rlist), adj)
+}
+
+func daTypeCMPPa(name string, pc uint, ins uint) string {
+	return daTypeCMPP(name, ins, 32)
+}
+
+func daTypeCMPPb(name string, pc uint, ins uint) string {
+	return daTypeCMPP(name, ins, 64)
+}
+
+func daTypeCMJT(name string, pc uint, ins uint) string {
+	return fmt.Sprintf("%s %d", name, decodeCMJT(ins))
+}
+
+func daTypeCMOP(name string, pc uint, ins uint) string {
+	return fmt.Sprintf("%s.%d", name, bitUnsigned(ins, 10, 8, 1)+1)
+}
+
+func daTypeCMOPa(name string, pc uint, ins uint) string {
+	return fmt.Sprintf("%s %s", name, abiXName[bitUnsigned(ins, 11, 7, 0)])
+}
+
+//-----------------------------------------------------------------------------
+// Type V Decodes
+
+// vmask returns the mask operand suffix (",v0.t" for a masked operation).
+func vmask(vm uint) string {
+	if vm == 0 {
+		return ",v0.t"
+	}
+	return ""
+}
+
+func daTypeVa(name string, pc uint, ins uint) string {
+	vm, vs2, vs1, vd := decodeV(ins)
+	return fmt.Sprintf("%s v%d,v%d,v%d%s", name, vd, vs2, vs1, vmask(vm))
+}
+
+func daTypeVb(name string, pc uint, ins uint) string {
+	vm, vs2, rs1, vd := decodeV(ins)
+	return fmt.Sprintf("%s v%d,v%d,%s%s", name, vd, vs2, abiXName[rs1], vmask(vm))
+}
+
+func daTypeVc(name string, pc uint, ins uint) string {
+	vm, vs2, uimm, vd := decodeV(ins)
+	return fmt.Sprintf("%s v%d,v%d,%d%s", name, vd, vs2, uimm, vmask(vm))
+}
+
+func daTypeVd(name string, pc uint, ins uint) string {
+	vm, vs2, _, vd := decodeV(ins)
+	return fmt.Sprintf("%s v%d,v%d%s", name, vd, vs2, vmask(vm))
+}
+
+func daTypeVe(name string, pc uint, ins uint) string {
+	vm, vs2, uimm, vd := decodeV(ins)
+	uimm |= bitUnsigned(ins, 26, 26, 5) // uimm[5]
+	return fmt.Sprintf("%s v%d,v%d,%d%s", name, vd, vs2, uimm, vmask(vm))
+}
+
+func daTypeVf(name string, pc uint, ins uint) string {
+	_, vs2, vs1, vd := decodeV(ins)
+	return fmt.Sprintf("%s v%d,v%d,v%d", name, vd, vs1, vs2)
+}
+
+func daTypeVg(name string, pc uint, ins uint) string {
+	vm, vs2, fs1, vd := decodeV(ins)
+	return fmt.Sprintf("%s v%d,v%d,%s%s", name, vd, vs2, abiFName[fs1], vmask(vm))
+}
+
+// SiFive VCIX: sf.vc[.v].{x,i} op,sel,vd,rs1 and sf.vc[.v].* op,vd,vs2,rs1
+// (vd/sel are immediates for the x, i, xv, iv, vv and fv forms without .v)
+func daTypeVh(name string, pc uint, ins uint) string {
+	vm, vs2, rs1, vd := decodeV(ins)
+	op := bitUnsigned(ins, 27, 26, 0)
+	var s1 string
+	switch bitUnsigned(ins, 14, 12, 0) {
+	case 0:
+		s1 = fmt.Sprintf("v%d", rs1)
+	case 3:
+		s1 = fmt.Sprintf("%d", bitSigned(ins, 19, 15))
+	case 4:
+		s1 = abiXName[rs1]
+	case 5:
+		s1 = abiFName[rs1]
+		op &= 1
+	}
+	t := bitUnsigned(ins, 31, 28, 0)
+	d := fmt.Sprintf("v%d", vd)
+	if vm == 1 && (t == 0 || t == 2) {
+		d = fmt.Sprintf("%d", vd)
+	}
+	if t == 0 {
+		return fmt.Sprintf("%s %d,%d,%s,%s", name, op, vs2, d, s1)
+	}
+	return fmt.Sprintf("%s %d,%s,v%d,%s", name, op, d, vs2, s1)
+}
+
+//-----------------------------------------------------------------------------
+// Type XCV Decodes (CORE-V)
+
+// hardware loop: cv.starti, cv.endi
+func daTypeXCVa(name string, pc uint, ins uint) string {
+	uimm, _, l := decodeXCVHwlp(ins)
+	return fmt.Sprintf("%s %d,%x", name, l, pc+uimm<<2)
+}
+
+// hardware loop: cv.counti
+func daTypeXCVb(name string, pc uint, ins uint) string {
+	uimm, _, l := decodeXCVHwlp(ins)
+	return fmt.Sprintf("%s %d,%d", name, l, uimm)
+}
+
+// hardware loop: cv.start, cv.end, cv.count
+func daTypeXCVc(name string, pc uint, ins uint) string {
+	_, rs1, l := decodeXCVHwlp(ins)
+	return fmt.Sprintf("%s %d,%s", name, l, abiXName[rs1])
+}
+
+// hardware loop: cv.setupi (count, end offset)
+func daTypeXCVd(name string, pc uint, ins uint) string {
+	uimm, uimms, l := decodeXCVHwlp(ins)
+	return fmt.Sprintf("%s %d,%d,%x", name, l, uimm, pc+uimms<<2)
+}
+
+// hardware loop: cv.setup (count register, end offset)
+func daTypeXCVe(name string, pc uint, ins uint) string {
+	uimm, rs1, l := decodeXCVHwlp(ins)
+	return fmt.Sprintf("%s %d,%s,%x", name, l, abiXName[rs1], pc+uimm<<2)
+}
+
+// post-increment load (immediate)
+func daTypeXCVf(name string, pc uint, ins uint) string {
+	imm, rs1, rd := decodeIa(ins)
+	return fmt.Sprintf("%s %s,(%s),%d", name, abiXName[rd], abiXName[rs1], imm)
+}
+
+// post-increment load (register)
+func daTypeXCVg(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	return fmt.Sprintf("%s %s,(%s),%s", name, abiXName[rd], abiXName[rs1], abiXName[rs2])
+}
+
+// register offset load
+func daTypeXCVh(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	return fmt.Sprintf("%s %s,%s(%s)", name, abiXName[rd], abiXName[rs2], abiXName[rs1])
+}
+
+// post-increment store (immediate)
+func daTypeXCVi(name string, pc uint, ins uint) string {
+	imm, rs2, rs1 := decodeS(ins)
+	return fmt.Sprintf("%s %s,(%s),%d", name, abiXName[rs2], abiXName[rs1], imm)
+}
+
+// post-increment store (register)
+func daTypeXCVj(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rs3 := decodeR(ins)
+	return fmt.Sprintf("%s %s,(%s),%s", name, abiXName[rs2], abiXName[rs1], abiXName[rs3])
+}
+
+// register offset store
+func daTypeXCVk(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rs3 := decodeR(ins)
+	return fmt.Sprintf("%s %s,%s(%s)", name, abiXName[rs2], abiXName[rs3], abiXName[rs1])
+}
+
+// immediate branch
+func daTypeXCVl(name string, pc uint, ins uint) string {
+	imm, _, rs1 := decodeB(ins)
+	imm5 := bitSigned(ins, 24, 20)
+	return fmt.Sprintf("%s %s,%d,%x", name, abiXName[rs1], imm5, int(pc)+imm)
+}
+
+// simdSuffix returns the packed SIMD mode suffix (halfword/byte, vector,
+// scalar register or scalar immediate).
+func simdSuffix(ins uint) string {
+	return [8]string{".h", ".b", "", "", ".sc.h", ".sc.b", ".sci.h", ".sci.b"}[bitUnsigned(ins, 14, 12, 0)]
+}
+
+// packed SIMD (vector, scalar register)
+func daTypeXCVm(name string, pc uint, ins uint) string {
+	rs2, rs1, _, rd := decodeR(ins)
+	return fmt.Sprintf("%s%s %s,%s,%s", name, simdSuffix(ins), abiXName[rd], abiXName[rs1], abiXName[rs2])
+}
+
+// packed SIMD (scalar signed immediate)
+func daTypeXCVn(name string, pc uint, ins uint) string {
+	_, rs1, rd := decodeIa(ins)
+	imm6 := bitSex(int(decodeXCVImm6(ins)), 5)
+	return fmt.Sprintf("%s%s %s,%s,%d", name, simdSuffix(ins), abiXName[rd], abiXName[rs1], imm6)
+}
+
+// packed SIMD (scalar unsigned immediate)
+func daTypeXCVo(name string, pc uint, ins uint) string {
+	_, rs1, rd := decodeIa(ins)
+	return fmt.Sprintf("%s%s %s,%s,%d", name, simdSuffix(ins), abiXName[rd], abiXName[rs1], decodeXCVImm6(ins))
+}
+
+//-----------------------------------------------------------------------------
+
+// daInstruction returns the disassembly for a 16/32-bit instruction with
+// the given meta information (nil if illegal).
+func daInstruction(im *insMeta, pc uint, ins uint) string {
 	if im != nil {
 		return im.defn.da(im.name, pc, ins)
 	}
 	return "illegal"
 }
 
+// legal returns true if the instruction decodes to a legal instruction.
+func (isa *ISA) legal(ins uint) bool {
+	im := isa.lookup(ins)
+	return im != nil && im.id != "c.illegal"
+}
+
 //-----------------------------------------------------------------------------
 
 // Disassembly returns the result of the disassembler call.
 type Disassembly struct {
-	Addr       uint // address
-	AddrLength uint // address length in bits
-	Ins        uint // instruction
-	InsLength  uint // instruction length in bytes
-	Assembly   string
+	Addr       uint       // address
+	AddrLength uint       // address length in bits
+	Ins        uint       // instruction
+	InsLength  uint       // instruction length in bytes
+	Assembly   string     // assembly string
+	Uses       RegSet     // registers read by the instruction
+	Defs       RegSet     // registers written by the instruction
+	Mem        *MemAccess // memory access (nil if none)
+	Flow       Flow       // control flow kind
+	Target     uint       // control flow target (branch, jump, call)
+	Comment    string     // annotation (e.g. a resolved address)
+	Class      Class      // normal, hint, reserved or illegal
+	Hint       string     // name of a standard hint (if any)
+	im         *insMeta   // instruction meta-data (nil if illegal)
+}
+
+// fmtAddr formats an address with the given bit length.
+func fmtAddr(addr, n uint) string {
+	addrFmt := fmt.Sprintf("%%0%dx", n>>2)
+	return fmt.Sprintf(addrFmt, addr)
+}
+
+// fmtIns formats an instruction with the given byte length.
+func fmtIns(ins, n uint) string {
+	if n == 2 {
+		return fmt.Sprintf("%04x    ", ins)
+	}
+	return fmt.Sprintf("%08x", ins)
+}
+
+// asm returns the assembly string with any class marker and comment.
+func (da *Disassembly) asm() string {
+	s := []string{}
+	switch da.Class {
+	case ClassHint:
+		s = append(s, strings.TrimSpace("hint "+da.Hint))
+	case ClassReserved:
+		s = append(s, "reserved")
+	}
+	if da.Comment != "" {
+		s = append(s, da.Comment)
+	}
+	if len(s) == 0 {
+		return da.Assembly
+	}
+	return fmt.Sprintf("%s # %s", da.Assembly, strings.Join(s, ", "))
 }
 
 func (da *Disassembly) String() string {
-	addrFmt := fmt.Sprintf("%%0%dx", da.AddrLength>>2)
-	addrStr := fmt.Sprintf(addrFmt, da.Addr)
-	if da.InsLength == 2 {
-		return fmt.Sprintf("%s: %04x     \t%s", addrStr, da.Ins, da.Assembly)
+	addrStr := fmtAddr(da.Addr, da.AddrLength)
+	return fmt.Sprintf("%s: %s \t%s", addrStr, fmtIns(da.Ins, da.InsLength), da.asm())
+}
+
+// addr masks an address to the register length.
+func (isa *ISA) addr(x uint) uint {
+	if isa.mxlen == 32 {
+		return uint(uint32(x))
 	}
-	return fmt.Sprintf("%s: %08x \t%s", addrStr, da.Ins, da.Assembly)
+	return x
 }
 
 // Disassemble a RISC-V instruction at the address.
@@ -465,7 +916,30 @@ func (isa *ISA) Disassemble(addr, ins uint) *Disassembly {
 		da.Ins = uint(uint16(ins))
 		da.InsLength = 2
 	}
-	da.Assembly = isa.daInstruction(addr, ins)
+	im := isa.lookup(ins)
+	da.Assembly = daInstruction(im, addr, ins)
+	da.Class, da.Hint = isa.classify(im, da.Ins)
+	if im != nil {
+		da.im = im
+		da.Uses, da.Defs = im.regUsage(da.Ins)
+		da.Mem = im.memAccess(da.Ins, isa.mxlen)
+		flow, offset := im.flow(da.Ins)
+		da.Flow = flow
+		if flow.HasTarget() {
+			da.Target = isa.addr(uint(int(addr) + offset))
+		}
+		if im.id == "cm.jt" || im.id == "cm.jalt" {
+			if target, ok := isa.jvtTarget(da.Ins); ok {
+				// resolved table jump
+				da.Flow = FlowJump
+				if im.id == "cm.jalt" {
+					da.Flow = FlowCall
+				}
+				da.Target = isa.addr(target)
+				da.Comment = fmt.Sprintf("0x%x", da.Target)
+			}
+		}
+	}
 	return da
 }
 
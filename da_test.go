@@ -99,6 +99,9 @@ var rv32iTest = []daTest{
 	{0, 0x34003cf3, "csrrc s9,mscratch,zero"},
 	{0, 0x30200073, "mret"},
 	{0, 0x0ff0000f, "fence"},
+	{0, 0x8330000f, "fence.tso"},
+	{0, 0x0100000f, "fence w,0"},
+	{0, 0x0330000f, "fence rw,rw"},
 	{0, 0x010fa033, "slt zero,t6,a6"},
 	{0, 0x00ff20b3, "slt ra,t5,a5"},
 	{0, 0x00cda233, "slt tp,s11,a2"},
@@ -202,6 +205,7 @@ var rv32dTest = []daTest{
 
 var rv32cTest = []daTest{
 	{0, 0x4705, "li a4,1"},
+	{0, 0x9002, "ebreak"},
 	{0, 0x8082, "ret"},
 	{0, 0xce06, "sw ra,28(sp)"},
 	{0, 0xcc22, "sw s0,24(sp)"},
@@ -252,11 +256,15 @@ var rv32cOnlyTest = []daTest{
 var rv32fcTest = []daTest{
 	{0, 0x7654, "flw fa3,44(a2)"},
 	{0, 0xfedc, "fsw fa5,60(a3)"},
+	{0, 0x6532, "flw fa0,12(sp)"},
+	{0, 0xe82e, "fsw fa1,16(sp)"},
 }
 
 var rv32dcTest = []daTest{
 	{0, 0x3210, "fld fa2,32(a2)"},
 	{0, 0xba98, "fsd fa4,48(a3)"},
+	{0, 0x2662, "fld fa2,24(sp)"},
+	{0, 0xb036, "fsd fa3,32(sp)"},
 }
 
 //-----------------------------------------------------------------------------
@@ -341,6 +349,518 @@ var rv64cTest = []daTest{
 	{0, 0xe426, "sd s1,8(sp)"},
 }
 
+//-----------------------------------------------------------------------------
+// register usage
+
+type regTest struct {
+	ins  uint   // instruction code
+	uses string // expected registers read
+	defs string // expected registers written
+}
+
+var rv32RegTest = []regTest{
+	{0x00a60533, "{a2,a0}", "{a0}"},                   // add a0,a2,a0
+	{0x00000513, "{}", "{a0}"},                        // li a0,0
+	{0x00812e23, "{sp,s0}", "{}"},                     // sw s0,28(sp)
+	{0x0100006f, "{}", "{}"},                          // j
+	{0x00000097, "{}", "{ra}"},                        // auipc ra,0x0
+	{0x40f2, "{sp}", "{ra}"},                          // lw ra,28(sp)
+	{0xce06, "{ra,sp}", "{}"},                         // sw ra,28(sp)
+	{0x1101, "{sp}", "{sp}"},                          // addi sp,sp,-32
+	{0x1800, "{sp}", "{s0}"},                          // addi s0,sp,48
+	{0x3d7d, "{}", "{ra}"},                            // jal ra,216
+	{0x9682, "{a3}", "{ra}"},                          // jalr a3
+	{0x8e09, "{a2,a0}", "{a2}"},                       // sub a2,a2,a0
+	{0xfec42707, "{s0}", "{fa4}"},                     // flw fa4,-20(s0)
+	{0xfef63c27, "{a2,fa5}", "{}"},                    // fsd fa5,-8(a2)
+	{0x10f777d3, "{fa4,fa5,frm}", "{fa5,fflags}"},     // fmul.s fa5,fa4,fa5
+	{0xc0001553, "{ft0}", "{a0,fflags}"},              // fcvt.w.s a0,ft0,rtz
+	{0xd2058053, "{a1}", "{ft0,fflags}"},              // fcvt.d.w ft0,a1
+	{0xe0078553, "{fa5}", "{a0}"},                     // fmv.x.w a0,fa5
+	{0x20208053, "{ft1,ft2}", "{ft0}"},                // fsgnj.s ft0,ft1,ft2
+	{0x101071c3, "{ft0,ft1,ft2,frm}", "{ft3,fflags}"}, // fmadd.s ft3,ft0,ft1,ft2
+	{0x30529073, "{t0}", "{mtvec}"},                   // csrw mtvec,t0
+	{0x34202f73, "{mcause}", "{t5}"},                  // csrr t5,mcause
+	{0x340290f3, "{t0,mscratch}", "{ra,mscratch}"},    // csrrw ra,mscratch,t0
+	{0x3400f0f3, "{mscratch}", "{ra,mscratch}"},       // csrrci ra,mscratch,1
+	{0x7654, "{a2}", "{fa3}"},                         // flw fa3,44(a2)
+	{0x0ff0050f, "{}", "{}"},                          // fence (reserved rd/rs1)
+}
+
+func Test_RegUsage(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range rv32RegTest {
+		da := isa.Disassemble(0, v.ins)
+		if da.Uses.String() != v.uses || da.Defs.String() != v.defs {
+			t.Errorf("%s: uses %s defs %s (expected %s %s)", da, da.Uses, da.Defs, v.uses, v.defs)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+// memory access
+
+type memTest struct {
+	ins   uint   // instruction code
+	mem   string // expected memory access
+	align uint   // expected alignment
+}
+
+var rv64MemTest = []memTest{
+	{0x00a60533, "", 0},               // add a0,a2,a0
+	{0xfef44783, "load8 -17(s0)", 1},  // lbu a5,-17(s0)
+	{0xffc62883, "load32 -4(a2)", 4},  // lw a7,-4(a2)
+	{0x00812e23, "store32 28(sp)", 4}, // sw s0,28(sp)
+	{0xfef63c27, "store64 -8(a2)", 8}, // fsd fa5,-8(a2)
+	{0x100526af, "load32 0(a0)", 4},   // lr.w a3,(a0)
+	{0x18c526af, "store32 0(a0)", 4},  // sc.w a3,a2,(a0)
+	{0x08b6b72f, "amo64 0(a3)", 8},    // amoswap.d a4,a1,(a3)
+	{0x40d4, "load32 4(s1)", 4},       // lw a3,4(s1)
+	{0xc74c, "store32 12(a4)", 4},     // sw a1,12(a4)
+	{0x60a6, "load64 72(sp)", 8},      // ld ra,72(sp)
+	{0xe426, "store64 8(sp)", 8},      // sd s1,8(sp)
+	{0xe70c, "store64 8(a4)", 8},      // sd a1,8(a4)
+	{0x3210, "load64 32(a2)", 8},      // fld fa2,32(a2)
+	{0xff80e703, "load32 -8(ra)", 4},  // lwu a4,-8(ra)
+}
+
+func Test_MemAccess(t *testing.T) {
+	isa, err := New(64, RV64gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range rv64MemTest {
+		da := isa.Disassemble(0, v.ins)
+		if da.Mem == nil {
+			if v.mem != "" {
+				t.Errorf("%s: no memory access (expected %s)", da, v.mem)
+			}
+			continue
+		}
+		if da.Mem.String() != v.mem || da.Mem.Align != v.align {
+			t.Errorf("%s: %s align %d (expected %s align %d)", da, da.Mem, da.Mem.Align, v.mem, v.align)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+// control flow
+
+type flowTest struct {
+	pc   uint   // program counter
+	ins  uint   // instruction code
+	flow Flow   // expected control flow kind
+	succ []uint // expected successors
+}
+
+var rv32FlowTest = []flowTest{
+	{0x100, 0x00a60533, FlowNext, []uint{0x104}},        // add a0,a2,a0
+	{0x44, 0x0100006f, FlowJump, []uint{0x54}},          // j 54
+	{0x100, 0x008000ef, FlowCall, []uint{0x104, 0x108}}, // jal ra,108
+	{0x100, 0x008002ef, FlowCall, []uint{0x104, 0x108}}, // jal t0,108
+	{0, 0x00008067, FlowReturn, nil},                    // ret
+	{0, 0x00028067, FlowReturn, nil},                    // jr t0
+	{0, 0x000080e7, FlowIndirectCall, []uint{4}},        // jalr ra
+	{0, 0x00040467, FlowIndirectJump, nil},              // jalr s0,s0
+	{0x28, 0x02e7f063, FlowBranch, []uint{0x2c, 0x48}},  // bgeu a5,a4,48
+	{0x24, 0xfea614e3, FlowBranch, []uint{0x28, 0xc}},   // bne a2,a0,c
+	{0, 0x00000073, FlowTrap, []uint{4}},                // ecall
+	{0, 0x30200073, FlowTrapReturn, nil},                // mret
+	{0, 0x10500073, FlowWfi, []uint{4}},                 // wfi
+	{0x186, 0xa029, FlowJump, []uint{0x190}},            // j 190
+	{0x1d0, 0xf3e1, FlowBranch, []uint{0x1d2, 0x190}},   // bnez a5,190
+	{0x358, 0x3d7d, FlowCall, []uint{0x35a, 0x216}},     // jal ra,216
+	{0, 0x8082, FlowReturn, nil},                        // ret
+	{0, 0x8f02, FlowIndirectJump, nil},                  // jr t5
+	{0, 0x9682, FlowIndirectCall, []uint{2}},            // jalr a3
+	{0x8000003e, 0xbfe5, FlowJump, []uint{0x80000036}},  // j 80000036
+}
+
+func Test_Flow(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range rv32FlowTest {
+		da := isa.Disassemble(v.pc, v.ins)
+		succ := da.Successors(v.pc)
+		if da.Flow != v.flow || fmt.Sprintf("%x", succ) != fmt.Sprintf("%x", v.succ) {
+			t.Errorf("%s: %s %x (expected %s %x)", da, da.Flow, succ, v.flow, v.succ)
+		}
+	}
+	// a backward branch moved near address 0 wraps at the register length
+	da := isa.Disassemble(0x24, 0xfea614e3) // bne a2,a0,c
+	if succ := da.Successors(4); fmt.Sprintf("%x", succ) != "[8 ffffffec]" {
+		t.Errorf("%s: successors at 4 %x (expected [8 ffffffec])", da, succ)
+	}
+}
+
+//-----------------------------------------------------------------------------
+// scalar cryptography
+
+var rv32ZkTest = []daTest{
+	{0, 0x40c5f533, "andn a0,a1,a2"},
+	{0, 0x6035d513, "rori a0,a1,0x3"},
+	{0, 0x08c5f533, "packh a0,a1,a2"},
+	{0, 0x6875d513, "brev8 a0,a1"},
+	{0, 0x6985d513, "rev8 a0,a1"},
+	{0, 0x08f59513, "zip a0,a1"},
+	{0, 0x08f5d513, "unzip a0,a1"},
+	{0, 0x0ac5b533, "clmulh a0,a1,a2"},
+	{0, 0x28c5c533, "xperm8 a0,a1,a2"},
+	{0, 0x6ac58533, "aes32dsi a0,a1,a2,0x1"},
+	{0, 0x26c58533, "aes32esmi a0,a1,a2,0x0"},
+	{0, 0x10059513, "sha256sum0 a0,a1"},
+	{0, 0x5ec58533, "sha512sig1h a0,a1,a2"},
+	{0, 0x70c58533, "sm4ed a0,a1,a2,0x1"},
+	{0, 0xf4c58533, "sm4ks a0,a1,a2,0x3"},
+	{0, 0x10859513, "sm3p0 a0,a1"},
+	{0, 0x01502573, "csrr a0,seed"},
+}
+
+var rv64ZkTest = []daTest{
+	{0, 0x6b85d513, "rev8 a0,a1"},
+	{0, 0x08c5c53b, "packw a0,a1,a2"},
+	{0, 0x6055d51b, "roriw a0,a1,0x5"},
+	{0, 0x6285d513, "rori a0,a1,0x28"},
+	{0, 0x30059513, "aes64im a0,a1"},
+	{0, 0x31a59513, "aes64ks1i a0,a1,0xa"},
+	{0, 0x7ec58533, "aes64ks2 a0,a1,a2"},
+	{0, 0x36c58533, "aes64esm a0,a1,a2"},
+	{0, 0x10759513, "sha512sig1 a0,a1"},
+	{0, 0x10059513, "sha256sum0 a0,a1"},
+	{0, 0x6ac58533, "illegal"},
+}
+
+var rvZvkTest = []daTest{
+	{0, 0x04860257, "vandn.vv v4,v8,v12,v0.t"},
+	{0, 0x06854257, "vandn.vx v4,v8,a0"},
+	{0, 0x4a842257, "vbrev8.v v4,v8"},
+	{0, 0x4884a257, "vrev8.v v4,v8,v0.t"},
+	{0, 0x56854257, "vrol.vx v4,v8,a0"},
+	{0, 0x52860257, "vror.vv v4,v8,v12"},
+	{0, 0x5282b257, "vror.vi v4,v8,5"},
+	{0, 0x568fb257, "vror.vi v4,v8,63"},
+	{0, 0x4a852257, "vbrev.v v4,v8"},
+	{0, 0x4a862257, "vclz.v v4,v8"},
+	{0, 0x48872257, "vcpop.v v4,v8,v0.t"},
+	{0, 0xd683b257, "vwsll.vi v4,v8,7"},
+	{0, 0x32862257, "vclmul.vv v4,v8,v12"},
+	{0, 0x3685e257, "vclmulh.vx v4,v8,a1"},
+	{0, 0xb2862277, "vghsh.vv v4,v8,v12"},
+	{0, 0xa288a277, "vgmul.vv v4,v8"},
+	{0, 0xa281a277, "vaesef.vv v4,v8"},
+	{0, 0xa681a277, "vaesef.vs v4,v8"},
+	{0, 0xa683a277, "vaesz.vs v4,v8"},
+	{0, 0x8a81a277, "vaeskf1.vi v4,v8,3"},
+	{0, 0xaa872277, "vaeskf2.vi v4,v8,14"},
+	{0, 0xb6862277, "vsha2ms.vv v4,v8,v12"},
+	{0, 0xba862277, "vsha2ch.vv v4,v8,v12"},
+	{0, 0x8683a277, "vsm4k.vi v4,v8,7"},
+	{0, 0xa6882277, "vsm4r.vs v4,v8"},
+	{0, 0xae812277, "vsm3c.vi v4,v8,2"},
+	{0, 0x82862277, "vsm3me.vv v4,v8,v12"},
+	{0, 0xa481a277, "illegal"}, // vm=0
+}
+
+func Test_Crypto(t *testing.T) {
+	testISA(t, "rv32i_zkn_zks_zkr", rv32ZkTest)
+	testISA(t, "rv64i_zk", rv64ZkTest)
+	testISA(t, "rv32i_zkne", []daTest{{0, 0x26c58533, "aes32esmi a0,a1,a2,0x0"}, {0, 0x6ac58533, "illegal"}})
+	testISA(t, "rv64gcv_zvkng_zvks_zvbb_zvbc", rvZvkTest)
+	testISA(t, "rv32imacv_zvkb", []daTest{{0, 0x06854257, "vandn.vx v4,v8,a0"}, {0, 0x4a852257, "illegal"}})
+	// vector element groups
+	isa, _ := Parse("rv64gcv_zvkned_zvksed_zvkb")
+	for _, v := range []regTest{
+		{0xa281a277, "{v8,v4}", "{v4}"},     // vaesef.vv v4,v8
+		{0x8683a277, "{v8}", "{v4}"},        // vsm4k.vi v4,v8,7
+		{0x04860257, "{v12,v8,v0}", "{v4}"}, // vandn.vv v4,v8,v12,v0.t
+	} {
+		da := isa.Disassemble(0, v.ins)
+		if da.Uses.String() != v.uses || da.Defs.String() != v.defs {
+			t.Errorf("%s: uses %s defs %s (expected %s %s)", da, da.Uses, da.Defs, v.uses, v.defs)
+		}
+	}
+	// reserved round number
+	isa, _ = Parse("rv64i_zknd")
+	if da := isa.Disassemble(0, 0x31b59513); da.Class != ClassReserved {
+		t.Errorf("%s: class %s (expected reserved)", da, da.Class)
+	}
+}
+
+var rvZiTest = []daTest{
+	{0, 0x0015200f, "cbo.clean 0(a0)"},
+	{0, 0x0045a00f, "cbo.zero 0(a1)"},
+	{0, 0x04156013, "prefetch.r 64(a0)"},
+	{0, 0xfe316013, "prefetch.w -32(sp)"},
+	{0, 0x0ec5d533, "czero.eqz a0,a1,a2"},
+	{0, 0x0ec5f533, "czero.nez a0,a1,a2"},
+	{0, 0x0100000f, "pause"},
+	{0, 0x00200033, "ntl.p1"},
+	{0, 0x00500033, "ntl.all"},
+	{0, 0x900a, "ntl.p1"},
+	{0, 0x9016, "ntl.all"},
+	{0, 0x81c5c573, "mop.r.0 a0,a1"},
+	{0, 0xcdf5c573, "mop.r.31 a0,a1"},
+	{0, 0xcec5c573, "mop.rr.7 a0,a1,a2"},
+	{0, 0x6081, "c.mop.1"},
+	{0, 0x6781, "c.mop.15"},
+}
+
+// the same encodings without the sub-extensions
+var rvZiBaseTest = []daTest{
+	{0, 0x0015200f, "illegal"},
+	{0, 0x04156013, "ori zero,a0,65"},
+	{0, 0x0ec5d533, "illegal"},
+	{0, 0x0100000f, "fence w,0"},
+	{0, 0x00200033, "add zero,zero,sp"},
+	{0, 0x900a, "add zero,zero,sp"},
+	{0, 0x81c5c573, "illegal"},
+	{0, 0x6081, "lui ra,0x0"},
+}
+
+func Test_Zi(t *testing.T) {
+	testISA(t, "rv64gc_zicbom_zicboz_zicbop_zicond_zihintntl_zihintpause_zimop_zcmop", rvZiTest)
+	testISA(t, "rv32imac_zicbom_zicboz_zicbop_zicond_zihintntl_zihintpause_zimop_zcmop", rvZiTest)
+	testISA(t, "rv64gc", rvZiBaseTest)
+	// overlays are normal instructions (not hints)
+	isa, _ := Parse("rv64gc_zihintpause")
+	if da := isa.Disassemble(0, 0x0100000f); da.Class != ClassNormal {
+		t.Errorf("%s: class %s (expected normal)", da, da.Class)
+	}
+}
+
+var rvXTheadTest = []daTest{
+	{0, 0x04c5950b, "th.addsl a0,a1,a2,2"},
+	{0, 0x1055950b, "th.srri a0,a1,0x5"},
+	{0, 0x3c85a50b, "th.ext a0,a1,15,8"},
+	{0, 0x1c05b50b, "th.extu a0,a1,7,0"},
+	{0, 0x8605950b, "th.ff1 a0,a1"},
+	{0, 0x8205950b, "th.rev a0,a1"},
+	{0, 0x8bf5950b, "th.tst a0,a1,0x3f"},
+	{0, 0x40c5950b, "th.mveqz a0,a1,a2"},
+	{0, 0x20c5950b, "th.mula a0,a1,a2"},
+	{0, 0x44c5c50b, "th.lrw a0,a1,a2,2"},
+	{0, 0x90c5c50b, "th.lurbu a0,a1,a2,0"},
+	{0, 0x22c5d50b, "th.srh a0,a1,a2,1"},
+	{0, 0x1d05c50b, "th.lbia a0,(a1),-16,2"},
+	{0, 0x4c45c50b, "th.lwib a0,(a1),4,2"},
+	{0, 0x5885d50b, "th.swia a0,(a1),8,0"},
+	{0, 0xe2c5c50b, "th.lwd a0,a2,(a1),1,3"},
+	{0, 0xe0c5d50b, "th.swd a0,a2,(a1),0,3"},
+	{0, 0x44c5e50b, "th.flrw fa0,a1,a2,2"},
+	{0, 0x66c5f50b, "th.fsrd fa0,a1,a2,3"},
+	{0, 0x04b5000b, "th.sfence.vmas a0,a1"},
+	{0, 0x01b0000b, "th.sync.is"},
+	{0, 0x0275000b, "th.dcache.civa a0"},
+	{0, 0x0100000b, "th.icache.iall"},
+	{0, 0x0170000b, "th.l2cache.ciall"},
+}
+
+var rv64XTheadTest = []daTest{
+	{0, 0x15f5950b, "th.srriw a0,a1,0x1f"},
+	{0, 0x9005950b, "th.revw a0,a1"},
+	{0, 0x24c5950b, "th.mulaw a0,a1,a2"},
+	{0, 0x7ff5c50b, "th.ldia a0,(a1),-1,3"},
+	{0, 0x66c5c50b, "th.lrd a0,a1,a2,3"},
+	{0, 0xfec5c50b, "th.ldd a0,a2,(a1),3,4"},
+	{0, 0xfac5d50b, "th.sdd a0,a2,(a1),1,4"},
+	{0, 0x70c5e50b, "th.flurd fa0,a1,a2,0"},
+}
+
+const xthead = "_xtheadba_xtheadbb_xtheadbs_xtheadcondmov_xtheadmac_xtheadmemidx_xtheadmempair_xtheadfmemidx_xtheadsync_xtheadcmo"
+
+func Test_XThead(t *testing.T) {
+	testISA(t, "rv32gc"+xthead, rvXTheadTest)
+	testISA(t, "rv64gc"+xthead, rvXTheadTest)
+	testISA(t, "rv64gc"+xthead, rv64XTheadTest)
+	testISA(t, "rv32gc"+xthead, []daTest{{0, 0x9005950b, "illegal"}, {0, 0x7ff5c50b, "illegal"}})
+	testISA(t, "rv64gc", []daTest{{0, 0x04c5950b, "illegal"}, {0, 0x0100000b, "illegal"}})
+	// registers and memory
+	isa, _ := Parse("rv64gc" + xthead)
+	for _, v := range []struct {
+		ins             uint
+		uses, defs, mem string
+	}{
+		{0x1d05c50b, "{a1}", "{a1,a0}", "load8 0(a1)"},    // th.lbia a0,(a1),-16,2
+		{0x4c45c50b, "{a1}", "{a1,a0}", "load32 16(a1)"},  // th.lwib a0,(a1),4,2
+		{0x5885d50b, "{a1,a0}", "{a1}", "store32 0(a1)"},  // th.swia a0,(a1),8,0
+		{0xfec5c50b, "{a1}", "{a2,a0}", "load128 48(a1)"}, // th.ldd a0,a2,(a1),3,4
+		{0x44c5e50b, "{a1,a2}", "{fa0}", "<nil>"},         // th.flrw fa0,a1,a2,2
+		{0x66c5f50b, "{a1,a2,fa0}", "{}", "<nil>"},        // th.fsrd fa0,a1,a2,3
+	} {
+		da := isa.Disassemble(0, v.ins)
+		mem := fmt.Sprintf("%v", da.Mem)
+		if da.Uses.String() != v.uses || da.Defs.String() != v.defs || mem != v.mem {
+			t.Errorf("%s: uses %s defs %s mem %s (expected %s %s %s)", da, da.Uses, da.Defs, mem, v.uses, v.defs, v.mem)
+		}
+	}
+	// diagnose
+	isa, _ = Parse("rv64gc")
+	if d := isa.Diagnose(0x04c5950b); d == nil || d.String() != "th.addsl: requires XTheadBa" {
+		t.Errorf("diagnosis %v", d)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+var rvXCVTest = []daTest{
+	{0x1000, 0x0100402b, "cv.starti 0,1040"},
+	{0x1000, 0x000541ab, "cv.start 1,a0"},
+	{0x1000, 0x0200422b, "cv.endi 0,1080"},
+	{0x1000, 0x0005c3ab, "cv.end 1,a1"},
+	{0x1000, 0x0640442b, "cv.counti 0,100"},
+	{0x1000, 0x0006452b, "cv.count 0,a2"},
+	{0x1000, 0x00a446ab, "cv.setupi 1,10,1020"},
+	{0x1000, 0x00c5472b, "cv.setup 0,a0,1030"},
+	{0x1000, 0x0045850b, "cv.lb a0,(a1),4"},
+	{0x1000, 0xffe5d50b, "cv.lhu a0,(a1),-2"},
+	{0x1000, 0x04c5b52b, "cv.lw a0,(a1),a2"},
+	{0x1000, 0x18c5b52b, "cv.lbu a0,a2(a1)"},
+	{0x1000, 0x00a5a42b, "cv.sw a0,(a1),8"},
+	{0x1000, 0x22a5b62b, "cv.sh a0,(a1),a2"},
+	{0x1000, 0x28a5b62b, "cv.sb a0,a2(a1)"},
+	{0x1000, 0x0105b50b, "cv.elw a0,16(a1)"},
+	{0x1000, 0xffd56c8b, "cv.beqimm a0,-3,ff8"},
+	{0x1000, 0x0055780b, "cv.bneimm a0,5,1010"},
+	{0x1000, 0x0e85855b, "cv.extract a0,a1,7,8"},
+	{0x1000, 0x8645855b, "cv.insert a0,a1,3,4"},
+	{0x1000, 0x41f5955b, "cv.bset a0,a1,0,31"},
+	{0x1000, 0xc455955b, "cv.bitrev a0,a1,2,5"},
+	{0x1000, 0x30c5b52b, "cv.extractr a0,a1,a2"},
+	{0x1000, 0x40c5b52b, "cv.ror a0,a1,a2"},
+	{0x1000, 0x4805b52b, "cv.cnt a0,a1"},
+	{0x1000, 0x5005b52b, "cv.abs a0,a1"},
+	{0x1000, 0x52c5b52b, "cv.sle a0,a1,a2"},
+	{0x1000, 0x6605b52b, "cv.extbz a0,a1"},
+	{0x1000, 0x7055b52b, "cv.clip a0,a1,5"},
+	{0x1000, 0x80c5b52b, "cv.addnr a0,a1,a2"},
+	{0x1000, 0xc8c5b55b, "cv.suburn a0,a1,a2,4"},
+	{0x1000, 0x90c5b52b, "cv.mac a0,a1,a2"},
+	{0x1000, 0x00c5c55b, "cv.mulsn a0,a1,a2,0"},
+	{0x1000, 0x60c5f55b, "cv.machhun a0,a1,a2,16"},
+	{0x1000, 0x00c5857b, "cv.add.h a0,a1,a2"},
+	{0x1000, 0x80c5957b, "cv.dotup.b a0,a1,a2"},
+	{0x1000, 0x08c5c57b, "cv.sub.sc.h a0,a1,a2"},
+	{0x1000, 0xa8c5d57b, "cv.sdotsp.sc.b a0,a1,a2"},
+	{0x1000, 0x03d5e57b, "cv.add.sci.h a0,a1,-5"},
+	{0x1000, 0x5305f57b, "cv.sll.sci.b a0,a1,33"},
+	{0x1000, 0x7005857b, "cv.abs.h a0,a1"},
+	{0x1000, 0x04c5957b, "cv.cmpeq.b a0,a1,a2"},
+	{0x1000, 0x4ff5e57b, "cv.cmpleu.sci.h a0,a1,63"},
+	{0x1000, 0x00c5a57b, "illegal"},
+	{0x1000, 0xcc202573, "csrr a0,lpcount0"},
+}
+
+const xcv = "_xcvhwlp_xcvmem_xcvelw_xcvbi_xcvbitmanip_xcvalu_xcvmac_xcvsimd"
+
+func Test_XCV(t *testing.T) {
+	testISA(t, "rv32imc"+xcv, rvXCVTest)
+	testISA(t, "rv64imc"+xcv, []daTest{{0, 0x0100402b, "illegal"}, {0, 0x00c5857b, "illegal"}})
+	testISA(t, "rv32imc", []daTest{{0, 0x0045850b, "illegal"}, {0, 0x90c5b52b, "illegal"}})
+	// registers and memory
+	isa, _ := Parse("rv32imc" + xcv)
+	for _, v := range []struct {
+		ins             uint
+		uses, defs, mem string
+	}{
+		{0x0045850b, "{a1}", "{a1,a0}", "load8 0(a1)"},            // cv.lb a0,(a1),4
+		{0x18c5b52b, "{a1,a2}", "{a0}", "load8 a2(a1)"},           // cv.lbu a0,a2(a1)
+		{0x28a5b62b, "{a1,a0,a2}", "{}", "store8 a2(a1)"},         // cv.sb a0,a2(a1)
+		{0x22a5b62b, "{a1,a0,a2}", "{a1}", "store16 0(a1)"},       // cv.sh a0,(a1),a2
+		{0x0105b50b, "{a1}", "{a0}", "load32 16(a1)"},             // cv.elw a0,16(a1)
+		{0x90c5b52b, "{a1,a2,a0}", "{a0}", "<nil>"},               // cv.mac a0,a1,a2
+		{0xa8c5d57b, "{a1,a2,a0}", "{a0}", "<nil>"},               // cv.sdotsp.sc.b a0,a1,a2
+		{0x0006452b, "{a2}", "{lpcount0}", "<nil>"},               // cv.count 0,a2
+		{0x00a446ab, "{}", "{lpstart1,lpend1,lpcount1}", "<nil>"}, // cv.setupi 1,10,20
+	} {
+		da := isa.Disassemble(0, v.ins)
+		mem := fmt.Sprintf("%v", da.Mem)
+		if da.Uses.String() != v.uses || da.Defs.String() != v.defs || mem != v.mem {
+			t.Errorf("%s: uses %s defs %s mem %s (expected %s %s %s)", da, da.Uses, da.Defs, mem, v.uses, v.defs, v.mem)
+		}
+	}
+	// immediate branch
+	if da := isa.Disassemble(0x1000, 0xffd56c8b); da.Flow != FlowBranch || da.Target != 0xff8 {
+		t.Errorf("%s: flow %s target %x (expected branch ff8)", da, da.Flow, da.Target)
+	}
+	// diagnose
+	isa, _ = Parse("rv32imc")
+	if d := isa.Diagnose(0x00c5857b); d == nil || d.String() != "cv.add: requires XCVsimd" {
+		t.Errorf("diagnosis %v", d)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+var rvXSiFiveTest = []daTest{
+	{0, 0x0ff5cfdb, "sf.vc.x 3,31,31,a1"},
+	{0, 0x0a1fb15b, "sf.vc.i 2,1,2,-1"},
+	{0, 0x0df5c45b, "sf.vc.v.x 3,31,v8,a1"},
+	{0, 0x2645cfdb, "sf.vc.xv 1,31,v4,a1"},
+	{0, 0x224602db, "sf.vc.vv 0,5,v4,v12"},
+	{0, 0x2e4551db, "sf.vc.fv 1,3,v4,fa0"},
+	{0, 0x2c45545b, "sf.vc.v.fv 1,v8,v4,fa0"},
+	{0, 0xaa47b45b, "sf.vc.ivv 2,v8,v4,15"},
+	{0, 0xac45c45b, "sf.vc.v.xvv 3,v8,v4,a1"},
+	{0, 0xf246045b, "sf.vc.vvw 0,v8,v4,v12"},
+	{0, 0xf845545b, "sf.vc.v.fvw 0,v8,v4,fa0"},
+	{0, 0xb246245b, "sf.vqmaccu.2x8x2 v8,v12,v4"},
+	{0, 0xfe46245b, "sf.vqmaccsu.4x8x4 v8,v12,v4"},
+	{0, 0x8e45545b, "sf.vfnrclip.x.f.qf v8,v4,fa0"},
+	{0, 0x8845545b, "sf.vfnrclip.xu.f.qf v8,v4,fa0,v0.t"},
+	{0, 0x30500073, "sf.cease"},
+	{0, 0xfc050073, "sf.cflush.d.l1 a0"},
+	{0, 0xfc000073, "sf.cflush.d.l1"},
+	{0, 0xfc258073, "sf.cdiscard.d.l1 a1"},
+	{0, 0x7c002573, "csrr a0,bpm"},
+	{0, 0x7c159073, "csrw featuredisable,a1"},
+}
+
+const xsifive = "_xsfvcp_xsfvqmaccdod_xsfvqmaccqoq_xsfvfnrclipxfqf_xsfcease_xsifivecflushdlone_xsifivecdiscarddlone"
+
+func Test_XSiFive(t *testing.T) {
+	testISA(t, "rv64gc"+xsifive, rvXSiFiveTest)
+	testISA(t, "rv32gc"+xsifive, rvXSiFiveTest)
+	testISA(t, "rv64gc_xventanacondops", []daTest{{0, 0x00c5e57b, "vt.maskc a0,a1,a2"}, {0, 0x00c5f57b, "vt.maskcn a0,a1,a2"}})
+	testISA(t, "rv32gc_xventanacondops", []daTest{{0, 0x00c5e57b, "illegal"}})
+	testISA(t, "rv64gc", []daTest{{0, 0x0ff5cfdb, "illegal"}, {0, 0x30500073, "illegal"}, {0, 0xfc050073, "illegal"}})
+	// registers
+	isa, _ := Parse("rv64gc" + xsifive)
+	for _, v := range []struct {
+		ins        uint
+		uses, defs string
+	}{
+		{0x0ff5cfdb, "{a1}", "{}"},          // sf.vc.x 3,31,31,a1
+		{0xae45c45b, "{a1,v4,v8}", "{}"},    // sf.vc.xvv 3,v8,v4,a1
+		{0xac45c45b, "{a1,v4,v8}", "{v8}"},  // sf.vc.v.xvv 3,v8,v4,a1
+		{0x2c45545b, "{fa0,v4}", "{v8}"},    // sf.vc.v.fv 1,v8,v4,fa0
+		{0xb246245b, "{v12,v4,v8}", "{v8}"}, // sf.vqmaccu.2x8x2 v8,v12,v4
+		{0x8845545b, "{fa0,v4,v0}", "{v8}"}, // sf.vfnrclip.xu.f.qf v8,v4,fa0,v0.t
+		{0xfc050073, "{a0}", "{}"},          // sf.cflush.d.l1 a0
+	} {
+		da := isa.Disassemble(0, v.ins)
+		if da.Uses.String() != v.uses || da.Defs.String() != v.defs {
+			t.Errorf("%s: uses %s defs %s (expected %s %s)", da, da.Uses, da.Defs, v.uses, v.defs)
+		}
+	}
+	// diagnose (the vendor opcode spaces overlap, RV32 prefers CORE-V)
+	isa, _ = Parse("rv64gc")
+	if d := isa.Diagnose(0x00c5e57b); d == nil || d.String() != "vt.maskc: requires XVentanaCondOps" {
+		t.Errorf("diagnosis %v", d)
+	}
+	if d := isa.Diagnose(0xb246245b); d == nil || d.String() != "sf.vqmaccu.2x8x2: requires XSfvqmaccdod" {
+		t.Errorf("diagnosis %v", d)
+	}
+	isa, _ = Parse("rv32gc")
+	if d := isa.Diagnose(0x00c5e57b); d == nil || d.String() != "cv.add: requires XCVsimd" {
+		t.Errorf("diagnosis %v", d)
+	}
+}
+
 //-----------------------------------------------------------------------------
 
 func testSet(mxlen, ext uint, tests []daTest) error {
@@ -357,6 +877,69 @@ func testSet(mxlen, ext uint, tests []daTest) error {
 	return nil
 }
 
+// testISA disassembles a test set with an ISA string.
+func testISA(t *testing.T, isa string, tests []daTest) {
+	t.Helper()
+	x, err := Parse(isa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range tests {
+		da := x.Disassemble(v.pc, v.ins)
+		if v.da != da.Assembly {
+			t.Errorf("%s: ins %08x \"%s\" (expected \"%s\")", isa, v.ins, da.Assembly, v.da)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+// classification
+
+type classTest struct {
+	ins   uint   // instruction code
+	class Class  // expected class
+	hint  string // expected hint name
+}
+
+var rv32ClassTest = []classTest{
+	{0x00000013, ClassNormal, ""},     // nop
+	{0x00500013, ClassHint, ""},       // li zero,5
+	{0x00200033, ClassHint, "ntl.p1"}, // add zero,zero,sp
+	{0x0100000f, ClassHint, "pause"},  // fence w,0
+	{0x0ff0000f, ClassNormal, ""},     // fence
+	{0x8330000f, ClassNormal, ""},     // fence.tso
+	{0x4ff0000f, ClassReserved, ""},   // fence (fm=0100)
+	{0x01f01013, ClassHint, ""},       // slli zero,zero,0x1f
+	{0x02051513, ClassReserved, ""},   // slli a0,a0,0x20
+	{0xffffffff, ClassIllegal, ""},    // illegal
+	{0x0001, ClassNormal, ""},         // nop
+	{0x0005, ClassHint, ""},           // c.nop 1
+	{0x4005, ClassHint, ""},           // li zero,1
+	{0x6101, ClassReserved, ""},       // c.addi16sp 0
+	{0x6501, ClassReserved, ""},       // c.lui a0,0
+	{0x0010, ClassReserved, ""},       // c.addi4spn s0,0
+	{0x8002, ClassReserved, ""},       // c.jr zero
+	{0x900a, ClassHint, "c.ntl.p1"},   // c.add zero,sp
+	{0x0000, ClassIllegal, ""},        // c.illegal
+}
+
+func Test_Class(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range rv32ClassTest {
+		da := isa.Disassemble(0, v.ins)
+		if da.Class != v.class || da.Hint != v.hint {
+			t.Errorf("%s: class %s hint \"%s\" (expected %s \"%s\")", da, da.Class, da.Hint, v.class, v.hint)
+		}
+	}
+	da := isa.Disassemble(0, 0x00200033)
+	if da.String() != "00000000: 00200033 \tadd zero,zero,sp # hint ntl.p1" {
+		t.Errorf("bad marker \"%s\"", da)
+	}
+}
+
 //-----------------------------------------------------------------------------
 
 func Test_Disassembly(t *testing.T) {
@@ -0,0 +1,172 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Illegal Instruction Diagnosis
+
+An instruction that doesn't decode may be from an extension that isn't
+enabled or be for a different register length. Probe all the known ISA
+modules to find the configuration that would decode it.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//-----------------------------------------------------------------------------
+
+// Diagnosis describes the ISA configuration needed to decode an instruction.
+type Diagnosis struct {
+	Name     string   // instruction name
+	Requires uint     // extensions that are not enabled
+	Zext     []string // sub-extensions that are not enabled
+	Xlen     uint     // required register length (0 if the current length is ok)
+}
+
+// zextTitles are the display names of the vendor sub-extensions.
+var zextTitles = map[string]string{
+	"xtheadba":             "XTheadBa",
+	"xtheadbb":             "XTheadBb",
+	"xtheadbs":             "XTheadBs",
+	"xtheadcondmov":        "XTheadCondMov",
+	"xtheadmac":            "XTheadMac",
+	"xtheadmemidx":         "XTheadMemIdx",
+	"xtheadmempair":        "XTheadMemPair",
+	"xtheadfmemidx":        "XTheadFMemIdx",
+	"xtheadsync":           "XTheadSync",
+	"xtheadcmo":            "XTheadCmo",
+	"xcvhwlp":              "XCVhwlp",
+	"xcvmem":               "XCVmem",
+	"xcvelw":               "XCVelw",
+	"xcvbi":                "XCVbi",
+	"xcvbitmanip":          "XCVbitmanip",
+	"xcvalu":               "XCValu",
+	"xcvmac":               "XCVmac",
+	"xcvsimd":              "XCVsimd",
+	"xsfvcp":               "XSfvcp",
+	"xsfvqmaccdod":         "XSfvqmaccdod",
+	"xsfvqmaccqoq":         "XSfvqmaccqoq",
+	"xsfvfnrclipxfqf":      "XSfvfnrclipxfqf",
+	"xsfcease":             "XSfcease",
+	"xsifivecflushdlone":   "XSiFivecflushdlone",
+	"xsifivecdiscarddlone": "XSiFivecdiscarddlone",
+	"xventanacondops":      "XVentanaCondOps",
+}
+
+// zextTitle returns the display name of a sub-extension (e.g. Zcb, XTheadBa).
+func zextTitle(z string) string {
+	if s, ok := zextTitles[z]; ok {
+		return s
+	}
+	return strings.ToUpper(z[:1]) + z[1:]
+}
+
+func (d *Diagnosis) String() string {
+	s := []string{}
+	if d.Xlen != 0 {
+		s = append(s, fmt.Sprintf("RV%d-only", d.Xlen))
+	}
+	if d.Requires != 0 || len(d.Zext) != 0 {
+		ext := []string{}
+		for i := 0; i < 26; i++ {
+			if d.Requires&(1<<i) != 0 {
+				ext = append(ext, string(rune('A'+i)))
+			}
+		}
+		for _, z := range d.Zext {
+			ext = append(ext, zextTitle(z))
+		}
+		s = append(s, "requires "+strings.Join(ext, "+"))
+	}
+	return fmt.Sprintf("%s: %s", d.Name, strings.Join(s, ", "))
+}
+
+//-----------------------------------------------------------------------------
+
+// probeModule is the parsed instructions of an ISA module.
+type probeModule struct {
+	cfg *isaConfig
+	ins []*insMeta
+}
+
+var probeOnce sync.Once
+var probeModules []probeModule
+
+// probe returns the parsed instructions of all known ISA modules.
+func probe() []probeModule {
+	probeOnce.Do(func() {
+		for i := range isaConfigs {
+			c := &isaConfigs[i]
+			pm := probeModule{cfg: c}
+			for j := range c.module.defn {
+				im, err := parseDefn(&c.module.defn[j], c.module.ilen)
+				if err == nil {
+					pm.ins = append(pm.ins, im)
+				}
+			}
+			probeModules = append(probeModules, pm)
+		}
+	})
+	return probeModules
+}
+
+// Diagnose returns the ISA configuration needed to decode an instruction
+// that is illegal for the ISA, e.g. "mulw: requires M" or "c.jal: RV32-only".
+// It returns nil if the instruction is legal or is not known to any module.
+func (isa *ISA) Diagnose(ins uint) *Diagnosis {
+	if isa.legal(ins) {
+		return nil
+	}
+	var best *Diagnosis
+	cost := 0
+	for _, pm := range probe() {
+		for _, im := range pm.ins {
+			if im.n != insBits(ins) || ins&im.mask != im.val {
+				continue
+			}
+			if im.id == "c.illegal" {
+				break
+			}
+			d := &Diagnosis{Name: im.id, Requires: pm.cfg.ext &^ isa.ext}
+			for _, z := range pm.cfg.missing(isa.zext) {
+				if zextC[z] && isa.ext&ExtC == 0 {
+					// implied by C
+					d.Requires |= ExtC
+				} else {
+					d.Zext = append(d.Zext, z)
+				}
+			}
+			if pm.cfg.xlen != 0 && pm.cfg.xlen != isa.mxlen {
+				d.Xlen = pm.cfg.xlen
+			}
+			// prefer the same register length, then the fewest extensions
+			n := len(d.Zext)
+			if d.Xlen != 0 {
+				n += 100
+			}
+			for x := d.Requires; x != 0; x &= x - 1 {
+				n++
+			}
+			if best == nil || n < cost {
+				best, cost = d, n
+			}
+			break
+		}
+	}
+	return best
+}
+
+// insBits returns the bit length of an instruction.
+func insBits(ins uint) int {
+	if ins&3 == 3 {
+		return 32
+	}
+	return 16
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,50 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Illegal Instruction Diagnosis Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import "testing"
+
+//-----------------------------------------------------------------------------
+
+func Test_Diagnose(t *testing.T) {
+	testCases := []struct {
+		mxlen uint
+		ext   uint
+		ins   uint
+		diag  string
+	}{
+		{64, ExtI | ExtC, 0x02b5053b, "mulw: requires M"},
+		{32, RV32gc, 0x00053503, "ld: RV64-only"},
+		{32, ExtI, 0x00b57553, "fadd.s: requires F"},
+		{32, ExtI, 0x2001, "c.jal: requires C"},
+		{64, ExtI, 0x2001, "c.addiw: requires C"},
+		{32, RV32gc, 0x81ec, "c.lbu: requires Zcb"},
+		{32, ExtI | ExtM | ExtC, 0x9de5, "c.sext.b: requires Zcb+Zbb"},
+		{64, RV64gc, 0x81c5c573, "mop.r: requires Zimop"},
+		{64, RV64gc, 0x0ec5d533, "czero.eqz: requires Zicond"},
+		{32, ExtI, 0x0000, ""},
+		{32, RV32gc, 0xffffffff, ""},
+		{32, RV32gc, 0x00a60533, ""},
+	}
+	for _, v := range testCases {
+		isa, err := New(v.mxlen, v.ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := ""
+		if d := isa.Diagnose(v.ins); d != nil {
+			s = d.String()
+		}
+		if s != v.diag {
+			t.Errorf("%s %08x: \"%s\" (expected \"%s\")", isa, v.ins, s, v.diag)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,246 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Environment Calls
+
+Name the Linux system call (a7) or SBI extension/function (a7/a6) of an
+ecall when the registers are set by a preceding instruction in the block.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import "fmt"
+
+//-----------------------------------------------------------------------------
+
+// EcallABI is the environment call ABI used to name ecalls.
+type EcallABI int
+
+// Environment call ABIs.
+const (
+	EcallLinux EcallABI = iota // Linux system calls (user mode)
+	EcallSBI                   // Supervisor Binary Interface (supervisor mode)
+)
+
+//-----------------------------------------------------------------------------
+// Linux
+
+// linuxSyscalls are the Linux system call numbers common to RV32 and RV64
+// (asm-generic).
+var linuxSyscalls = map[uint]string{
+	0: "io_setup", 1: "io_destroy", 2: "io_submit", 3: "io_cancel",
+	5: "setxattr", 6: "lsetxattr", 7: "fsetxattr", 8: "getxattr",
+	9: "lgetxattr", 10: "fgetxattr", 11: "listxattr", 12: "llistxattr",
+	13: "flistxattr", 14: "removexattr", 15: "lremovexattr", 16: "fremovexattr",
+	17: "getcwd", 18: "lookup_dcookie", 19: "eventfd2", 20: "epoll_create1",
+	21: "epoll_ctl", 22: "epoll_pwait", 23: "dup", 24: "dup3",
+	25: "fcntl", 26: "inotify_init1", 27: "inotify_add_watch", 28: "inotify_rm_watch",
+	29: "ioctl", 30: "ioprio_set", 31: "ioprio_get", 32: "flock",
+	33: "mknodat", 34: "mkdirat", 35: "unlinkat", 36: "symlinkat",
+	37: "linkat", 39: "umount2", 40: "mount", 41: "pivot_root",
+	42: "nfsservctl", 43: "statfs", 44: "fstatfs", 45: "truncate",
+	46: "ftruncate", 47: "fallocate", 48: "faccessat", 49: "chdir",
+	50: "fchdir", 51: "chroot", 52: "fchmod", 53: "fchmodat",
+	54: "fchownat", 55: "fchown", 56: "openat", 57: "close",
+	58: "vhangup", 59: "pipe2", 60: "quotactl", 61: "getdents64",
+	62: "lseek", 63: "read", 64: "write", 65: "readv",
+	66: "writev", 67: "pread64", 68: "pwrite64", 69: "preadv",
+	70: "pwritev", 71: "sendfile", 74: "signalfd4", 75: "vmsplice",
+	76: "splice", 77: "tee", 78: "readlinkat", 81: "sync",
+	82: "fsync", 83: "fdatasync", 84: "sync_file_range", 85: "timerfd_create",
+	89: "acct", 90: "capget", 91: "capset", 92: "personality",
+	93: "exit", 94: "exit_group", 95: "waitid", 96: "set_tid_address",
+	97: "unshare", 99: "set_robust_list", 100: "get_robust_list", 102: "getitimer",
+	103: "setitimer", 104: "kexec_load", 105: "init_module", 106: "delete_module",
+	107: "timer_create", 109: "timer_getoverrun", 111: "timer_delete", 116: "syslog",
+	117: "ptrace", 118: "sched_setparam", 119: "sched_setscheduler", 120: "sched_getscheduler",
+	121: "sched_getparam", 122: "sched_setaffinity", 123: "sched_getaffinity", 124: "sched_yield",
+	125: "sched_get_priority_max", 126: "sched_get_priority_min", 128: "restart_syscall", 129: "kill",
+	130: "tkill", 131: "tgkill", 132: "sigaltstack", 133: "rt_sigsuspend",
+	134: "rt_sigaction", 135: "rt_sigprocmask", 136: "rt_sigpending", 138: "rt_sigqueueinfo",
+	139: "rt_sigreturn", 140: "setpriority", 141: "getpriority", 142: "reboot",
+	143: "setregid", 144: "setgid", 145: "setreuid", 146: "setuid",
+	147: "setresuid", 148: "getresuid", 149: "setresgid", 150: "getresgid",
+	151: "setfsuid", 152: "setfsgid", 153: "times", 154: "setpgid",
+	155: "getpgid", 156: "getsid", 157: "setsid", 158: "getgroups",
+	159: "setgroups", 160: "uname", 161: "sethostname", 162: "setdomainname",
+	163: "getrlimit", 164: "setrlimit", 165: "getrusage", 166: "umask",
+	167: "prctl", 168: "getcpu", 172: "getpid", 173: "getppid",
+	174: "getuid", 175: "geteuid", 176: "getgid", 177: "getegid",
+	178: "gettid", 179: "sysinfo", 180: "mq_open", 181: "mq_unlink",
+	184: "mq_notify", 185: "mq_getsetattr", 186: "msgget", 187: "msgctl",
+	188: "msgrcv", 189: "msgsnd", 190: "semget", 191: "semctl",
+	193: "semop", 194: "shmget", 195: "shmctl", 196: "shmat",
+	197: "shmdt", 198: "socket", 199: "socketpair", 200: "bind",
+	201: "listen", 202: "accept", 203: "connect", 204: "getsockname",
+	205: "getpeername", 206: "sendto", 207: "recvfrom", 208: "setsockopt",
+	209: "getsockopt", 210: "shutdown", 211: "sendmsg", 212: "recvmsg",
+	213: "readahead", 214: "brk", 215: "munmap", 216: "mremap",
+	217: "add_key", 218: "request_key", 219: "keyctl", 220: "clone",
+	221: "execve", 222: "mmap", 223: "fadvise64", 224: "swapon",
+	225: "swapoff", 226: "mprotect", 227: "msync", 228: "mlock",
+	229: "munlock", 230: "mlockall", 231: "munlockall", 232: "mincore",
+	233: "madvise", 234: "remap_file_pages", 235: "mbind", 236: "get_mempolicy",
+	237: "set_mempolicy", 238: "migrate_pages", 239: "move_pages", 240: "rt_tgsigqueueinfo",
+	241: "perf_event_open", 242: "accept4", 258: "riscv_hwprobe", 259: "riscv_flush_icache",
+	261: "prlimit64", 262: "fanotify_init", 263: "fanotify_mark", 264: "name_to_handle_at",
+	265: "open_by_handle_at", 267: "syncfs", 268: "setns", 269: "sendmmsg",
+	270: "process_vm_readv", 271: "process_vm_writev", 272: "kcmp", 273: "finit_module",
+	274: "sched_setattr", 275: "sched_getattr", 276: "renameat2", 277: "seccomp",
+	278: "getrandom", 279: "memfd_create", 280: "bpf", 281: "execveat",
+	282: "userfaultfd", 283: "membarrier", 284: "mlock2", 285: "copy_file_range",
+	286: "preadv2", 287: "pwritev2", 288: "pkey_mprotect", 289: "pkey_alloc",
+	290: "pkey_free", 291: "statx", 293: "rseq", 294: "kexec_file_load",
+	424: "pidfd_send_signal", 425: "io_uring_setup", 426: "io_uring_enter", 427: "io_uring_register",
+	428: "open_tree", 429: "move_mount", 430: "fsopen", 431: "fsconfig",
+	432: "fsmount", 433: "fspick", 434: "pidfd_open", 435: "clone3",
+	436: "close_range", 437: "openat2", 438: "pidfd_getfd", 439: "faccessat2",
+	440: "process_madvise", 441: "epoll_pwait2", 442: "mount_setattr", 443: "quotactl_fd",
+	444: "landlock_create_ruleset", 445: "landlock_add_rule", 446: "landlock_restrict_self", 447: "memfd_secret",
+	448: "process_mrelease", 449: "futex_waitv", 450: "set_mempolicy_home_node", 451: "cachestat",
+	452: "fchmodat2", 453: "map_shadow_stack", 454: "futex_wake", 455: "futex_wait",
+	456: "futex_requeue", 457: "statmount", 458: "listmount", 459: "lsm_get_self_attr",
+	460: "lsm_set_self_attr", 461: "lsm_list_modules", 462: "mseal",
+}
+
+// linuxSyscalls32 are the RV32 system calls with 64-bit file offsets or
+// 64-bit time.
+var linuxSyscalls32 = map[uint]string{
+	25: "fcntl64", 43: "statfs64", 44: "fstatfs64", 45: "truncate64",
+	46: "ftruncate64", 62: "llseek", 71: "sendfile64", 222: "mmap2",
+	223: "fadvise64_64", 403: "clock_gettime64", 404: "clock_settime64", 405: "clock_adjtime64",
+	406: "clock_getres_time64", 407: "clock_nanosleep_time64", 408: "timer_gettime64", 409: "timer_settime64",
+	410: "timerfd_gettime64", 411: "timerfd_settime64", 412: "utimensat_time64", 413: "pselect6_time64",
+	414: "ppoll_time64", 416: "io_pgetevents_time64", 417: "recvmmsg_time64", 418: "mq_timedsend_time64",
+	419: "mq_timedreceive_time64", 420: "semtimedop_time64", 421: "rt_sigtimedwait_time64", 422: "futex_time64",
+	423: "sched_rr_get_interval_time64",
+}
+
+// linuxSyscalls64 are the RV64 only system calls. RV32 has no 32-bit time or
+// new stat calls, it uses the *_time64 and statx calls instead.
+var linuxSyscalls64 = map[uint]string{
+	4: "io_getevents", 72: "pselect6", 73: "ppoll", 79: "newfstatat",
+	80: "fstat", 86: "timerfd_settime", 87: "timerfd_gettime", 88: "utimensat",
+	98: "futex", 101: "nanosleep", 108: "timer_gettime", 110: "timer_settime",
+	112: "clock_settime", 113: "clock_gettime", 114: "clock_getres", 115: "clock_nanosleep",
+	127: "sched_rr_get_interval", 137: "rt_sigtimedwait", 169: "gettimeofday", 170: "settimeofday",
+	171: "adjtimex", 182: "mq_timedsend", 183: "mq_timedreceive", 192: "semtimedop",
+	243: "recvmmsg", 260: "wait4", 266: "clock_adjtime", 292: "io_pgetevents",
+}
+
+// SyscallName returns the name of a Linux system call number.
+func SyscallName(mxlen, n uint) (string, bool) {
+	xlen := linuxSyscalls64
+	if mxlen == 32 {
+		xlen = linuxSyscalls32
+	}
+	if name, ok := xlen[n]; ok {
+		return name, true
+	}
+	name, ok := linuxSyscalls[n]
+	return name, ok
+}
+
+//-----------------------------------------------------------------------------
+// SBI
+
+type sbiExt struct {
+	name string
+	fn   []string
+}
+
+// sbiLegacy are the legacy (v0.1) SBI extensions.
+var sbiLegacy = []string{
+	"set_timer", "console_putchar", "console_getchar", "clear_ipi", "send_ipi",
+	"remote_fence_i", "remote_sfence_vma", "remote_sfence_vma_asid", "shutdown",
+}
+
+// sbiExts are the SBI extensions by extension id.
+var sbiExts = map[uint]sbiExt{
+	0x10: {"BASE", []string{"get_sbi_spec_version", "get_sbi_impl_id", "get_sbi_impl_version",
+		"probe_extension", "get_mvendorid", "get_marchid", "get_mimpid"}},
+	0x54494d45: {"TIME", []string{"set_timer"}},
+	0x735049:   {"IPI", []string{"send_ipi"}},
+	0x52464e43: {"RFENCE", []string{"remote_fence_i", "remote_sfence_vma", "remote_sfence_vma_asid",
+		"remote_hfence_gvma_vmid", "remote_hfence_gvma", "remote_hfence_vvma_asid", "remote_hfence_vvma"}},
+	0x48534d:   {"HSM", []string{"hart_start", "hart_stop", "hart_get_status", "hart_suspend"}},
+	0x53525354: {"SRST", []string{"system_reset"}},
+	0x504d55: {"PMU", []string{"num_counters", "counter_get_info", "counter_config_matching",
+		"counter_start", "counter_stop", "counter_fw_read", "counter_fw_read_hi",
+		"snapshot_set_shmem", "event_get_info"}},
+	0x4442434e: {"DBCN", []string{"console_write", "console_read", "console_write_byte"}},
+	0x53555350: {"SUSP", []string{"system_suspend"}},
+	0x43505043: {"CPPC", []string{"probe", "read", "read_hi", "write"}},
+	0x4e41434c: {"NACL", []string{"probe_feature", "set_shmem", "sync_csr", "sync_hfence", "sync_sret"}},
+	0x535441:   {"STA", []string{"set_shmem"}},
+	0x46574654: {"FWFT", []string{"set", "get"}},
+}
+
+// SBIExtName returns the name of an SBI extension id.
+func SBIExtName(eid uint) (string, bool) {
+	if eid < uint(len(sbiLegacy)) {
+		return "legacy", true
+	}
+	if ext, ok := sbiExts[eid]; ok {
+		return ext.name, true
+	}
+	return "", false
+}
+
+// SBIName returns the name of an SBI call, e.g. "TIME set_timer" or
+// "legacy console_putchar". The function id is not used by legacy calls.
+func SBIName(eid, fid uint) (string, bool) {
+	if eid < uint(len(sbiLegacy)) {
+		return "legacy " + sbiLegacy[eid], true
+	}
+	ext, ok := sbiExts[eid]
+	if !ok || fid >= uint(len(ext.fn)) {
+		return "", false
+	}
+	return ext.name + " " + ext.fn[fid], true
+}
+
+//-----------------------------------------------------------------------------
+
+// ecallName returns the name of an ecall given the known register values.
+func ecallName(isa *ISA, abi EcallABI, regs *regTracker) string {
+	a7, ok := regs.value(17)
+	if !ok {
+		return ""
+	}
+	switch abi {
+	case EcallLinux:
+		if name, ok := SyscallName(isa.mxlen, a7); ok {
+			return name
+		}
+		return fmt.Sprintf("syscall %d", a7)
+	case EcallSBI:
+		if a6, ok := regs.value(16); ok || a7 < uint(len(sbiLegacy)) {
+			if name, ok := SBIName(a7, a6); ok {
+				return name
+			}
+		}
+		if name, ok := SBIExtName(a7); ok {
+			return name
+		}
+		return fmt.Sprintf("sbi 0x%x", a7)
+	}
+	return ""
+}
+
+// Ecalls annotates the ecall instructions in a listing with the Linux
+// system call or SBI call name when a7 (and a6 for SBI) are set by a
+// preceding instruction in the same basic block.
+func (l *Listing) Ecalls(isa *ISA, syms *Symbols, abi EcallABI) {
+	l.consts(isa, syms, nil, func(i int, regs *regTracker) {
+		da := l.Lines[i].Da
+		if da.id() != "ecall" || da.Comment != "" {
+			return
+		}
+		da.Comment = ecallName(isa, abi, regs)
+	})
+}
+
+//-----------------------------------------------------------------------------
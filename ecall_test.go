@@ -0,0 +1,88 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Environment Call Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func ecallComments(t *testing.T, abi EcallABI, ins ...uint) string {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := Sweep(isa, testCode(0, ins...))
+	l.Ecalls(isa, nil, abi)
+	s := []string{}
+	for _, line := range l.Lines {
+		if line.Da != nil && line.Da.Assembly == "ecall" {
+			s = append(s, line.Da.Comment)
+		}
+	}
+	return strings.Join(s, ",")
+}
+
+func Test_Ecalls(t *testing.T) {
+	s := ecallComments(t, EcallLinux,
+		0x04000893, // li a7,64
+		0x00000073, // ecall
+		0x00000073, // ecall
+	)
+	if s != "write," {
+		t.Errorf("linux ecalls \"%s\" (expected \"write,\")", s)
+	}
+
+	s = ecallComments(t, EcallSBI,
+		0x544958b7, // lui a7,0x54495
+		0xd4588893, // addi a7,a7,-699
+		0x4801,     // li a6,0
+		0x00000073, // ecall
+		0x4885,     // li a7,1
+		0x00000073, // ecall
+	)
+	if s != "TIME set_timer,legacy console_putchar" {
+		t.Errorf("sbi ecalls \"%s\"", s)
+	}
+
+	// a7 set by a folded "li" line
+	isa, _ := New(32, RV32gc)
+	l := Sweep(isa, testCode(0, 0x544958b7, 0xd4588893, 0x4801, 0x00000073))
+	l.Fold(isa, nil, nil, true)
+	l.Ecalls(isa, nil, EcallSBI)
+	if da := l.Lines[len(l.Lines)-1].Da; da.Comment != "TIME set_timer" {
+		t.Errorf("%s: folded sbi ecall \"%s\"", da, da.Comment)
+	}
+
+	if name, _ := SyscallName(32, 62); name != "llseek" {
+		t.Errorf("syscall 62 %s (expected llseek)", name)
+	}
+	if name, _ := SyscallName(64, 94); name != "exit_group" {
+		t.Errorf("syscall 94 %s (expected exit_group)", name)
+	}
+	if name, _ := SyscallName(64, 113); name != "clock_gettime" {
+		t.Errorf("syscall 113 %s (expected clock_gettime)", name)
+	}
+	if _, ok := SyscallName(32, 113); ok {
+		t.Error("rv32 syscall 113 should not exist")
+	}
+	if name, _ := SyscallName(32, 403); name != "clock_gettime64" {
+		t.Errorf("syscall 403 %s (expected clock_gettime64)", name)
+	}
+	if _, ok := SyscallName(64, 403); ok {
+		t.Error("rv64 syscall 403 should not exist")
+	}
+	if name, _ := SBIName(0x48534d, 0); name != "HSM hart_start" {
+		t.Errorf("sbi %s (expected HSM hart_start)", name)
+	}
+}
+
+//-----------------------------------------------------------------------------
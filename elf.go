@@ -0,0 +1,238 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V ELF Files
+
+Load the code and symbols from a RISC-V ELF file.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// Program is the code and symbols of an executable.
+type Program struct {
+	Mxlen   uint     // machine register length
+	Entry   uint     // entry point
+	Code    []*Code  // executable sections
+	Funcs   []uint   // function symbol addresses
+	Symbols *Symbols // symbols
+	GP      uint     // global pointer (__global_pointer$, 0 if unknown)
+	TLS     uint     // thread local storage segment address (0 if none)
+	Arch    string   // ISA string from the ELF attributes ("" if none)
+	JVT     *Code    // jump vector table (__jvt_base$, nil if none)
+}
+
+// Known returns the registers with values known from the program
+// (gp and tp) for constant folding (see Fold).
+func (p *Program) Known() map[uint]uint {
+	known := make(map[uint]uint)
+	if p.GP != 0 {
+		known[3] = p.GP
+	}
+	if p.TLS != 0 {
+		known[4] = p.TLS
+	}
+	return known
+}
+
+// CodeAt returns the code region containing an address (or nil).
+func (p *Program) CodeAt(addr uint) *Code {
+	for _, c := range p.Code {
+		if c.Contains(addr, 1) {
+			return c
+		}
+	}
+	return nil
+}
+
+// shtRISCVAttributes is the RISC-V attributes section type.
+const shtRISCVAttributes = 0x70000003
+
+// tagRISCVArch is the ISA string attribute tag.
+const tagRISCVArch = 5
+
+// uleb128 decodes an unsigned LEB128 value and returns the remaining bytes.
+func uleb128(b []byte) (uint, []byte) {
+	var x uint
+	for i, c := range b {
+		x |= uint(c&0x7f) << (7 * uint(i))
+		if c&0x80 == 0 {
+			return x, b[i+1:]
+		}
+	}
+	return x, nil
+}
+
+// elfArch returns the ISA string from a RISC-V attributes section.
+func elfArch(b []byte) string {
+	if len(b) == 0 || b[0] != 'A' {
+		return ""
+	}
+	b = b[1:]
+	for len(b) >= 4 {
+		// vendor subsection
+		n := uint(binary.LittleEndian.Uint32(b))
+		if n < 4 || n > uint(len(b)) {
+			return ""
+		}
+		sub := b[4:n]
+		b = b[n:]
+		i := bytes.IndexByte(sub, 0)
+		if i < 0 || string(sub[:i]) != "riscv" {
+			continue
+		}
+		sub = sub[i+1:]
+		for len(sub) > 5 {
+			// file attributes: tag, size, attributes
+			tag, x := uleb128(sub)
+			if len(x) < 4 {
+				return ""
+			}
+			k := uint(len(sub) - len(x)) // tag length
+			n := uint(binary.LittleEndian.Uint32(x))
+			if n < 4+k || n > uint(len(sub)) {
+				return ""
+			}
+			attr := x[4 : n-k]
+			sub = sub[n:]
+			if tag != 1 {
+				continue
+			}
+			for len(attr) > 0 {
+				tag, attr = uleb128(attr)
+				if tag&1 != 0 {
+					// odd tags are strings
+					i := bytes.IndexByte(attr, 0)
+					if i < 0 {
+						return ""
+					}
+					if tag == tagRISCVArch {
+						return string(attr[:i])
+					}
+					attr = attr[i+1:]
+				} else {
+					_, attr = uleb128(attr)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// LoadELF loads the executable sections and symbols of a RISC-V ELF file.
+func LoadELF(filename string) (*Program, error) {
+	f, err := elf.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if f.Machine != elf.EM_RISCV {
+		return nil, fmt.Errorf("%s: not a RISC-V ELF file (%s)", filename, f.Machine)
+	}
+
+	p := &Program{
+		Entry:   uint(f.Entry),
+		Symbols: NewSymbols(),
+	}
+
+	switch f.Class {
+	case elf.ELFCLASS32:
+		p.Mxlen = 32
+	case elf.ELFCLASS64:
+		p.Mxlen = 64
+	default:
+		return nil, fmt.Errorf("%s: unknown ELF class %s: %w", filename, f.Class, ErrUnsupportedXLEN)
+	}
+
+	// executable sections
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_PROGBITS || s.Flags&elf.SHF_EXECINSTR == 0 {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		p.Code = append(p.Code, &Code{Addr: uint(s.Addr), Data: data})
+	}
+
+	// ISA string
+	for _, s := range f.Sections {
+		if s.Type == shtRISCVAttributes {
+			if data, err := s.Data(); err == nil {
+				p.Arch = elfArch(data)
+			}
+		}
+	}
+
+	// thread local storage
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_TLS {
+			p.TLS = uint(prog.Vaddr)
+		}
+	}
+
+	// symbols
+	syms, err := f.Symbols()
+	if err != nil && err != elf.ErrNoSymbols {
+		return nil, err
+	}
+	for _, s := range syms {
+		if s.Name == "" || s.Section == elf.SHN_UNDEF || strings.HasPrefix(s.Name, "$") || strings.HasPrefix(s.Name, ".L") {
+			continue
+		}
+		switch elf.ST_TYPE(s.Info) {
+		case elf.STT_FUNC:
+			p.Funcs = append(p.Funcs, uint(s.Value))
+			p.Symbols.Add(uint(s.Value), s.Name)
+		case elf.STT_NOTYPE, elf.STT_OBJECT:
+			p.Symbols.Add(uint(s.Value), s.Name)
+			if s.Name == "__global_pointer$" {
+				p.GP = uint(s.Value)
+			}
+			if s.Name == "__jvt_base$" {
+				p.JVT = elfData(f, uint(s.Value))
+			}
+		case elf.STT_TLS:
+			// the value is the offset within the TLS segment
+			if p.TLS != 0 {
+				p.Symbols.Add(p.TLS+uint(s.Value), s.Name)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// elfData returns the section data from an address to the end of the
+// section (or nil).
+func elfData(f *elf.File, addr uint) *Code {
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_PROGBITS || s.Flags&elf.SHF_ALLOC == 0 {
+			continue
+		}
+		if addr < uint(s.Addr) || addr >= uint(s.Addr+s.Size) {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil
+		}
+		return &Code{Addr: addr, Data: data[addr-uint(s.Addr):]}
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,105 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Disassembler Errors
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+
+// Disassembly errors.
+var (
+	ErrIllegal         = errors.New("illegal instruction")
+	ErrReserved        = errors.New("reserved instruction")
+	ErrUnsupportedXLEN = errors.New("unsupported register length")
+	ErrTruncated       = errors.New("truncated instruction")
+	ErrMisaligned      = errors.New("misaligned instruction")
+)
+
+// InsError is an error decoding an instruction.
+type InsError struct {
+	Err    error  // ErrIllegal, ErrReserved, ErrTruncated or ErrMisaligned
+	Addr   uint   // instruction address
+	Ins    uint   // raw instruction word
+	Reason string // why the instruction is in error
+}
+
+func (e *InsError) Error() string {
+	s := fmt.Sprintf("%x: %s (0x%x)", e.Addr, e.Err, e.Ins)
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+// Unwrap returns the underlying error (for errors.Is).
+func (e *InsError) Unwrap() error {
+	return e.Err
+}
+
+//-----------------------------------------------------------------------------
+
+// ialign returns the instruction alignment in bytes.
+func (isa *ISA) ialign() uint {
+	if isa.ext&ExtC != 0 {
+		return 2
+	}
+	return 4
+}
+
+// DisassembleChecked disassembles an instruction at the address and returns
+// an *InsError (wrapping ErrIllegal, ErrReserved or ErrMisaligned) if the
+// instruction is not a valid instruction for the ISA. The disassembly is
+// returned in all cases.
+func (isa *ISA) DisassembleChecked(addr, ins uint) (*Disassembly, error) {
+	da := isa.Disassemble(addr, ins)
+	if addr%isa.ialign() != 0 {
+		return da, &InsError{ErrMisaligned, addr, da.Ins, fmt.Sprintf("%d-byte alignment required", isa.ialign())}
+	}
+	switch da.Class {
+	case ClassIllegal:
+		reason := "unknown encoding"
+		if ins&0x1f == 0x1f {
+			reason = "instruction length > 32 bits"
+		} else if da.id() == "c.illegal" {
+			reason = "all zero instruction"
+		} else if d := isa.Diagnose(da.Ins); d != nil {
+			reason = d.String()
+		}
+		return da, &InsError{ErrIllegal, addr, da.Ins, reason}
+	case ClassReserved:
+		return da, &InsError{ErrReserved, addr, da.Ins, "reserved encoding of " + da.id()}
+	}
+	return da, nil
+}
+
+// DisassembleBytes disassembles the little endian instruction at the start
+// of a buffer. It returns an *InsError wrapping ErrTruncated if the buffer
+// is shorter than the instruction, or the errors of DisassembleChecked.
+func (isa *ISA) DisassembleBytes(addr uint, buf []byte) (*Disassembly, error) {
+	if len(buf) < 2 {
+		var ins uint
+		if len(buf) == 1 {
+			ins = uint(buf[0])
+		}
+		return nil, &InsError{ErrTruncated, addr, ins, fmt.Sprintf("%d bytes available", len(buf))}
+	}
+	ins := uint(buf[0]) | uint(buf[1])<<8
+	if ins&3 == 3 {
+		if len(buf) < 4 {
+			return nil, &InsError{ErrTruncated, addr, ins, "32-bit instruction, 2 bytes available"}
+		}
+		ins |= uint(buf[2])<<16 | uint(buf[3])<<24
+	}
+	return isa.DisassembleChecked(addr, ins)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,54 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Disassembler Errors Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"errors"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Errors(t *testing.T) {
+	_, err := New(128, RV64gc)
+	if !errors.Is(err, ErrUnsupportedXLEN) {
+		t.Errorf("New(128): %v (expected ErrUnsupportedXLEN)", err)
+	}
+
+	isa, err := New(32, ExtI|ExtM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		addr uint
+		buf  []byte
+		err  error
+		msg  string
+	}{
+		{0, []byte{0x33, 0x05, 0xb5, 0x02}, nil, ""},
+		{2, []byte{0x33, 0x05, 0xb5, 0x02}, ErrMisaligned, "2: misaligned instruction (0x2b50533): 4-byte alignment required"},
+		{0, []byte{0x53, 0x75, 0xb5, 0x00}, ErrIllegal, "0: illegal instruction (0xb57553): fadd.s: requires F"},
+		{0, []byte{0x1f, 0x00, 0x00, 0x00}, ErrIllegal, "0: illegal instruction (0x1f): instruction length > 32 bits"},
+		{0, []byte{0x13, 0x10, 0x05, 0x02}, ErrReserved, "0: reserved instruction (0x2051013): reserved encoding of slli"},
+		{0, []byte{0x33, 0x05}, ErrTruncated, "0: truncated instruction (0x533): 32-bit instruction, 2 bytes available"},
+		{0, []byte{}, ErrTruncated, "0: truncated instruction (0x0): 0 bytes available"},
+	}
+	for _, v := range testCases {
+		_, err := isa.DisassembleBytes(v.addr, v.buf)
+		if !errors.Is(err, v.err) || (err != nil && err.Error() != v.msg) {
+			t.Errorf("%x: %v (expected %s)", v.buf, err, v.msg)
+		}
+		var ie *InsError
+		if err != nil && !errors.As(err, &ie) {
+			t.Errorf("%x: %v is not an *InsError", v.buf, err)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
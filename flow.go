@@ -0,0 +1,204 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Control Flow
+
+Classify the control flow of instructions and compute their successors.
+Call/return detection follows the ra/t0 link register hints of the spec.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+//-----------------------------------------------------------------------------
+
+// Flow is the control flow kind of an instruction.
+type Flow int
+
+// Control flow kinds.
+const (
+	FlowNext         Flow = iota // fall through to the next instruction
+	FlowBranch                   // conditional branch
+	FlowJump                     // direct jump
+	FlowCall                     // direct call
+	FlowIndirectJump             // indirect jump
+	FlowIndirectCall             // indirect call
+	FlowReturn                   // return
+	FlowTrap                     // trap (ecall, ebreak)
+	FlowTrapReturn               // trap return (mret, sret, uret)
+	FlowWfi                      // wait for interrupt
+)
+
+var flowName = map[Flow]string{
+	FlowNext:         "next",
+	FlowBranch:       "branch",
+	FlowJump:         "jump",
+	FlowCall:         "call",
+	FlowIndirectJump: "indirect jump",
+	FlowIndirectCall: "indirect call",
+	FlowReturn:       "return",
+	FlowTrap:         "trap",
+	FlowTrapReturn:   "trap return",
+	FlowWfi:          "wfi",
+}
+
+func (f Flow) String() string {
+	return flowName[f]
+}
+
+// HasTarget returns true if the control flow has a static target.
+func (f Flow) HasTarget() bool {
+	return f == FlowBranch || f == FlowJump || f == FlowCall
+}
+
+// IsCall returns true if the control flow is a call.
+func (f Flow) IsCall() bool {
+	return f == FlowCall || f == FlowIndirectCall
+}
+
+// isLink returns true if the register is a link register (ra or t0).
+func isLink(r uint) bool {
+	return r == 1 || r == 5
+}
+
+//-----------------------------------------------------------------------------
+// control flow decoders
+
+// flowDecode returns the control flow kind and target offset of an instruction.
+type flowDecode func(ins uint) (Flow, int)
+
+func flowTypeB(ins uint) (Flow, int) {
+	imm, _, _ := decodeB(ins)
+	return FlowBranch, imm
+}
+
+func flowTypeJ(ins uint) (Flow, int) {
+	imm, rd := decodeJ(ins)
+	if isLink(rd) {
+		return FlowCall, imm
+	}
+	return FlowJump, imm
+}
+
+func flowTypeJALR(ins uint) (Flow, int) {
+	_, rs1, rd := decodeIa(ins)
+	if isLink(rd) {
+		return FlowIndirectCall, 0
+	}
+	if isLink(rs1) {
+		return FlowReturn, 0
+	}
+	return FlowIndirectJump, 0
+}
+
+func flowTypeCB(ins uint) (Flow, int) {
+	imm, _ := decodeCB(ins)
+	return FlowBranch, imm
+}
+
+func flowTypeCJ(ins uint) (Flow, int) {
+	return FlowJump, decodeCJ(ins)
+}
+
+func flowTypeCJAL(ins uint) (Flow, int) {
+	return FlowCall, decodeCJ(ins)
+}
+
+func flowTypeCJR(ins uint) (Flow, int) {
+	rs1, _ := decodeCR(ins)
+	if isLink(rs1) {
+		return FlowReturn, 0
+	}
+	return FlowIndirectJump, 0
+}
+
+func flowConst(f Flow) flowDecode {
+	return func(ins uint) (Flow, int) {
+		return f, 0
+	}
+}
+
+var flowOps = map[string]flowDecode{
+	"jal":      flowTypeJ,
+	"jalr":     flowTypeJALR,
+	"beq":      flowTypeB,
+	"bne":      flowTypeB,
+	"blt":      flowTypeB,
+	"bge":      flowTypeB,
+	"bltu":     flowTypeB,
+	"bgeu":     flowTypeB,
+	"ecall":    flowConst(FlowTrap),
+	"ebreak":   flowConst(FlowTrap),
+	"uret":     flowConst(FlowTrapReturn),
+	"sret":     flowConst(FlowTrapReturn),
+	"mret":     flowConst(FlowTrapReturn),
+	"wfi":      flowConst(FlowWfi),
+	"c.j":      flowTypeCJ,
+	"c.jal":    flowTypeCJAL,
+	"c.jr":     flowTypeCJR,
+	"c.jalr":   flowConst(FlowIndirectCall),
+	"c.beqz":   flowTypeCB,
+	"c.bnez":   flowTypeCB,
+	"c.ebreak": flowConst(FlowTrap),
+	// Zcmp/Zcmt
+	"cm.popret":  flowConst(FlowReturn),
+	"cm.popretz": flowConst(FlowReturn),
+	"cm.jt":      flowConst(FlowIndirectJump),
+	"cm.jalt":    flowConst(FlowIndirectCall),
+	// CORE-V
+	"cv.beqimm": flowTypeB,
+	"cv.bneimm": flowTypeB,
+}
+
+// flow returns the control flow kind and target offset for an instruction.
+func (im *insMeta) flow(ins uint) (Flow, int) {
+	if im.fd != nil {
+		return im.fd(ins)
+	}
+	if fd, ok := flowOps[im.id]; ok {
+		return fd(ins)
+	}
+	return FlowNext, 0
+}
+
+//-----------------------------------------------------------------------------
+
+// auipcTarget returns the target of an "auipc rd,hi; jalr lo(rd)" pair
+// (as used by the call and tail pseudo instructions).
+func (isa *ISA) auipcTarget(prev, da *Disassembly) (uint, bool) {
+	if da.id() != "jalr" {
+		return 0, false
+	}
+	return isa.auipcPair(prev, da)
+}
+
+//-----------------------------------------------------------------------------
+
+// mask reduces an address to the address length of the disassembly.
+func (da *Disassembly) mask(x uint) uint {
+	if da.AddrLength == 32 {
+		return uint(uint32(x))
+	}
+	return x
+}
+
+// Successors returns the static successor addresses of the instruction
+// when it is located at pc. Indirect jumps and returns have no static
+// successors. Calls, traps and wfi are assumed to return to the next
+// instruction.
+func (da *Disassembly) Successors(pc uint) []uint {
+	next := da.mask(pc + da.InsLength)
+	target := da.mask(pc + da.Target - da.Addr)
+	switch da.Flow {
+	case FlowBranch, FlowCall:
+		return []uint{next, target}
+	case FlowJump:
+		return []uint{target}
+	case FlowIndirectJump, FlowReturn, FlowTrapReturn:
+		return nil
+	}
+	return []uint{next}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,283 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Constant Folding
+
+Large constants are built with lui/addi(w)/slli chains. Track the register
+constants across these sequences and annotate (or collapse) them, e.g.
+"li a0,0xdeadbeef" or "# a0 = 0x12345678abcd". Loads, stores and address
+computations relative to a register with a known value (e.g. gp, tp) are
+annotated with the address and symbol.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import "fmt"
+
+//-----------------------------------------------------------------------------
+
+// foldOp returns the destination, source and operation of an instruction
+// that computes a constant from a constant.
+func foldOp(da *Disassembly) (uint, uint, func(x uint) uint, bool) {
+	switch da.id() {
+	case "lui":
+		hi, rd := decodeU(da.Ins)
+		return rd, 0, func(x uint) uint { return uint(hi << 12) }, true
+	case "c.lui":
+		hi, rd := decodeCIf(da.Ins)
+		return rd, 0, func(x uint) uint { return uint(hi << 12) }, true
+	case "addi":
+		imm, rs1, rd := decodeIa(da.Ins)
+		return rd, rs1, func(x uint) uint { return x + uint(imm) }, true
+	case "c.addi", "c.li":
+		imm, rd := decodeCIa(da.Ins)
+		rs1 := rd
+		if da.id() == "c.li" {
+			rs1 = 0
+		}
+		return rd, rs1, func(x uint) uint { return x + uint(imm) }, true
+	case "addiw":
+		imm, rs1, rd := decodeIa(da.Ins)
+		return rd, rs1, func(x uint) uint { return uint(int32(x + uint(imm))) }, true
+	case "c.addiw":
+		imm, rd := decodeCIa(da.Ins)
+		return rd, rd, func(x uint) uint { return uint(int32(x + uint(imm))) }, true
+	case "slli":
+		shamt, rs1, rd := decodeIc(da.Ins)
+		return rd, rs1, func(x uint) uint { return x << shamt }, true
+	case "c.slli":
+		shamt, rd := decodeCId(da.Ins)
+		return rd, rd, func(x uint) uint { return x << shamt }, true
+	case "c.mv":
+		rd, rs := decodeCR(da.Ins)
+		return rd, rs, func(x uint) uint { return x }, true
+	}
+	return 0, 0, nil, false
+}
+
+// foldSymOffset bounds the symbol offset of an address annotation. Symbol
+// sizes aren't known, so a constant further from the closest symbol is
+// taken to be a plain integer.
+const foldSymOffset = 0x1000
+
+// addrComment returns an address annotation.
+func addrComment(addr uint, syms *Symbols) string {
+	if _, offset, ok := syms.Lookup(addr); ok && offset < foldSymOffset {
+		return fmt.Sprintf("0x%x <%s>", addr, syms.Symbolize(addr))
+	}
+	return fmt.Sprintf("0x%x", addr)
+}
+
+// leaders returns the addresses that start a basic block in a listing
+// (branch/jump/call targets and symbols).
+func (l *Listing) leaders(syms *Symbols) map[uint]bool {
+	leaders := make(map[uint]bool)
+	for _, line := range l.Lines {
+		if line.Da != nil && line.Da.Flow.HasTarget() {
+			leaders[line.Da.Target] = true
+		}
+		if _, ok := syms.Name(line.Addr); ok {
+			leaders[line.Addr] = true
+		}
+	}
+	return leaders
+}
+
+// regConst is a register with a known constant value.
+type regConst struct {
+	val    uint // constant value
+	n      int  // number of instructions in the chain (0 for a base register)
+	first  int  // line index of the first instruction in the chain
+	last   int  // line index of the last instruction in the chain
+	contig bool // the chain is a contiguous "op rd,rd,..." sequence
+	rel    bool // address computed relative to a base register
+}
+
+// regTracker tracks the x register constants through a listing. The known
+// registers hold their values at the start of each basic block.
+type regTracker struct {
+	isa     *ISA
+	known   map[uint]uint      // registers with values known on block entry
+	leaders map[uint]bool      // basic block start addresses
+	regs    map[uint]*regConst // current register constants
+}
+
+// tracker returns a register constant tracker for a listing.
+func (l *Listing) tracker(isa *ISA, syms *Symbols, known map[uint]uint) *regTracker {
+	t := &regTracker{
+		isa:     isa,
+		known:   known,
+		leaders: l.leaders(syms),
+	}
+	t.reset()
+	return t
+}
+
+// reset sets the registers to their values on block entry.
+func (t *regTracker) reset() {
+	t.regs = make(map[uint]*regConst)
+	for r, v := range t.known {
+		t.regs[r] = &regConst{val: v}
+	}
+}
+
+// value returns the value of a register (if known).
+func (t *regTracker) value(r uint) (uint, bool) {
+	if x := t.regs[r]; x != nil {
+		return x.val, true
+	}
+	return 0, false
+}
+
+// enter resets the registers if a line starts a basic block.
+func (t *regTracker) enter(line *Line) {
+	if t.leaders[line.Addr] {
+		t.reset()
+	}
+}
+
+// step updates the registers with the instructions of the line at index
+// idx. If the line is a single instruction computing a constant the
+// destination register and its constant are returned.
+func (t *regTracker) step(line *Line, idx int) (uint, *regConst) {
+	var rd uint
+	var r *regConst
+	for _, da := range append([]*Disassembly{line.Da}, line.Seq...) {
+		rd, r = t.fold(da, idx)
+		if r != nil && len(line.Seq) != 0 {
+			// a chain doesn't continue through a merged line
+			r.contig = false
+		}
+		if da.Flow != FlowNext {
+			t.reset()
+		}
+	}
+	if len(line.Seq) != 0 {
+		return 0, nil
+	}
+	return rd, r
+}
+
+// fold updates the registers with an instruction at line index idx.
+func (t *regTracker) fold(da *Disassembly, idx int) (uint, *regConst) {
+	rd, rs1, op, ok := foldOp(da)
+	if !ok || rd == 0 {
+		// the registers written by the instruction are unknown
+		for _, r := range da.Defs {
+			if r.File == RegX {
+				delete(t.regs, r.Num)
+			}
+		}
+		return 0, nil
+	}
+	src := &regConst{contig: true}
+	if rs1 != 0 {
+		src = t.regs[rs1]
+	}
+	if src == nil {
+		delete(t.regs, rd)
+		return 0, nil
+	}
+	r := &regConst{val: t.isa.addr(op(src.val)), n: src.n + 1, first: idx, last: idx, contig: true}
+	if src.n == 0 && rs1 != 0 {
+		// address computation relative to a base register
+		r.contig, r.rel = false, true
+	} else if src.n != 0 {
+		r.first = src.first
+		r.contig = src.contig && rs1 == rd && src.last == idx-1
+	}
+	t.regs[rd] = r
+	return rd, r
+}
+
+// consts tracks the x register constants through a listing, calling fn for
+// each instruction with the tracker holding the constants known before the
+// instruction.
+func (l *Listing) consts(isa *ISA, syms *Symbols, known map[uint]uint, fn func(i int, t *regTracker)) {
+	t := l.tracker(isa, syms, known)
+	for i, line := range l.Lines {
+		t.enter(line)
+		if line.Da == nil {
+			continue
+		}
+		fn(i, t)
+		t.step(line, i)
+	}
+}
+
+// Fold tracks register constants through a listing. Constants built by
+// instruction chains are annotated with the register value, or with
+// collapse set are merged into a single "li" pseudo instruction line.
+// Memory accesses and address computations relative to a known register
+// are annotated with the address and symbol. The known registers (e.g.
+// gp, tp) hold their values at the start of each basic block.
+func (l *Listing) Fold(isa *ISA, syms *Symbols, known map[uint]uint, collapse bool) {
+	t := l.tracker(isa, syms, known)
+	lines := make([]*Line, 0, len(l.Lines))
+	for i, line := range l.Lines {
+		t.enter(line)
+		lines = append(lines, line)
+		da := line.Da
+		if da == nil {
+			continue
+		}
+		idx := len(lines) - 1
+
+		// memory access relative to a known register
+		if m := da.Mem; m != nil && m.Base.File == RegX && m.Base.Num != 0 && da.Comment == "" {
+			if base, ok := t.value(m.Base.Num); ok {
+				da.Comment = addrComment(isa.addr(base+uint(m.Offset)), syms)
+			}
+		}
+
+		rd, r := t.step(line, idx)
+		if r == nil {
+			continue
+		}
+		if r.rel {
+			// address computation relative to a base register
+			if da.Comment == "" {
+				da.Comment = addrComment(r.val, syms)
+			}
+			continue
+		}
+		if r.n < 2 {
+			continue
+		}
+
+		// does the chain continue with the next instruction?
+		if i+1 < len(l.Lines) && l.Lines[i+1].Da != nil {
+			if nrd, nrs1, _, ok := foldOp(l.Lines[i+1].Da); ok && nrd == rd && nrs1 == rd {
+				continue
+			}
+		}
+
+		if collapse && r.contig {
+			first := lines[r.first]
+			x := *first.Da
+			x.Assembly = fmt.Sprintf("li %s,0x%x", abiXName[rd], r.val)
+			x.Comment = ""
+			cl := &Line{
+				Addr:       first.Addr,
+				AddrLength: first.AddrLength,
+				Da:         &x,
+			}
+			for _, ln := range lines[r.first:] {
+				cl.Size += ln.Size
+				if ln != first {
+					cl.Seq = append(cl.Seq, ln.Da)
+				}
+			}
+			lines = append(lines[:r.first], cl)
+			r.first = len(lines) - 1
+			r.last = r.first
+		} else if da.Comment == "" {
+			da.Comment = fmt.Sprintf("%s = 0x%x", abiXName[rd], r.val)
+		}
+	}
+	l.Lines = lines
+}
+
+//-----------------------------------------------------------------------------
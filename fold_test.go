@@ -0,0 +1,81 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Constant Folding Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func foldListing(t *testing.T, mxlen, ext uint, collapse bool, ins ...uint) string {
+	isa, err := New(mxlen, ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syms := NewSymbols()
+	syms.Add(0x10000, "data")
+	syms.Add(0x20000, "tls")
+	l := Sweep(isa, testCode(0, ins...))
+	l.Fold(isa, syms, map[uint]uint{3: 0x10800, 4: 0x20000}, collapse)
+	s := []string{}
+	for _, line := range l.Lines {
+		s = append(s, line.String())
+	}
+	return strings.Join(s, "\n")
+}
+
+func Test_Fold(t *testing.T) {
+	code := []uint{
+		0xdeadc537, // 00: lui a0,0xdeadc
+		0xeef50513, // 04: addi a0,a0,-273
+		0x123455b7, // 08: lui a1,0x12345
+		0x6785a603, // 0c: lw a2,1656(a1)
+		0x80018693, // 10: addi a3,gp,-2048
+		0x01022703, // 14: lw a4,16(tp)
+		0x4785,     // 18: li a5,1
+		0x07b2,     // 1a: slli a5,a5,12
+		0x8082,     // 1c: ret
+	}
+	expected := []string{
+		"00000000: deadc537 \tlui a0,0xdeadc",
+		"00000004: eef50513 \taddi a0,a0,-273 # a0 = 0xdeadbeef",
+		"00000008: 123455b7 \tlui a1,0x12345",
+		"0000000c: 6785a603 \tlw a2,1656(a1) # 0x12345678",
+		"00000010: 80018693 \taddi a3,gp,-2048 # 0x10000 <data>",
+		"00000014: 01022703 \tlw a4,16(tp) # 0x20010 <tls+0x10>",
+		"00000018: 4785     \tli a5,1",
+		"0000001a: 07b2     \tslli a5,a5,0xc # a5 = 0x1000",
+		"0000001c: 8082     \tret",
+	}
+	if s := foldListing(t, 32, RV32gc, false, code...); s != strings.Join(expected, "\n") {
+		t.Errorf("annotated listing\n%s\n(expected)\n%s", s, strings.Join(expected, "\n"))
+	}
+
+	// li a0,0x12345678abcd
+	code = []uint{
+		0x00092537, // 00: lui a0,0x92
+		0xa2b5051b, // 04: addiw a0,a0,-1493
+		0x0536,     // 08: slli a0,a0,0xd
+		0x78b50513, // 0a: addi a0,a0,1931
+		0x0532,     // 0e: slli a0,a0,0xc
+		0xbcd50513, // 10: addi a0,a0,-1075
+		0x8082,     // 14: ret
+	}
+	expected = []string{
+		"0000000000000000: 00092537 a2b5051b 0536     78b50513 0532     bcd50513 \tli a0,0x12345678abcd",
+		"0000000000000014: 8082     \tret",
+	}
+	if s := foldListing(t, 64, RV64gc, true, code...); s != strings.Join(expected, "\n") {
+		t.Errorf("collapsed listing\n%s\n(expected)\n%s", s, strings.Join(expected, "\n"))
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,311 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Function Detection
+
+Find function boundaries in code without symbols. Function starts are found
+from call targets, stack allocating prologues and landing pads. Epilogues
+and tail calls are found by walking each function.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// Func is a function found in code.
+type Func struct {
+	Addr      uint   // entry address
+	End       uint   // end address of the furthest instruction reached
+	Name      string // symbol name (or a synthesized name)
+	Prologue  bool   // the function starts with a stack allocating prologue
+	Calls     []uint // addresses of the call instructions
+	Epilogues []uint // addresses of the return instructions
+	TailCalls []uint // addresses of the tail call jumps
+}
+
+func (f *Func) String() string {
+	return fmt.Sprintf("%s: %x-%x", f.Name, f.Addr, f.End)
+}
+
+// funcName returns the synthesized name for a function.
+func funcName(addr uint) string {
+	return fmt.Sprintf("sub_%x", addr)
+}
+
+//-----------------------------------------------------------------------------
+// instruction patterns
+
+// id returns the instruction identifier ("" if illegal).
+func (da *Disassembly) id() string {
+	if da.im == nil {
+		return ""
+	}
+	return da.im.id
+}
+
+// spAdjust returns the stack pointer adjustment made by an instruction.
+func (da *Disassembly) spAdjust() (int, bool) {
+	switch da.id() {
+	case "addi":
+		imm, rs1, rd := decodeIa(da.Ins)
+		if rd == 2 && rs1 == 2 {
+			return imm, true
+		}
+	case "c.addi":
+		imm, rd := decodeCIa(da.Ins)
+		if rd == 2 {
+			return imm, true
+		}
+	case "c.addi16sp":
+		return decodeCIb(da.Ins), true
+	case "cm.push":
+		return -int(cmStackAdj(da.Ins, da.AddrLength)), true
+	case "cm.pop", "cm.popret", "cm.popretz":
+		return int(cmStackAdj(da.Ins, da.AddrLength)), true
+	}
+	return 0, false
+}
+
+// isStackAlloc returns true if the instruction allocates a stack frame.
+func (da *Disassembly) isStackAlloc() bool {
+	n, ok := da.spAdjust()
+	return ok && n < 0
+}
+
+// isStackFree returns true if the instruction frees a stack frame.
+func (da *Disassembly) isStackFree() bool {
+	n, ok := da.spAdjust()
+	return ok && n > 0
+}
+
+// isSaveRA returns true if the instruction saves ra on the stack.
+func (da *Disassembly) isSaveRA() bool {
+	return da.Mem != nil && da.Mem.Store && da.Mem.Base == regSP && da.Uses.Contains(regRA)
+}
+
+// isNop returns true if the instruction is a nop (as used for padding).
+func (da *Disassembly) isNop() bool {
+	return da.id() == "c.nop" || da.Ins == 0x00000013
+}
+
+// isPrologue returns true if the instruction is a typical first instruction
+// of a function.
+func (da *Disassembly) isPrologue() bool {
+	return da.isStackAlloc() || da.isSaveRA()
+}
+
+//-----------------------------------------------------------------------------
+
+// funcFinder holds the state for finding functions.
+type funcFinder struct {
+	isa   *ISA
+	code  *Code
+	ins   map[uint]*Disassembly // decoded instructions
+	start map[uint]bool         // function start addresses
+}
+
+// decode returns the instruction at an address (or nil).
+func (ff *funcFinder) decode(pc uint) *Disassembly {
+	if da, ok := ff.ins[pc]; ok {
+		return da
+	}
+	var da *Disassembly
+	if x, ok := ff.code.Fetch(pc); ok && ff.isa.legal(x) {
+		da = ff.isa.Disassemble(pc, x)
+	}
+	ff.ins[pc] = da
+	return da
+}
+
+// prologues scans the code for functions starting with a prologue that
+// directly follows the end of some other code.
+func (ff *funcFinder) prologues() {
+	boundary := true
+	for _, l := range Sweep(ff.isa, ff.code).Lines {
+		da := l.Da
+		if da == nil || !ff.isa.legal(da.Ins) {
+			// data or illegal
+			boundary = true
+			continue
+		}
+		if da.isNop() {
+			// padding
+			continue
+		}
+		if boundary && (da.isPrologue() || da.isLandingPad()) {
+			ff.start[l.Addr] = true
+		}
+		switch da.Flow {
+		case FlowJump, FlowReturn, FlowIndirectJump, FlowTrapReturn:
+			boundary = true
+		default:
+			boundary = false
+		}
+	}
+}
+
+// walk follows the control flow within a function.
+func (ff *funcFinder) walk(f *Func) {
+	visited := make(map[uint]bool)
+	work := []uint{f.Addr}
+	for len(work) > 0 {
+		pc := work[len(work)-1]
+		work = work[:len(work)-1]
+		var prev *Disassembly
+		for !visited[pc] {
+			da := ff.decode(pc)
+			if da == nil {
+				break
+			}
+			visited[pc] = true
+			if pc+da.InsLength > f.End {
+				f.End = pc + da.InsLength
+			}
+			end := false
+			switch da.Flow {
+			case FlowBranch:
+				work = append(work, da.Target)
+			case FlowJump:
+				// a jump to a function, or (not backwards) after freeing the stack frame is a tail call
+				back := da.Target >= f.Addr && da.Target <= pc
+				if (ff.start[da.Target] && da.Target != f.Addr) || (prev != nil && prev.isStackFree() && !back) {
+					f.TailCalls = append(f.TailCalls, pc)
+					ff.start[da.Target] = true
+				} else {
+					work = append(work, da.Target)
+				}
+				end = true
+			case FlowCall:
+				f.Calls = append(f.Calls, pc)
+				ff.start[da.Target] = true
+			case FlowIndirectCall:
+				f.Calls = append(f.Calls, pc)
+				if target, ok := ff.isa.auipcTarget(prev, da); ok {
+					ff.start[target] = true
+				}
+			case FlowIndirectJump:
+				if target, ok := ff.isa.auipcTarget(prev, da); ok {
+					// auipc/jr: tail call to a known address
+					f.TailCalls = append(f.TailCalls, pc)
+					ff.start[target] = true
+				} else if prev != nil && prev.isStackFree() {
+					f.TailCalls = append(f.TailCalls, pc)
+				}
+				end = true
+			case FlowReturn:
+				f.Epilogues = append(f.Epilogues, pc)
+				end = true
+			case FlowTrapReturn:
+				end = true
+			}
+			if end {
+				break
+			}
+			prev = da
+			pc += da.InsLength
+		}
+	}
+	sortAddrs(f.Calls)
+	sortAddrs(f.Epilogues)
+	sortAddrs(f.TailCalls)
+}
+
+// sortAddrs sorts a slice of addresses.
+func sortAddrs(x []uint) {
+	sort.Slice(x, func(i, j int) bool { return x[i] < x[j] })
+}
+
+// funcAddrs returns the sorted addresses of a set of functions.
+func funcAddrs(funcs map[uint]*Func) []uint {
+	addrs := make([]uint, 0, len(funcs))
+	for pc := range funcs {
+		addrs = append(addrs, pc)
+	}
+	sortAddrs(addrs)
+	return addrs
+}
+
+// discover walks the function starts (in address order) that are not yet
+// functions until no new function starts are found.
+func (ff *funcFinder) discover(funcs map[uint]*Func) {
+	for {
+		var work []uint
+		for pc := range ff.start {
+			if funcs[pc] == nil && ff.code.Contains(pc, 2) {
+				work = append(work, pc)
+			}
+		}
+		if len(work) == 0 {
+			return
+		}
+		sortAddrs(work)
+		for _, pc := range work {
+			f := &Func{Addr: pc, End: pc}
+			f.Prologue = ff.decode(pc) != nil && ff.decode(pc).isPrologue()
+			funcs[pc] = f
+			ff.walk(f)
+		}
+	}
+}
+
+// FindFuncs finds the functions in a code region. The entry addresses are
+// known function starts (e.g. from the ELF entry and symbols). Functions
+// without a symbol are given a synthesized name (sub_<addr>) which is also
+// added to the symbol table.
+func FindFuncs(isa *ISA, code *Code, entry []uint, syms *Symbols) []*Func {
+	ff := &funcFinder{
+		isa:   isa,
+		code:  code,
+		ins:   make(map[uint]*Disassembly),
+		start: make(map[uint]bool),
+	}
+	for _, pc := range entry {
+		ff.start[pc] = true
+	}
+	ff.prologues()
+
+	// walk the functions, then rewalk them all now that more starts are
+	// known (tail call detection) until no new function starts are found
+	funcs := make(map[uint]*Func)
+	ff.discover(funcs)
+	var list []*Func
+	for {
+		n := len(ff.start)
+		list = list[:0]
+		for _, pc := range funcAddrs(funcs) {
+			f := funcs[pc]
+			if ff.decode(pc) == nil {
+				continue
+			}
+			*f = Func{Addr: f.Addr, End: f.Addr, Prologue: f.Prologue}
+			ff.walk(f)
+			list = append(list, f)
+		}
+		if len(ff.start) == n {
+			break
+		}
+		ff.discover(funcs)
+	}
+
+	// name the functions
+	for _, f := range list {
+		if name, ok := syms.Name(f.Addr); ok {
+			f.Name = name
+		} else {
+			f.Name = funcName(f.Addr)
+			if syms != nil {
+				syms.Add(f.Addr, f.Name)
+			}
+		}
+	}
+	return list
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,87 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Function Detection Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+// funcTestCode is a small program with functions found by call, prologue
+// and tail call.
+var funcTestCode = []uint{
+	0x2019,     // 00: jal ra,6
+	0x2829,     // 02: jal ra,1c
+	0xa001,     // 04: j 4
+	0x1141,     // 06: addi sp,sp,-16
+	0xc606,     // 08: sw ra,12(sp)
+	0x2031,     // 0a: jal ra,16
+	0x40b2,     // 0c: lw ra,12(sp)
+	0x0141,     // 0e: addi sp,sp,16
+	0x8082,     // 10: ret
+	0x0000,     // 12: data
+	0x0000,     // 14: data
+	0x1101,     // 16: addi sp,sp,-32
+	0x6105,     // 18: addi sp,sp,32
+	0xa009,     // 1a: j 1c (tail call)
+	0x4505,     // 1c: li a0,1
+	0x8082,     // 1e: ret
+	0xffffffff, // 20: data
+	0x1141,     // 24: addi sp,sp,-16 (not called)
+	0x0141,     // 26: addi sp,sp,16
+	0x8082,     // 28: ret
+}
+
+func Test_FindFuncs(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syms := NewSymbols()
+	syms.Add(0, "_start")
+	funcs := FindFuncs(isa, testCode(0, funcTestCode...), []uint{0}, syms)
+
+	s := []string{}
+	for _, f := range funcs {
+		s = append(s, fmt.Sprintf("%s calls %x epilogues %x tail %x", f, f.Calls, f.Epilogues, f.TailCalls))
+	}
+	expected := []string{
+		"_start: 0-6 calls [0 2] epilogues [] tail []",
+		"sub_6: 6-12 calls [a] epilogues [10] tail []",
+		"sub_16: 16-1c calls [] epilogues [] tail [1a]",
+		"sub_1c: 1c-20 calls [] epilogues [1e] tail []",
+		"sub_24: 24-2a calls [] epilogues [28] tail []",
+	}
+	if strings.Join(s, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("functions\n%s\n(expected)\n%s", strings.Join(s, "\n"), strings.Join(expected, "\n"))
+	}
+	if name, _ := syms.Name(0x16); name != "sub_16" {
+		t.Errorf("synthesized name not added to symbols")
+	}
+	if syms.Symbolize(0x1e) != "sub_1c+0x2" {
+		t.Errorf("bad symbolize %s", syms.Symbolize(0x1e))
+	}
+	// the result doesn't depend on map iteration order
+	for i := 0; i < 20; i++ {
+		syms := NewSymbols()
+		syms.Add(0, "_start")
+		x := []string{}
+		for _, f := range FindFuncs(isa, testCode(0, funcTestCode...), []uint{0}, syms) {
+			x = append(x, fmt.Sprintf("%s calls %x epilogues %x tail %x", f, f.Calls, f.Epilogues, f.TailCalls))
+		}
+		if strings.Join(x, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("run %d: functions\n%s", i, strings.Join(x, "\n"))
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -85,6 +85,7 @@ var knownFields = map[string]int{
 	"imm[4:0]":                   5,
 	"shamt5":                     5,
 	"shamt6":                     6,
+	"fm":                         4,
 	"pred":                       4,
 	"succ":                       4,
 	"csr":                        12,
@@ -126,6 +127,50 @@ var knownFields = map[string]int{
 	"nzuimm[5:4|9:6|2|3]":        8,
 	"nzuimm[5]":                  1,
 	"nzuimm[4:0]":                5,
+	"uimm[0|1]":                  2,
+	"uimm[1]":                    1,
+	"r1s":                        3,
+	"r2s":                        3,
+	"rlist":                      4,
+	"spimm[5:4]":                 2,
+	"index":                      8,
+	"index[4:0]":                 5,
+	"bs":                         2,
+	"rnum":                       4,
+	"uimm[4:0]":                  5,
+	"vm":                         1,
+	"vd":                         5,
+	"vs1":                        5,
+	"vs2":                        5,
+	"n[4]":                       1,
+	"n[3:2]":                     2,
+	"n[3:1]":                     3,
+	"n[2]":                       1,
+	"n[1:0]":                     2,
+	"imm2":                       2,
+	"imm5":                       5,
+	"msb":                        6,
+	"lsb":                        6,
+	"rd2":                        5,
+	"fd":                         5,
+	"fs3":                        5,
+	"l":                          1,
+	"uimml":                      12,
+	"uimms":                      5,
+	"is3":                        5,
+	"is3[1:0]":                   2,
+	"is2":                        5,
+	"fmt[2]":                     1,
+	"fmt[0]":                     1,
+	"imm6[0]":                    1,
+	"imm6[5:1]":                  5,
+	"op[1:0]":                    2,
+	"op[0]":                      1,
+	"uimm5":                      5,
+	"uimmd":                      5,
+	"simm5":                      5,
+	"fs1":                        5,
+	"vs3":                        5,
 }
 
 // isField returns the length of an instruction field.
@@ -157,56 +202,135 @@ const (
 	decodeTypeCS          // Compressed Store
 	decodeTypeCB          // Compressed Branch
 	decodeTypeCJ          // Compressed Jump
+	decodeTypeCLB         // Compressed Load Byte
+	decodeTypeCLH         // Compressed Load Halfword
+	decodeTypeCSB         // Compressed Store Byte
+	decodeTypeCSH         // Compressed Store Halfword
+	decodeTypeCU          // Compressed Unary
+	decodeTypeCMMV        // Compressed Move Pair
+	decodeTypeCMPP        // Compressed Push/Pop
+	decodeTypeCMJT        // Compressed Table Jump
+	decodeTypeV           // Vector
 )
 
 var knownDecodes = map[string]decodeType{
-	"imm[31:12]_rd_7b":                        decodeTypeU,
-	"imm[20|10:1|11|19:12]_rd_7b":             decodeTypeJ, // aka UJ
-	"imm[11:5]_rs2_rs1_3b_imm[4:0]_7b":        decodeTypeS,
-	"imm[12|10:5]_rs2_rs1_3b_imm[4:1|11]_7b":  decodeTypeB, // aka SB
-	"7b_shamt5_rs1_3b_rd_7b":                  decodeTypeI,
-	"6b_shamt6_rs1_3b_rd_7b":                  decodeTypeI,
-	"imm[11:0]_rs1_3b_rd_7b":                  decodeTypeI,
-	"csr_rs1_3b_rd_7b":                        decodeTypeI,
-	"csr_zimm_3b_rd_7b":                       decodeTypeI,
-	"4b_pred_succ_5b_3b_5b_7b":                decodeTypeI,
-	"7b_5b_5b_3b_5b_7b":                       decodeTypeI,
-	"7b_rs2_rs1_3b_5b_7b":                     decodeTypeI,
-	"4b_4b_4b_5b_3b_5b_7b":                    decodeTypeI,
-	"7b_rs2_rs1_3b_rd_7b":                     decodeTypeR,
-	"7b_rs2_rs1_rm_rd_7b":                     decodeTypeR,
-	"7b_5b_rs1_rm_rd_7b":                      decodeTypeR,
-	"5b_aq_rl_5b_rs1_3b_rd_7b":                decodeTypeR,
-	"5b_aq_rl_rs2_rs1_3b_rd_7b":               decodeTypeR,
-	"7b_5b_rs1_3b_rd_7b":                      decodeTypeR,
-	"rs3_2b_rs2_rs1_rm_rd_7b":                 decodeTypeR4,
-	"3b_nzuimm[5:4|9:6|2|3]_rd0_2b":           decodeTypeCIW,
-	"3b_8b_3b_2b":                             decodeTypeCIW,
-	"3b_uimm[5:3]_rs10_uimm[7:6]_rd0_2b":      decodeTypeCL,
-	"3b_uimm[5:3]_rs10_uimm[2|6]_rd0_2b":      decodeTypeCL,
-	"3b_uimm[5:3]_rs10_uimm[7:6]_rs20_2b":     decodeTypeCS,
-	"3b_uimm[5:3]_rs10_uimm[2|6]_rs20_2b":     decodeTypeCS,
-	"3b_nzimm[5]_5b_nzimm[4:0]_2b":            decodeTypeCI,
-	"3b_nzimm[5]_rs1/rd!=0_nzimm[4:0]_2b":     decodeTypeCI,
-	"3b_imm[11|4|9:8|10|6|7|3:1|5]_2b":        decodeTypeCJ,
-	"3b_imm[5]_rd!=0_imm[4:0]_2b":             decodeTypeCI,
-	"3b_nzimm[9]_5b_nzimm[4|6|8:7|5]_2b":      decodeTypeCI,
-	"3b_nzimm[17]_rd!={0,2}_nzimm[16:12]_2b":  decodeTypeCI,
-	"3b_nzuimm[5]_2b_rs10/rd0_nzuimm[4:0]_2b": decodeTypeCI,
-	"3b_imm[5]_2b_rs10/rd0_imm[4:0]_2b":       decodeTypeCI,
-	"3b_1b_2b_rs10/rd0_2b_rs20_2b":            decodeTypeCR,
-	"3b_imm[8|4:3]_rs10_imm[7:6|2:1|5]_2b":    decodeTypeCB,
-	"3b_nzuimm[5]_rs1/rd!=0_nzuimm[4:0]_2b":   decodeTypeCI,
-	"3b_1b_rs1/rd!=0_5b_2b":                   decodeTypeCI,
-	"3b_uimm[5]_rd_uimm[4:3|8:6]_2b":          decodeTypeCSS,
-	"3b_uimm[5]_rd!=0_uimm[4:2|7:6]_2b":       decodeTypeCSS,
-	"3b_uimm[5]_rd_uimm[4:2|7:6]_2b":          decodeTypeCSS,
-	"3b_1b_rs1!=0_5b_2b":                      decodeTypeCR,
-	"3b_1b_rd!=0_rs2!=0_2b":                   decodeTypeCR,
-	"3b_1b_5b_5b_2b":                          decodeTypeCI,
-	"3b_1b_rs1/rd!=0_rs2!=0_2b":               decodeTypeCR,
-	"3b_uimm[5:3|8:6]_rs2_2b":                 decodeTypeCSS,
-	"3b_uimm[5:2|7:6]_rs2_2b":                 decodeTypeCSS,
+	"imm[31:12]_rd_7b":                            decodeTypeU,
+	"imm[20|10:1|11|19:12]_rd_7b":                 decodeTypeJ, // aka UJ
+	"imm[11:5]_rs2_rs1_3b_imm[4:0]_7b":            decodeTypeS,
+	"imm[12|10:5]_rs2_rs1_3b_imm[4:1|11]_7b":      decodeTypeB, // aka SB
+	"7b_shamt5_rs1_3b_rd_7b":                      decodeTypeI,
+	"6b_shamt6_rs1_3b_rd_7b":                      decodeTypeI,
+	"imm[11:0]_rs1_3b_rd_7b":                      decodeTypeI,
+	"csr_rs1_3b_rd_7b":                            decodeTypeI,
+	"csr_zimm_3b_rd_7b":                           decodeTypeI,
+	"4b_pred_succ_5b_3b_5b_7b":                    decodeTypeI,
+	"fm_pred_succ_rs1_3b_rd_7b":                   decodeTypeI,
+	"7b_5b_5b_3b_5b_7b":                           decodeTypeI,
+	"7b_rs2_rs1_3b_5b_7b":                         decodeTypeI,
+	"4b_4b_4b_5b_3b_5b_7b":                        decodeTypeI,
+	"7b_rs2_rs1_3b_rd_7b":                         decodeTypeR,
+	"7b_rs2_rs1_rm_rd_7b":                         decodeTypeR,
+	"7b_5b_rs1_rm_rd_7b":                          decodeTypeR,
+	"5b_aq_rl_5b_rs1_3b_rd_7b":                    decodeTypeR,
+	"5b_aq_rl_rs2_rs1_3b_rd_7b":                   decodeTypeR,
+	"7b_5b_rs1_3b_rd_7b":                          decodeTypeR,
+	"rs3_2b_rs2_rs1_rm_rd_7b":                     decodeTypeR4,
+	"3b_nzuimm[5:4|9:6|2|3]_rd0_2b":               decodeTypeCIW,
+	"3b_8b_3b_2b":                                 decodeTypeCIW,
+	"3b_uimm[5:3]_rs10_uimm[7:6]_rd0_2b":          decodeTypeCL,
+	"3b_uimm[5:3]_rs10_uimm[2|6]_rd0_2b":          decodeTypeCL,
+	"3b_uimm[5:3]_rs10_uimm[7:6]_rs20_2b":         decodeTypeCS,
+	"3b_uimm[5:3]_rs10_uimm[2|6]_rs20_2b":         decodeTypeCS,
+	"3b_nzimm[5]_5b_nzimm[4:0]_2b":                decodeTypeCI,
+	"3b_nzimm[5]_rs1/rd!=0_nzimm[4:0]_2b":         decodeTypeCI,
+	"3b_imm[11|4|9:8|10|6|7|3:1|5]_2b":            decodeTypeCJ,
+	"3b_imm[5]_rd!=0_imm[4:0]_2b":                 decodeTypeCI,
+	"3b_nzimm[9]_5b_nzimm[4|6|8:7|5]_2b":          decodeTypeCI,
+	"3b_nzimm[17]_rd!={0,2}_nzimm[16:12]_2b":      decodeTypeCI,
+	"3b_nzuimm[5]_2b_rs10/rd0_nzuimm[4:0]_2b":     decodeTypeCI,
+	"3b_imm[5]_2b_rs10/rd0_imm[4:0]_2b":           decodeTypeCI,
+	"3b_1b_2b_rs10/rd0_2b_rs20_2b":                decodeTypeCR,
+	"3b_imm[8|4:3]_rs10_imm[7:6|2:1|5]_2b":        decodeTypeCB,
+	"3b_nzuimm[5]_rs1/rd!=0_nzuimm[4:0]_2b":       decodeTypeCI,
+	"3b_1b_rs1/rd!=0_5b_2b":                       decodeTypeCI,
+	"3b_uimm[5]_rd_uimm[4:3|8:6]_2b":              decodeTypeCSS,
+	"3b_uimm[5]_rd!=0_uimm[4:2|7:6]_2b":           decodeTypeCSS,
+	"3b_uimm[5]_rd_uimm[4:2|7:6]_2b":              decodeTypeCSS,
+	"3b_1b_rs1!=0_5b_2b":                          decodeTypeCR,
+	"3b_1b_rd!=0_rs2!=0_2b":                       decodeTypeCR,
+	"3b_1b_5b_5b_2b":                              decodeTypeCI,
+	"3b_1b_rs1/rd!=0_rs2!=0_2b":                   decodeTypeCR,
+	"3b_uimm[5:3|8:6]_rs2_2b":                     decodeTypeCSS,
+	"3b_uimm[5:2|7:6]_rs2_2b":                     decodeTypeCSS,
+	"3b_3b_rs10_uimm[0|1]_rd0_2b":                 decodeTypeCLB,
+	"3b_3b_rs10_1b_uimm[1]_rd0_2b":                decodeTypeCLH,
+	"3b_3b_rs10_uimm[0|1]_rs20_2b":                decodeTypeCSB,
+	"3b_3b_rs10_1b_uimm[1]_rs20_2b":               decodeTypeCSH,
+	"3b_1b_2b_rs10/rd0_2b_3b_2b":                  decodeTypeCU,
+	"3b_3b_r1s_2b_r2s_2b":                         decodeTypeCMMV,
+	"3b_5b_rlist_spimm[5:4]_2b":                   decodeTypeCMPP,
+	"3b_3b_3b_index[4:0]_2b":                      decodeTypeCMJT,
+	"3b_3b_index_2b":                              decodeTypeCMJT,
+	"bs_5b_rs2_rs1_3b_rd_7b":                      decodeTypeR,
+	"8b_rnum_rs1_3b_rd_7b":                        decodeTypeI,
+	"6b_vm_vs2_vs1_3b_vd_7b":                      decodeTypeV,
+	"6b_vm_vs2_rs1_3b_vd_7b":                      decodeTypeV,
+	"6b_vm_vs2_uimm[4:0]_3b_vd_7b":                decodeTypeV,
+	"5b_uimm[5]_vm_vs2_uimm[4:0]_3b_vd_7b":        decodeTypeV,
+	"6b_vm_vs2_5b_3b_vd_7b":                       decodeTypeV,
+	"6b_1b_vs2_vs1_3b_vd_7b":                      decodeTypeV,
+	"6b_1b_vs2_5b_3b_vd_7b":                       decodeTypeV,
+	"6b_1b_vs2_uimm[4:0]_3b_vd_7b":                decodeTypeV,
+	"12b_rs1_3b_5b_7b":                            decodeTypeI,
+	"imm[11:5]_5b_rs1_3b_5b_7b":                   decodeTypeS,
+	"1b_n[4]_2b_n[3:2]_4b_n[1:0]_rs1_3b_rd_7b":    decodeTypeI,
+	"1b_n[2]_2b_n[1:0]_1b_rs2_rs1_3b_rd_7b":       decodeTypeR,
+	"5b_n[3:1]_6b_2b":                             decodeTypeCI,
+	"imm[31:12]_5b_7b":                            decodeTypeU,
+	"7b_5b_5b_3b_rd_7b":                           decodeTypeI,
+	"5b_imm2_rs2_rs1_3b_rd_7b":                    decodeTypeR,
+	"5b_imm2_rs2_rs1_3b_rs3_7b":                   decodeTypeR,
+	"5b_imm2_rd2_rs1_3b_rd_7b":                    decodeTypeR,
+	"5b_imm2_rs2_rs1_3b_fd_7b":                    decodeTypeR,
+	"5b_imm2_rs2_rs1_3b_fs3_7b":                   decodeTypeR,
+	"5b_imm2_imm5_rs1/rd!=0_3b_rd_7b":             decodeTypeI,
+	"5b_imm2_imm5_rs1/rd!=0_3b_rs3_7b":            decodeTypeS,
+	"msb_lsb_rs1_3b_rd_7b":                        decodeTypeI,
+	"7b_5b_rs1_3b_5b_7b":                          decodeTypeI,
+	"uimml_5b_3b_4b_l_7b":                         decodeTypeI,
+	"12b_rs1_3b_4b_l_7b":                          decodeTypeI,
+	"uimml_uimms_3b_4b_l_7b":                      decodeTypeI,
+	"uimml_rs1_3b_4b_l_7b":                        decodeTypeI,
+	"imm[11:0]_rs1/rd!=0_3b_rd_7b":                decodeTypeI,
+	"7b_rs2_rs1/rd!=0_3b_rd_7b":                   decodeTypeR,
+	"imm[11:5]_rs2_rs1/rd!=0_3b_imm[4:0]_7b":      decodeTypeS,
+	"7b_rs2_rs1/rd!=0_3b_rs3_7b":                  decodeTypeR,
+	"7b_rs2_rs1_3b_rs3_7b":                        decodeTypeR,
+	"imm[12|10:5]_imm5_rs1_3b_imm[4:1|11]_7b":     decodeTypeB,
+	"2b_is3_is2_rs1_3b_rd_7b":                     decodeTypeI,
+	"2b_3b_is3[1:0]_is2_rs1_3b_rd_7b":             decodeTypeI,
+	"7b_is2_rs1_3b_rd_7b":                         decodeTypeI,
+	"2b_is3_rs2_rs1_3b_rd_7b":                     decodeTypeR,
+	"5b_1b_1b_rs2_rs1_fmt[2]_1b_fmt[0]_rd_7b":     decodeTypeR,
+	"5b_1b_imm6[0]_imm6[5:1]_rs1_2b_fmt[0]_rd_7b": decodeTypeI,
+	"5b_1b_1b_5b_rs1_3b_rd_7b":                    decodeTypeR,
+	"4b_op[1:0]_1b_uimm5_rs1_3b_uimmd_7b":         decodeTypeV,
+	"4b_op[1:0]_1b_uimm5_simm5_3b_uimmd_7b":       decodeTypeV,
+	"4b_op[1:0]_1b_vs2_rs1_3b_uimmd_7b":           decodeTypeV,
+	"4b_op[1:0]_1b_vs2_simm5_3b_uimmd_7b":         decodeTypeV,
+	"4b_op[1:0]_1b_vs2_vs1_3b_uimmd_7b":           decodeTypeV,
+	"4b_1b_op[0]_1b_vs2_fs1_3b_uimmd_7b":          decodeTypeV,
+	"4b_op[1:0]_1b_vs2_rs1_3b_vs3_7b":             decodeTypeV,
+	"4b_op[1:0]_1b_vs2_simm5_3b_vs3_7b":           decodeTypeV,
+	"4b_op[1:0]_1b_vs2_vs1_3b_vs3_7b":             decodeTypeV,
+	"4b_1b_op[0]_1b_vs2_fs1_3b_vs3_7b":            decodeTypeV,
+	"4b_op[1:0]_1b_uimm5_rs1_3b_vd_7b":            decodeTypeV,
+	"4b_op[1:0]_1b_uimm5_simm5_3b_vd_7b":          decodeTypeV,
+	"4b_op[1:0]_1b_vs2_rs1_3b_vd_7b":              decodeTypeV,
+	"4b_op[1:0]_1b_vs2_simm5_3b_vd_7b":            decodeTypeV,
+	"4b_op[1:0]_1b_vs2_vs1_3b_vd_7b":              decodeTypeV,
+	"4b_1b_op[0]_1b_vs2_fs1_3b_vd_7b":             decodeTypeV,
+	"6b_vm_vs2_fs1_3b_vd_7b":                      decodeTypeV,
 }
 
 // getDecode returns the decode type for the instruction.
@@ -226,8 +350,13 @@ func nameRemap(name string) string {
 		"c.swsp":     "sw",
 		"c.ldsp":     "ld",
 		"c.sdsp":     "sd",
+		"c.flwsp":    "flw",
+		"c.fswsp":    "fsw",
+		"c.fldsp":    "fld",
+		"c.fsdsp":    "fsd",
 		"c.addi16sp": "addi",
 		"c.addi4spn": "addi",
+		"c.mop":      "c.mop",
 	}
 	if s, ok := x[name]; ok {
 		return s
@@ -267,8 +396,33 @@ func csrRemap2(name string) string {
 
 //-----------------------------------------------------------------------------
 
+// fieldPos is the bit position of a named field within an instruction.
+type fieldPos struct {
+	name     string // field name
+	msb, lsb uint   // bit range
+}
+
+// field returns the value of a named field of an instruction.
+func (im *insMeta) field(ins uint, name string) (uint, bool) {
+	for _, f := range im.fields {
+		if f.name == name {
+			return bitUnsigned(ins, f.msb, f.lsb, 0), true
+		}
+	}
+	return 0, false
+}
+
+//-----------------------------------------------------------------------------
+
 // parseDefn parses an instruction definition string and returns the meta-data.
 func parseDefn(id *insDefn, ilen int) (*insMeta, error) {
+	return parseDefnFields(id, ilen, nil)
+}
+
+// parseDefnFields parses an instruction definition. Fields that are not
+// known are looked up in extra (the fields declared for user defined
+// instructions). User defined instructions don't need a known decode type.
+func parseDefnFields(id *insDefn, ilen int, extra map[string]int) (*insMeta, error) {
 
 	im := insMeta{
 		defn: id,
@@ -282,23 +436,33 @@ func parseDefn(id *insDefn, ilen int) (*insMeta, error) {
 	}
 
 	// mneumonic
-	im.name = nameRemap(strings.ToLower(parts[n-1]))
+	im.id = strings.ToLower(parts[n-1])
+	im.name = nameRemap(im.id)
 
 	// remove the mneumonic from the end
 	parts = parts[0 : n-1]
 
 	s0 := make([]string, 0) // bit pattern
 	s1 := make([]string, 0) // decode signature
+	pos := ilen             // current bit position (msb + 1)
 
 	for _, x := range parts {
 		if isBits(x) {
 			s0 = append(s0, fmt.Sprintf("%s", x))
 			s1 = append(s1, fmt.Sprintf("%db", len(x)))
+			pos -= len(x)
 		} else {
 			n, err := isField(x)
+			if w, ok := extra[x]; ok && err != nil {
+				n, err = w, nil
+			}
 			if err == nil {
 				s0 = append(s0, dontCare(n))
 				s1 = append(s1, x)
+				if pos-n >= 0 {
+					im.fields = append(im.fields, fieldPos{x, uint(pos - 1), uint(pos - n)})
+				}
+				pos -= n
 			} else {
 				return nil, err
 			}
@@ -314,7 +478,7 @@ func parseDefn(id *insDefn, ilen int) (*insMeta, error) {
 
 	// set the decode type
 	dt, err := getDecode(strings.Join(s1, "_"))
-	if err != nil {
+	if err != nil && extra == nil {
 		return nil, err
 	}
 	im.dt = dt
@@ -11,6 +11,8 @@ package rvda
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 //-----------------------------------------------------------------------------
@@ -76,7 +78,7 @@ var isaRV32i = isaModule{
 		{"0100000 rs2 rs1 101 rd 0110011 SRA", daTypeRa},                // R
 		{"0000000 rs2 rs1 110 rd 0110011 OR", daTypeRa},                 // R
 		{"0000000 rs2 rs1 111 rd 0110011 AND", daTypeRa},                // R
-		{"0000 pred succ 00000 000 00000 0001111 FENCE", daTypeIi},      // I
+		{"fm pred succ rs1 000 rd 0001111 FENCE", daTypeIl},             // I
 		{"0000 0000 0000 00000 001 00000 0001111 FENCE.I", daTypeIi},    // I
 		{"0000000 00000 00000 000 00000 1110011 ECALL", daTypeIi},       // I
 		{"0000000 00001 00000 000 00000 1110011 EBREAK", daTypeIi},      // I
@@ -224,11 +226,10 @@ var isaRV32c = isaModule{
 		{"110 imm[8|4:3] rs10 imm[7:6|2:1|5] 01 C.BEQZ", daTypeCBa},      // CB
 		{"111 imm[8|4:3] rs10 imm[7:6|2:1|5] 01 C.BNEZ", daTypeCBa},      // CB
 		{"000 nzuimm[5] rs1/rd!=0 nzuimm[4:0] 10 C.SLLI", daTypeCIe},     // CI (Quadrant 2)
-		{"000 0 rs1/rd!=0 00000 10 C.SLLI64", daNone},                    // CI
 		{"010 uimm[5] rd!=0 uimm[4:2|7:6] 10 C.LWSP", daTypeCSSa},        // CSS
 		{"100 0 rs1!=0 00000 10 C.JR", daTypeCRd},                        // CR
 		{"100 0 rd!=0 rs2!=0 10 C.MV", daTypeCRa},                        // CR
-		{"100 1 00000 00000 10 C.EBREAK", daNone},                        // CI
+		{"100 1 00000 00000 10 C.EBREAK", daTypeIi},                      // CI
 		{"100 1 rs1!=0 00000 10 C.JALR", daTypeCRe},                      // CR
 		{"100 1 rs1/rd!=0 rs2!=0 10 C.ADD", daTypeCRb},                   // CR
 		{"110 uimm[5:2|7:6] rs2 10 C.SWSP", daTypeCSSb},                  // CSS
@@ -250,9 +251,9 @@ var isaRV32fc = isaModule{
 	ilen: 16,
 	defn: []insDefn{
 		{"011 uimm[5:3] rs10 uimm[2|6] rd0 00 C.FLW", daTypeCSc},  // CL
-		{"011 uimm[5] rd uimm[4:2|7:6] 10 C.FLWSP", daNone},       // CSS
+		{"011 uimm[5] rd uimm[4:2|7:6] 10 C.FLWSP", daTypeCSSd},   // CSS
 		{"111 uimm[5:3] rs10 uimm[2|6] rs20 00 C.FSW", daTypeCSc}, // CS
-		{"111 uimm[5:2|7:6] rs2 10 C.FSWSP", daNone},              // CSS
+		{"111 uimm[5:2|7:6] rs2 10 C.FSWSP", daTypeCSSe},          // CSS
 	},
 }
 
@@ -262,9 +263,873 @@ var isaRV32dc = isaModule{
 	ilen: 16,
 	defn: []insDefn{
 		{"001 uimm[5:3] rs10 uimm[7:6] rd0 00 C.FLD", daTypeCSc},  // CL
-		{"001 uimm[5] rd uimm[4:3|8:6] 10 C.FLDSP", daNone},       // CSS
+		{"001 uimm[5] rd uimm[4:3|8:6] 10 C.FLDSP", daTypeCIi},    // CSS
 		{"101 uimm[5:3] rs10 uimm[7:6] rs20 00 C.FSD", daTypeCSc}, // CS
-		{"101 uimm[5:3|8:6] rs2 10 C.FSDSP", daNone},              // CSS
+		{"101 uimm[5:3|8:6] rs2 10 C.FSDSP", daTypeCSSf},          // CSS
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Zc* code size reduction instructions (RV32/64)
+
+// isaZcb simple compressed instructions.
+var isaZcb = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"100 000 rs10 uimm[0|1] rd0 00 C.LBU", daTypeCSd},  // CLB
+		{"100 001 rs10 0 uimm[1] rd0 00 C.LHU", daTypeCSe},  // CLH
+		{"100 001 rs10 1 uimm[1] rd0 00 C.LH", daTypeCSe},   // CLH
+		{"100 010 rs10 uimm[0|1] rs20 00 C.SB", daTypeCSd},  // CSB
+		{"100 011 rs10 0 uimm[1] rs20 00 C.SH", daTypeCSe},  // CSH
+		{"100 1 11 rs10/rd0 11 000 01 C.ZEXT.B", daTypeCUa}, // CU
+		{"100 1 11 rs10/rd0 11 101 01 C.NOT", daTypeCUa},    // CU
+	},
+}
+
+// isaZcbM compressed multiply (requires M).
+var isaZcbM = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"100 1 11 rs10/rd0 10 rs20 01 C.MUL", daTypeCRc}, // CA
+	},
+}
+
+// isaZcbZbb compressed bit manipulation (requires Zbb).
+var isaZcbZbb = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"100 1 11 rs10/rd0 11 001 01 C.SEXT.B", daTypeCUa}, // CU
+		{"100 1 11 rs10/rd0 11 010 01 C.ZEXT.H", daTypeCUa}, // CU
+		{"100 1 11 rs10/rd0 11 011 01 C.SEXT.H", daTypeCUa}, // CU
+	},
+}
+
+// isaZcmp compressed register moves.
+var isaZcmp = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"101 011 r1s 01 r2s 10 CM.MVSA01", daTypeCMMV}, // CMMV
+		{"101 011 r1s 11 r2s 10 CM.MVA01S", daTypeCMMV}, // CMMV
+	},
+}
+
+// isaZcmp32 compressed push/pop (RV32 stack adjustment).
+var isaZcmp32 = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"101 11000 rlist spimm[5:4] 10 CM.PUSH", daTypeCMPPa},    // CMPP
+		{"101 11010 rlist spimm[5:4] 10 CM.POP", daTypeCMPPa},     // CMPP
+		{"101 11100 rlist spimm[5:4] 10 CM.POPRETZ", daTypeCMPPa}, // CMPP
+		{"101 11110 rlist spimm[5:4] 10 CM.POPRET", daTypeCMPPa},  // CMPP
+	},
+}
+
+// isaZcmt compressed table jumps.
+var isaZcmt = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"101 000 000 index[4:0] 10 CM.JT", daTypeCMJT}, // CMJT
+		{"101 000 index 10 CM.JALT", daTypeCMJT},        // CMJT
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Cache management, conditional and hint instructions (RV32/64)
+
+// isaZicbom cache block management.
+var isaZicbom = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"000000000000 rs1 010 00000 0001111 CBO.INVAL", daTypeIn}, // I
+		{"000000000001 rs1 010 00000 0001111 CBO.CLEAN", daTypeIn}, // I
+		{"000000000010 rs1 010 00000 0001111 CBO.FLUSH", daTypeIn}, // I
+	},
+}
+
+// isaZicboz cache block zero.
+var isaZicboz = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"000000000100 rs1 010 00000 0001111 CBO.ZERO", daTypeIn}, // I
+	},
+}
+
+// isaZicbop cache block prefetch (overlays ori with rd=x0).
+var isaZicbop = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"imm[11:5] 00000 rs1 110 00000 0010011 PREFETCH.I", daTypeIo}, // I
+		{"imm[11:5] 00001 rs1 110 00000 0010011 PREFETCH.R", daTypeIo}, // I
+		{"imm[11:5] 00011 rs1 110 00000 0010011 PREFETCH.W", daTypeIo}, // I
+	},
+}
+
+// isaZicond integer conditional operations.
+var isaZicond = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0000111 rs2 rs1 101 rd 0110011 CZERO.EQZ", daTypeRa}, // R
+		{"0000111 rs2 rs1 111 rd 0110011 CZERO.NEZ", daTypeRa}, // R
+	},
+}
+
+// isaZihintntl non-temporal locality hints (overlays add x0,x0,rs2).
+var isaZihintntl = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0000000 00010 00000 000 00000 0110011 NTL.P1", daTypeIi},   // R
+		{"0000000 00011 00000 000 00000 0110011 NTL.PALL", daTypeIi}, // R
+		{"0000000 00100 00000 000 00000 0110011 NTL.S1", daTypeIi},   // R
+		{"0000000 00101 00000 000 00000 0110011 NTL.ALL", daTypeIi},  // R
+	},
+}
+
+// isaZihintntlC compressed non-temporal locality hints (overlays c.add x0,rs2).
+var isaZihintntlC = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"100 1 00000 00010 10 C.NTL.P1", daTypeIi},   // CR
+		{"100 1 00000 00011 10 C.NTL.PALL", daTypeIi}, // CR
+		{"100 1 00000 00100 10 C.NTL.S1", daTypeIi},   // CR
+		{"100 1 00000 00101 10 C.NTL.ALL", daTypeIi},  // CR
+	},
+}
+
+// isaZihintpause pause hint (overlays fence w,0).
+var isaZihintpause = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0000 0001 0000 00000 000 00000 0001111 PAUSE", daTypeIi}, // I
+	},
+}
+
+// isaZimop may-be-operations.
+var isaZimop = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"1 n[4] 00 n[3:2] 0111 n[1:0] rs1 100 rd 1110011 MOP.R", daTypeIp}, // I
+		{"1 n[2] 00 n[1:0] 1 rs2 rs1 100 rd 1110011 MOP.RR", daTypeRn},      // R
+	},
+}
+
+// isaZcmop compressed may-be-operations (overlays c.lui with nzimm=0).
+var isaZcmop = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"01100 n[3:1] 100000 01 C.MOP", daTypeCMOP}, // CI
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Control-flow integrity instructions (RV32/64)
+
+// isaZicfilp landing pads (overlays auipc with rd=x0).
+var isaZicfilp = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"imm[31:12] 00000 0010111 LPAD", daTypeUb}, // U
+	},
+}
+
+// isaZicfiss shadow stacks (sspush/sspopchk/ssrdp overlay mop.rr.7/mop.r.28).
+var isaZicfiss = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"1100111 00001 00000 100 00000 1110011 SSPUSH", daTypeRo},   // R
+		{"1100111 00101 00000 100 00000 1110011 SSPUSH", daTypeRo},   // R
+		{"1100110 11100 00001 100 00000 1110011 SSPOPCHK", daTypeIq}, // I
+		{"1100110 11100 00101 100 00000 1110011 SSPOPCHK", daTypeIq}, // I
+		{"1100110 11100 00000 100 rd 1110011 SSRDP", daTypeIr},       // I
+		{"01001 aq rl rs2 rs1 010 rd 0101111 SSAMOSWAP.W", daTypeRb}, // R
+	},
+}
+
+// isaZicfissC compressed shadow stacks (overlays c.mop.1/c.mop.5).
+var isaZicfissC = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"011 0 00001 00000 01 C.SSPUSH", daTypeCMOPa},   // CI
+		{"011 0 00101 00000 01 C.SSPOPCHK", daTypeCMOPa}, // CI
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Scalar cryptography instructions (RV32/64)
+
+// isaZbkb bit manipulation for cryptography.
+var isaZbkb = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0100000 rs2 rs1 111 rd 0110011 ANDN", daTypeRa},    // R
+		{"0100000 rs2 rs1 110 rd 0110011 ORN", daTypeRa},     // R
+		{"0100000 rs2 rs1 100 rd 0110011 XNOR", daTypeRa},    // R
+		{"0110000 rs2 rs1 001 rd 0110011 ROL", daTypeRa},     // R
+		{"0110000 rs2 rs1 101 rd 0110011 ROR", daTypeRa},     // R
+		{"011000 shamt6 rs1 101 rd 0010011 RORI", daTypeId},  // I
+		{"0000100 rs2 rs1 100 rd 0110011 PACK", daTypeRa},    // R
+		{"0000100 rs2 rs1 111 rd 0110011 PACKH", daTypeRa},   // R
+		{"0110100 00111 rs1 101 rd 0010011 BREV8", daTypeRl}, // R
+	},
+}
+
+// isaZbkb32 bit manipulation for cryptography (RV32 only).
+var isaZbkb32 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0110100 11000 rs1 101 rd 0010011 REV8", daTypeRl},  // R
+		{"0000100 01111 rs1 001 rd 0010011 ZIP", daTypeRl},   // R
+		{"0000100 01111 rs1 101 rd 0010011 UNZIP", daTypeRl}, // R
+	},
+}
+
+// isaZbkc carry-less multiply for cryptography.
+var isaZbkc = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0000101 rs2 rs1 001 rd 0110011 CLMUL", daTypeRa},  // R
+		{"0000101 rs2 rs1 011 rd 0110011 CLMULH", daTypeRa}, // R
+	},
+}
+
+// isaZbkx crossbar permutations.
+var isaZbkx = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0010100 rs2 rs1 100 rd 0110011 XPERM8", daTypeRa}, // R
+		{"0010100 rs2 rs1 010 rd 0110011 XPERM4", daTypeRa}, // R
+	},
+}
+
+// isaZknd32 AES decryption (RV32).
+var isaZknd32 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"bs 10101 rs2 rs1 000 rd 0110011 AES32DSI", daTypeRm},  // R
+		{"bs 10111 rs2 rs1 000 rd 0110011 AES32DSMI", daTypeRm}, // R
+	},
+}
+
+// isaZkne32 AES encryption (RV32).
+var isaZkne32 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"bs 10001 rs2 rs1 000 rd 0110011 AES32ESI", daTypeRm},  // R
+		{"bs 10011 rs2 rs1 000 rd 0110011 AES32ESMI", daTypeRm}, // R
+	},
+}
+
+// isaZknh SHA-256 hash functions.
+var isaZknh = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0001000 00010 rs1 001 rd 0010011 SHA256SIG0", daTypeRl}, // R
+		{"0001000 00011 rs1 001 rd 0010011 SHA256SIG1", daTypeRl}, // R
+		{"0001000 00000 rs1 001 rd 0010011 SHA256SUM0", daTypeRl}, // R
+		{"0001000 00001 rs1 001 rd 0010011 SHA256SUM1", daTypeRl}, // R
+	},
+}
+
+// isaZknh32 SHA-512 hash functions (RV32).
+var isaZknh32 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0101110 rs2 rs1 000 rd 0110011 SHA512SIG0H", daTypeRa}, // R
+		{"0101010 rs2 rs1 000 rd 0110011 SHA512SIG0L", daTypeRa}, // R
+		{"0101111 rs2 rs1 000 rd 0110011 SHA512SIG1H", daTypeRa}, // R
+		{"0101011 rs2 rs1 000 rd 0110011 SHA512SIG1L", daTypeRa}, // R
+		{"0101000 rs2 rs1 000 rd 0110011 SHA512SUM0R", daTypeRa}, // R
+		{"0101001 rs2 rs1 000 rd 0110011 SHA512SUM1R", daTypeRa}, // R
+	},
+}
+
+// isaZksed SM4 block cipher.
+var isaZksed = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"bs 11000 rs2 rs1 000 rd 0110011 SM4ED", daTypeRm}, // R
+		{"bs 11010 rs2 rs1 000 rd 0110011 SM4KS", daTypeRm}, // R
+	},
+}
+
+// isaZksh SM3 hash functions.
+var isaZksh = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0001000 01000 rs1 001 rd 0010011 SM3P0", daTypeRl}, // R
+		{"0001000 01001 rs1 001 rd 0010011 SM3P1", daTypeRl}, // R
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Vector cryptography instructions (RV32/64)
+
+// isaZvbb vector basic bit manipulation.
+var isaZvbb = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"010010 vm vs2 01010 010 vd 1010111 VBREV.V", daTypeVd},      // V
+		{"010010 vm vs2 01100 010 vd 1010111 VCLZ.V", daTypeVd},       // V
+		{"010010 vm vs2 01101 010 vd 1010111 VCTZ.V", daTypeVd},       // V
+		{"010010 vm vs2 01110 010 vd 1010111 VCPOP.V", daTypeVd},      // V
+		{"110101 vm vs2 vs1 000 vd 1010111 VWSLL.VV", daTypeVa},       // V
+		{"110101 vm vs2 rs1 100 vd 1010111 VWSLL.VX", daTypeVb},       // V
+		{"110101 vm vs2 uimm[4:0] 011 vd 1010111 VWSLL.VI", daTypeVc}, // V
+	},
+}
+
+// isaZvkb vector cryptography bit manipulation.
+var isaZvkb = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"000001 vm vs2 vs1 000 vd 1010111 VANDN.VV", daTypeVa},             // V
+		{"000001 vm vs2 rs1 100 vd 1010111 VANDN.VX", daTypeVb},             // V
+		{"010010 vm vs2 01000 010 vd 1010111 VBREV8.V", daTypeVd},           // V
+		{"010010 vm vs2 01001 010 vd 1010111 VREV8.V", daTypeVd},            // V
+		{"010101 vm vs2 vs1 000 vd 1010111 VROL.VV", daTypeVa},              // V
+		{"010101 vm vs2 rs1 100 vd 1010111 VROL.VX", daTypeVb},              // V
+		{"010100 vm vs2 vs1 000 vd 1010111 VROR.VV", daTypeVa},              // V
+		{"010100 vm vs2 rs1 100 vd 1010111 VROR.VX", daTypeVb},              // V
+		{"01010 uimm[5] vm vs2 uimm[4:0] 011 vd 1010111 VROR.VI", daTypeVe}, // V
+	},
+}
+
+// isaZvbc vector carry-less multiply.
+var isaZvbc = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"001100 vm vs2 vs1 010 vd 1010111 VCLMUL.VV", daTypeVa},  // V
+		{"001100 vm vs2 rs1 110 vd 1010111 VCLMUL.VX", daTypeVb},  // V
+		{"001101 vm vs2 vs1 010 vd 1010111 VCLMULH.VV", daTypeVa}, // V
+		{"001101 vm vs2 rs1 110 vd 1010111 VCLMULH.VX", daTypeVb}, // V
+	},
+}
+
+// isaZvkg vector GCM/GMAC.
+var isaZvkg = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"101100 1 vs2 vs1 010 vd 1110111 VGHSH.VV", daTypeVa},   // V
+		{"101000 1 vs2 10001 010 vd 1110111 VGMUL.VV", daTypeVd}, // V
+	},
+}
+
+// isaZvkned vector AES block cipher.
+var isaZvkned = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"101000 1 vs2 00001 010 vd 1110111 VAESDF.VV", daTypeVd},      // V
+		{"101001 1 vs2 00001 010 vd 1110111 VAESDF.VS", daTypeVd},      // V
+		{"101000 1 vs2 00000 010 vd 1110111 VAESDM.VV", daTypeVd},      // V
+		{"101001 1 vs2 00000 010 vd 1110111 VAESDM.VS", daTypeVd},      // V
+		{"101000 1 vs2 00011 010 vd 1110111 VAESEF.VV", daTypeVd},      // V
+		{"101001 1 vs2 00011 010 vd 1110111 VAESEF.VS", daTypeVd},      // V
+		{"101000 1 vs2 00010 010 vd 1110111 VAESEM.VV", daTypeVd},      // V
+		{"101001 1 vs2 00010 010 vd 1110111 VAESEM.VS", daTypeVd},      // V
+		{"101001 1 vs2 00111 010 vd 1110111 VAESZ.VS", daTypeVd},       // V
+		{"100010 1 vs2 uimm[4:0] 010 vd 1110111 VAESKF1.VI", daTypeVc}, // V
+		{"101010 1 vs2 uimm[4:0] 010 vd 1110111 VAESKF2.VI", daTypeVc}, // V
+	},
+}
+
+// isaZvknh vector SHA-2 secure hash.
+var isaZvknh = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"101101 1 vs2 vs1 010 vd 1110111 VSHA2MS.VV", daTypeVa}, // V
+		{"101110 1 vs2 vs1 010 vd 1110111 VSHA2CH.VV", daTypeVa}, // V
+		{"101111 1 vs2 vs1 010 vd 1110111 VSHA2CL.VV", daTypeVa}, // V
+	},
+}
+
+// isaZvksed vector SM4 block cipher.
+var isaZvksed = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"100001 1 vs2 uimm[4:0] 010 vd 1110111 VSM4K.VI", daTypeVc}, // V
+		{"101000 1 vs2 10000 010 vd 1110111 VSM4R.VV", daTypeVd},     // V
+		{"101001 1 vs2 10000 010 vd 1110111 VSM4R.VS", daTypeVd},     // V
+	},
+}
+
+// isaZvksh vector SM3 secure hash.
+var isaZvksh = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"101011 1 vs2 uimm[4:0] 010 vd 1110111 VSM3C.VI", daTypeVc}, // V
+		{"100000 1 vs2 vs1 010 vd 1110111 VSM3ME.VV", daTypeVa},      // V
+	},
+}
+
+//-----------------------------------------------------------------------------
+// T-Head vendor instructions (RV32/64)
+
+// isaXTheadBa address calculation.
+var isaXTheadBa = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"00000 imm2 rs2 rs1 001 rd 0001011 TH.ADDSL", daTypeRp}, // R
+	},
+}
+
+// isaXTheadBb basic bit manipulation.
+var isaXTheadBb = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"000100 shamt6 rs1 001 rd 0001011 TH.SRRI", daTypeId},   // I
+		{"msb lsb rs1 010 rd 0001011 TH.EXT", daTypeIs},          // I
+		{"msb lsb rs1 011 rd 0001011 TH.EXTU", daTypeIs},         // I
+		{"1000010 00000 rs1 001 rd 0001011 TH.FF0", daTypeRl},    // R
+		{"1000011 00000 rs1 001 rd 0001011 TH.FF1", daTypeRl},    // R
+		{"1000001 00000 rs1 001 rd 0001011 TH.REV", daTypeRl},    // R
+		{"1000000 00000 rs1 001 rd 0001011 TH.TSTNBZ", daTypeRl}, // R
+	},
+}
+
+// isaXTheadBs single bit test.
+var isaXTheadBs = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"100010 shamt6 rs1 001 rd 0001011 TH.TST", daTypeId}, // I
+	},
+}
+
+// isaXTheadCondMov conditional move.
+var isaXTheadCondMov = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0100000 rs2 rs1 001 rd 0001011 TH.MVEQZ", daTypeRa}, // R
+		{"0100001 rs2 rs1 001 rd 0001011 TH.MVNEZ", daTypeRa}, // R
+	},
+}
+
+// isaXTheadMac multiply-accumulate.
+var isaXTheadMac = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0010000 rs2 rs1 001 rd 0001011 TH.MULA", daTypeRa},  // R
+		{"0010001 rs2 rs1 001 rd 0001011 TH.MULS", daTypeRa},  // R
+		{"0010100 rs2 rs1 001 rd 0001011 TH.MULAH", daTypeRa}, // R
+		{"0010101 rs2 rs1 001 rd 0001011 TH.MULSH", daTypeRa}, // R
+	},
+}
+
+// isaXTheadMemIdx indexed and update memory operations.
+var isaXTheadMemIdx = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"00011 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LBIA", daTypeIt},  // I
+		{"00001 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LBIB", daTypeIt},  // I
+		{"10011 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LBUIA", daTypeIt}, // I
+		{"10001 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LBUIB", daTypeIt}, // I
+		{"00111 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LHIA", daTypeIt},  // I
+		{"00101 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LHIB", daTypeIt},  // I
+		{"10111 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LHUIA", daTypeIt}, // I
+		{"10101 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LHUIB", daTypeIt}, // I
+		{"01011 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LWIA", daTypeIt},  // I
+		{"01001 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LWIB", daTypeIt},  // I
+		{"00011 imm2 imm5 rs1/rd!=0 101 rs3 0001011 TH.SBIA", daTypeIt}, // S
+		{"00001 imm2 imm5 rs1/rd!=0 101 rs3 0001011 TH.SBIB", daTypeIt}, // S
+		{"00111 imm2 imm5 rs1/rd!=0 101 rs3 0001011 TH.SHIA", daTypeIt}, // S
+		{"00101 imm2 imm5 rs1/rd!=0 101 rs3 0001011 TH.SHIB", daTypeIt}, // S
+		{"01011 imm2 imm5 rs1/rd!=0 101 rs3 0001011 TH.SWIA", daTypeIt}, // S
+		{"01001 imm2 imm5 rs1/rd!=0 101 rs3 0001011 TH.SWIB", daTypeIt}, // S
+		{"00000 imm2 rs2 rs1 100 rd 0001011 TH.LRB", daTypeRp},          // R
+		{"10000 imm2 rs2 rs1 100 rd 0001011 TH.LRBU", daTypeRp},         // R
+		{"00010 imm2 rs2 rs1 100 rd 0001011 TH.LURB", daTypeRp},         // R
+		{"10010 imm2 rs2 rs1 100 rd 0001011 TH.LURBU", daTypeRp},        // R
+		{"00100 imm2 rs2 rs1 100 rd 0001011 TH.LRH", daTypeRp},          // R
+		{"10100 imm2 rs2 rs1 100 rd 0001011 TH.LRHU", daTypeRp},         // R
+		{"00110 imm2 rs2 rs1 100 rd 0001011 TH.LURH", daTypeRp},         // R
+		{"10110 imm2 rs2 rs1 100 rd 0001011 TH.LURHU", daTypeRp},        // R
+		{"01000 imm2 rs2 rs1 100 rd 0001011 TH.LRW", daTypeRp},          // R
+		{"01010 imm2 rs2 rs1 100 rd 0001011 TH.LURW", daTypeRp},         // R
+		{"00000 imm2 rs2 rs1 101 rs3 0001011 TH.SRB", daTypeRp},         // R
+		{"00010 imm2 rs2 rs1 101 rs3 0001011 TH.SURB", daTypeRp},        // R
+		{"00100 imm2 rs2 rs1 101 rs3 0001011 TH.SRH", daTypeRp},         // R
+		{"00110 imm2 rs2 rs1 101 rs3 0001011 TH.SURH", daTypeRp},        // R
+		{"01000 imm2 rs2 rs1 101 rs3 0001011 TH.SRW", daTypeRp},         // R
+		{"01010 imm2 rs2 rs1 101 rs3 0001011 TH.SURW", daTypeRp},        // R
+	},
+}
+
+// isaXTheadMemPair two register memory operations.
+var isaXTheadMemPair = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"11100 imm2 rd2 rs1 100 rd 0001011 TH.LWD", daTypeRr},  // R
+		{"11100 imm2 rs2 rs1 101 rs3 0001011 TH.SWD", daTypeRr}, // R
+	},
+}
+
+// isaXTheadFMemIdxF indexed single precision memory operations.
+var isaXTheadFMemIdxF = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"01000 imm2 rs2 rs1 110 fd 0001011 TH.FLRW", daTypeRq},  // R
+		{"01000 imm2 rs2 rs1 111 fs3 0001011 TH.FSRW", daTypeRq}, // R
+	},
+}
+
+// isaXTheadFMemIdxD indexed double precision memory operations.
+var isaXTheadFMemIdxD = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"01100 imm2 rs2 rs1 110 fd 0001011 TH.FLRD", daTypeRq},  // R
+		{"01100 imm2 rs2 rs1 111 fs3 0001011 TH.FSRD", daTypeRq}, // R
+	},
+}
+
+// isaXTheadSync multi-core synchronization.
+var isaXTheadSync = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0000010 rs2 rs1 000 00000 0001011 TH.SFENCE.VMAS", daTypeRs}, // R
+		{"0000000 11000 00000 000 00000 0001011 TH.SYNC", daTypeIi},    // I
+		{"0000000 11001 00000 000 00000 0001011 TH.SYNC.S", daTypeIi},  // I
+		{"0000000 11010 00000 000 00000 0001011 TH.SYNC.I", daTypeIi},  // I
+		{"0000000 11011 00000 000 00000 0001011 TH.SYNC.IS", daTypeIi}, // I
+	},
+}
+
+// isaXTheadCmo cache management operations.
+var isaXTheadCmo = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0000000 00001 00000 000 00000 0001011 TH.DCACHE.CALL", daTypeIi},   // I
+		{"0000000 00010 00000 000 00000 0001011 TH.DCACHE.IALL", daTypeIi},   // I
+		{"0000000 00011 00000 000 00000 0001011 TH.DCACHE.CIALL", daTypeIi},  // I
+		{"0000001 00001 rs1 000 00000 0001011 TH.DCACHE.CSW", daTypeIq},      // I
+		{"0000001 00010 rs1 000 00000 0001011 TH.DCACHE.ISW", daTypeIq},      // I
+		{"0000001 00011 rs1 000 00000 0001011 TH.DCACHE.CISW", daTypeIq},     // I
+		{"0000001 00100 rs1 000 00000 0001011 TH.DCACHE.CVAL1", daTypeIq},    // I
+		{"0000001 00101 rs1 000 00000 0001011 TH.DCACHE.CVA", daTypeIq},      // I
+		{"0000001 00110 rs1 000 00000 0001011 TH.DCACHE.IVA", daTypeIq},      // I
+		{"0000001 00111 rs1 000 00000 0001011 TH.DCACHE.CIVA", daTypeIq},     // I
+		{"0000001 01000 rs1 000 00000 0001011 TH.DCACHE.CPAL1", daTypeIq},    // I
+		{"0000001 01001 rs1 000 00000 0001011 TH.DCACHE.CPA", daTypeIq},      // I
+		{"0000001 01010 rs1 000 00000 0001011 TH.DCACHE.IPA", daTypeIq},      // I
+		{"0000001 01011 rs1 000 00000 0001011 TH.DCACHE.CIPA", daTypeIq},     // I
+		{"0000000 10000 00000 000 00000 0001011 TH.ICACHE.IALL", daTypeIi},   // I
+		{"0000000 10001 00000 000 00000 0001011 TH.ICACHE.IALLS", daTypeIi},  // I
+		{"0000001 10000 rs1 000 00000 0001011 TH.ICACHE.IVA", daTypeIq},      // I
+		{"0000001 11000 rs1 000 00000 0001011 TH.ICACHE.IPA", daTypeIq},      // I
+		{"0000000 10101 00000 000 00000 0001011 TH.L2CACHE.CALL", daTypeIi},  // I
+		{"0000000 10110 00000 000 00000 0001011 TH.L2CACHE.IALL", daTypeIi},  // I
+		{"0000000 10111 00000 000 00000 0001011 TH.L2CACHE.CIALL", daTypeIi}, // I
+	},
+}
+
+//-----------------------------------------------------------------------------
+// CORE-V vendor instructions (RV32)
+
+// isaXCVHwlp hardware loops.
+var isaXCVHwlp = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"uimml 00000 100 0000 l 0101011 CV.STARTI", daTypeXCVa},     // I
+		{"000000000000 rs1 100 0001 l 0101011 CV.START", daTypeXCVc}, // I
+		{"uimml 00000 100 0010 l 0101011 CV.ENDI", daTypeXCVa},       // I
+		{"000000000000 rs1 100 0011 l 0101011 CV.END", daTypeXCVc},   // I
+		{"uimml 00000 100 0100 l 0101011 CV.COUNTI", daTypeXCVb},     // I
+		{"000000000000 rs1 100 0101 l 0101011 CV.COUNT", daTypeXCVc}, // I
+		{"uimml uimms 100 0110 l 0101011 CV.SETUPI", daTypeXCVd},     // I
+		{"uimml rs1 100 0111 l 0101011 CV.SETUP", daTypeXCVe},        // I
+	},
+}
+
+// isaXCVMem post-increment and register offset memory operations.
+var isaXCVMem = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"imm[11:0] rs1/rd!=0 000 rd 0001011 CV.LB", daTypeXCVf},           // I
+		{"imm[11:0] rs1/rd!=0 100 rd 0001011 CV.LBU", daTypeXCVf},          // I
+		{"imm[11:0] rs1/rd!=0 001 rd 0001011 CV.LH", daTypeXCVf},           // I
+		{"imm[11:0] rs1/rd!=0 101 rd 0001011 CV.LHU", daTypeXCVf},          // I
+		{"imm[11:0] rs1/rd!=0 010 rd 0001011 CV.LW", daTypeXCVf},           // I
+		{"0000000 rs2 rs1/rd!=0 011 rd 0101011 CV.LB", daTypeXCVg},         // R
+		{"0001000 rs2 rs1/rd!=0 011 rd 0101011 CV.LBU", daTypeXCVg},        // R
+		{"0000001 rs2 rs1/rd!=0 011 rd 0101011 CV.LH", daTypeXCVg},         // R
+		{"0001001 rs2 rs1/rd!=0 011 rd 0101011 CV.LHU", daTypeXCVg},        // R
+		{"0000010 rs2 rs1/rd!=0 011 rd 0101011 CV.LW", daTypeXCVg},         // R
+		{"0000100 rs2 rs1 011 rd 0101011 CV.LB", daTypeXCVh},               // R
+		{"0001100 rs2 rs1 011 rd 0101011 CV.LBU", daTypeXCVh},              // R
+		{"0000101 rs2 rs1 011 rd 0101011 CV.LH", daTypeXCVh},               // R
+		{"0001101 rs2 rs1 011 rd 0101011 CV.LHU", daTypeXCVh},              // R
+		{"0000110 rs2 rs1 011 rd 0101011 CV.LW", daTypeXCVh},               // R
+		{"imm[11:5] rs2 rs1/rd!=0 000 imm[4:0] 0101011 CV.SB", daTypeXCVi}, // S
+		{"imm[11:5] rs2 rs1/rd!=0 001 imm[4:0] 0101011 CV.SH", daTypeXCVi}, // S
+		{"imm[11:5] rs2 rs1/rd!=0 010 imm[4:0] 0101011 CV.SW", daTypeXCVi}, // S
+		{"0010000 rs2 rs1/rd!=0 011 rs3 0101011 CV.SB", daTypeXCVj},        // R
+		{"0010001 rs2 rs1/rd!=0 011 rs3 0101011 CV.SH", daTypeXCVj},        // R
+		{"0010010 rs2 rs1/rd!=0 011 rs3 0101011 CV.SW", daTypeXCVj},        // R
+		{"0010100 rs2 rs1 011 rs3 0101011 CV.SB", daTypeXCVk},              // R
+		{"0010101 rs2 rs1 011 rs3 0101011 CV.SH", daTypeXCVk},              // R
+		{"0010110 rs2 rs1 011 rs3 0101011 CV.SW", daTypeXCVk},              // R
+	},
+}
+
+// isaXCVElw event load.
+var isaXCVElw = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"imm[11:0] rs1 011 rd 0001011 CV.ELW", daTypeIc}, // I
+	},
+}
+
+// isaXCVBi immediate branches.
+var isaXCVBi = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"imm[12|10:5] imm5 rs1 110 imm[4:1|11] 0001011 CV.BEQIMM", daTypeXCVl}, // B
+		{"imm[12|10:5] imm5 rs1 111 imm[4:1|11] 0001011 CV.BNEIMM", daTypeXCVl}, // B
+	},
+}
+
+// isaXCVBitmanip bit manipulation.
+var isaXCVBitmanip = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"00 is3 is2 rs1 000 rd 1011011 CV.EXTRACT", daTypeIu},         // I
+		{"01 is3 is2 rs1 000 rd 1011011 CV.EXTRACTU", daTypeIu},        // I
+		{"10 is3 is2 rs1 000 rd 1011011 CV.INSERT", daTypeIu},          // I
+		{"00 is3 is2 rs1 001 rd 1011011 CV.BCLR", daTypeIu},            // I
+		{"01 is3 is2 rs1 001 rd 1011011 CV.BSET", daTypeIu},            // I
+		{"11 000 is3[1:0] is2 rs1 001 rd 1011011 CV.BITREV", daTypeIu}, // I
+		{"0011000 rs2 rs1 011 rd 0101011 CV.EXTRACTR", daTypeRa},       // R
+		{"0011001 rs2 rs1 011 rd 0101011 CV.EXTRACTUR", daTypeRa},      // R
+		{"0011010 rs2 rs1 011 rd 0101011 CV.INSERTR", daTypeRa},        // R
+		{"0011100 rs2 rs1 011 rd 0101011 CV.BCLRR", daTypeRa},          // R
+		{"0011101 rs2 rs1 011 rd 0101011 CV.BSETR", daTypeRa},          // R
+		{"0100000 rs2 rs1 011 rd 0101011 CV.ROR", daTypeRa},            // R
+		{"0100001 00000 rs1 011 rd 0101011 CV.FF1", daTypeRl},          // R
+		{"0100010 00000 rs1 011 rd 0101011 CV.FL1", daTypeRl},          // R
+		{"0100011 00000 rs1 011 rd 0101011 CV.CLB", daTypeRl},          // R
+		{"0100100 00000 rs1 011 rd 0101011 CV.CNT", daTypeRl},          // R
+	},
+}
+
+// isaXCVAlu miscellaneous ALU operations.
+var isaXCVAlu = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0101000 00000 rs1 011 rd 0101011 CV.ABS", daTypeRl},   // R
+		{"0101001 rs2 rs1 011 rd 0101011 CV.SLE", daTypeRa},     // R
+		{"0101010 rs2 rs1 011 rd 0101011 CV.SLEU", daTypeRa},    // R
+		{"0101011 rs2 rs1 011 rd 0101011 CV.MIN", daTypeRa},     // R
+		{"0101100 rs2 rs1 011 rd 0101011 CV.MINU", daTypeRa},    // R
+		{"0101101 rs2 rs1 011 rd 0101011 CV.MAX", daTypeRa},     // R
+		{"0101110 rs2 rs1 011 rd 0101011 CV.MAXU", daTypeRa},    // R
+		{"0110000 00000 rs1 011 rd 0101011 CV.EXTHS", daTypeRl}, // R
+		{"0110001 00000 rs1 011 rd 0101011 CV.EXTHZ", daTypeRl}, // R
+		{"0110010 00000 rs1 011 rd 0101011 CV.EXTBS", daTypeRl}, // R
+		{"0110011 00000 rs1 011 rd 0101011 CV.EXTBZ", daTypeRl}, // R
+		{"0111000 is2 rs1 011 rd 0101011 CV.CLIP", daTypeIv},    // I
+		{"0111001 is2 rs1 011 rd 0101011 CV.CLIPU", daTypeIv},   // I
+		{"0111010 rs2 rs1 011 rd 0101011 CV.CLIPR", daTypeRa},   // R
+		{"0111011 rs2 rs1 011 rd 0101011 CV.CLIPUR", daTypeRa},  // R
+		{"1000000 rs2 rs1 011 rd 0101011 CV.ADDNR", daTypeRa},   // R
+		{"1000001 rs2 rs1 011 rd 0101011 CV.ADDUNR", daTypeRa},  // R
+		{"1000010 rs2 rs1 011 rd 0101011 CV.ADDRNR", daTypeRa},  // R
+		{"1000011 rs2 rs1 011 rd 0101011 CV.ADDURNR", daTypeRa}, // R
+		{"1000100 rs2 rs1 011 rd 0101011 CV.SUBNR", daTypeRa},   // R
+		{"1000101 rs2 rs1 011 rd 0101011 CV.SUBUNR", daTypeRa},  // R
+		{"1000110 rs2 rs1 011 rd 0101011 CV.SUBRNR", daTypeRa},  // R
+		{"1000111 rs2 rs1 011 rd 0101011 CV.SUBURNR", daTypeRa}, // R
+		{"00 is3 rs2 rs1 010 rd 1011011 CV.ADDN", daTypeRt},     // R
+		{"01 is3 rs2 rs1 010 rd 1011011 CV.ADDUN", daTypeRt},    // R
+		{"10 is3 rs2 rs1 010 rd 1011011 CV.ADDRN", daTypeRt},    // R
+		{"11 is3 rs2 rs1 010 rd 1011011 CV.ADDURN", daTypeRt},   // R
+		{"00 is3 rs2 rs1 011 rd 1011011 CV.SUBN", daTypeRt},     // R
+		{"01 is3 rs2 rs1 011 rd 1011011 CV.SUBUN", daTypeRt},    // R
+		{"10 is3 rs2 rs1 011 rd 1011011 CV.SUBRN", daTypeRt},    // R
+		{"11 is3 rs2 rs1 011 rd 1011011 CV.SUBURN", daTypeRt},   // R
+	},
+}
+
+// isaXCVMac multiply-accumulate.
+var isaXCVMac = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"1001000 rs2 rs1 011 rd 0101011 CV.MAC", daTypeRa},     // R
+		{"1001001 rs2 rs1 011 rd 0101011 CV.MSU", daTypeRa},     // R
+		{"00 is3 rs2 rs1 100 rd 1011011 CV.MULSN", daTypeRt},    // R
+		{"01 is3 rs2 rs1 100 rd 1011011 CV.MULHHSN", daTypeRt},  // R
+		{"10 is3 rs2 rs1 100 rd 1011011 CV.MULSRN", daTypeRt},   // R
+		{"11 is3 rs2 rs1 100 rd 1011011 CV.MULHHSRN", daTypeRt}, // R
+		{"00 is3 rs2 rs1 101 rd 1011011 CV.MULUN", daTypeRt},    // R
+		{"01 is3 rs2 rs1 101 rd 1011011 CV.MULHHUN", daTypeRt},  // R
+		{"10 is3 rs2 rs1 101 rd 1011011 CV.MULURN", daTypeRt},   // R
+		{"11 is3 rs2 rs1 101 rd 1011011 CV.MULHHURN", daTypeRt}, // R
+		{"00 is3 rs2 rs1 110 rd 1011011 CV.MACSN", daTypeRt},    // R
+		{"01 is3 rs2 rs1 110 rd 1011011 CV.MACHHSN", daTypeRt},  // R
+		{"10 is3 rs2 rs1 110 rd 1011011 CV.MACSRN", daTypeRt},   // R
+		{"11 is3 rs2 rs1 110 rd 1011011 CV.MACHHSRN", daTypeRt}, // R
+		{"00 is3 rs2 rs1 111 rd 1011011 CV.MACUN", daTypeRt},    // R
+		{"01 is3 rs2 rs1 111 rd 1011011 CV.MACHHUN", daTypeRt},  // R
+		{"10 is3 rs2 rs1 111 rd 1011011 CV.MACURN", daTypeRt},   // R
+		{"11 is3 rs2 rs1 111 rd 1011011 CV.MACHHURN", daTypeRt}, // R
+	},
+}
+
+// isaXCVSimd packed SIMD.
+var isaXCVSimd = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"00000 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.ADD", daTypeXCVm},           // R
+		{"00000 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.ADD", daTypeXCVn},     // I
+		{"00001 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.SUB", daTypeXCVm},           // R
+		{"00001 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.SUB", daTypeXCVn},     // I
+		{"00010 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.AVG", daTypeXCVm},           // R
+		{"00010 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.AVG", daTypeXCVn},     // I
+		{"00011 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.AVGU", daTypeXCVm},          // R
+		{"00011 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.AVGU", daTypeXCVo},    // I
+		{"00100 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.MIN", daTypeXCVm},           // R
+		{"00100 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.MIN", daTypeXCVn},     // I
+		{"00101 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.MINU", daTypeXCVm},          // R
+		{"00101 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.MINU", daTypeXCVo},    // I
+		{"00110 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.MAX", daTypeXCVm},           // R
+		{"00110 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.MAX", daTypeXCVn},     // I
+		{"00111 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.MAXU", daTypeXCVm},          // R
+		{"00111 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.MAXU", daTypeXCVo},    // I
+		{"01000 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.SRL", daTypeXCVm},           // R
+		{"01000 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.SRL", daTypeXCVo},     // I
+		{"01001 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.SRA", daTypeXCVm},           // R
+		{"01001 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.SRA", daTypeXCVo},     // I
+		{"01010 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.SLL", daTypeXCVm},           // R
+		{"01010 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.SLL", daTypeXCVo},     // I
+		{"01011 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.OR", daTypeXCVm},            // R
+		{"01011 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.OR", daTypeXCVn},      // I
+		{"01100 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.XOR", daTypeXCVm},           // R
+		{"01100 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.XOR", daTypeXCVn},     // I
+		{"01101 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.AND", daTypeXCVm},           // R
+		{"01101 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.AND", daTypeXCVn},     // I
+		{"10000 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.DOTUP", daTypeXCVm},         // R
+		{"10000 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.DOTUP", daTypeXCVo},   // I
+		{"10001 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.DOTUSP", daTypeXCVm},        // R
+		{"10001 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.DOTUSP", daTypeXCVn},  // I
+		{"10010 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.DOTSP", daTypeXCVm},         // R
+		{"10010 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.DOTSP", daTypeXCVn},   // I
+		{"10011 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.SDOTUP", daTypeXCVm},        // R
+		{"10011 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.SDOTUP", daTypeXCVo},  // I
+		{"10100 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.SDOTUSP", daTypeXCVm},       // R
+		{"10100 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.SDOTUSP", daTypeXCVn}, // I
+		{"10101 0 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.SDOTSP", daTypeXCVm},        // R
+		{"10101 0 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.SDOTSP", daTypeXCVn},  // I
+		{"01110 0 0 00000 rs1 000 rd 1111011 CV.ABS.H", daTypeRl},                     // R
+		{"01110 0 0 00000 rs1 001 rd 1111011 CV.ABS.B", daTypeRl},                     // R
+		{"00000 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPEQ", daTypeXCVm},         // R
+		{"00000 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPEQ", daTypeXCVn},   // I
+		{"00001 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPNE", daTypeXCVm},         // R
+		{"00001 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPNE", daTypeXCVn},   // I
+		{"00010 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPGT", daTypeXCVm},         // R
+		{"00010 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPGT", daTypeXCVn},   // I
+		{"00011 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPGE", daTypeXCVm},         // R
+		{"00011 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPGE", daTypeXCVn},   // I
+		{"00100 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPLT", daTypeXCVm},         // R
+		{"00100 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPLT", daTypeXCVn},   // I
+		{"00101 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPLE", daTypeXCVm},         // R
+		{"00101 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPLE", daTypeXCVn},   // I
+		{"00110 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPGTU", daTypeXCVm},        // R
+		{"00110 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPGTU", daTypeXCVo},  // I
+		{"00111 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPGEU", daTypeXCVm},        // R
+		{"00111 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPGEU", daTypeXCVo},  // I
+		{"01000 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPLTU", daTypeXCVm},        // R
+		{"01000 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPLTU", daTypeXCVo},  // I
+		{"01001 1 0 rs2 rs1 fmt[2] 0 fmt[0] rd 1111011 CV.CMPLEU", daTypeXCVm},        // R
+		{"01001 1 imm6[0] imm6[5:1] rs1 11 fmt[0] rd 1111011 CV.CMPLEU", daTypeXCVo},  // I
+	},
+}
+
+//-----------------------------------------------------------------------------
+// SiFive vendor instructions (RV32/64)
+
+// isaXSfvcp vector coprocessor interface (VCIX).
+var isaXSfvcp = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0000 op[1:0] 1 uimm5 rs1 100 uimmd 1011011 SF.VC.X", daTypeVh},   // V
+		{"0000 op[1:0] 1 uimm5 simm5 011 uimmd 1011011 SF.VC.I", daTypeVh}, // V
+		{"0010 op[1:0] 1 vs2 rs1 100 uimmd 1011011 SF.VC.XV", daTypeVh},    // V
+		{"0010 op[1:0] 1 vs2 simm5 011 uimmd 1011011 SF.VC.IV", daTypeVh},  // V
+		{"0010 op[1:0] 1 vs2 vs1 000 uimmd 1011011 SF.VC.VV", daTypeVh},    // V
+		{"0010 1 op[0] 1 vs2 fs1 101 uimmd 1011011 SF.VC.FV", daTypeVh},    // V
+		{"1010 op[1:0] 1 vs2 rs1 100 vs3 1011011 SF.VC.XVV", daTypeVh},     // V
+		{"1010 op[1:0] 1 vs2 simm5 011 vs3 1011011 SF.VC.IVV", daTypeVh},   // V
+		{"1010 op[1:0] 1 vs2 vs1 000 vs3 1011011 SF.VC.VVV", daTypeVh},     // V
+		{"1010 1 op[0] 1 vs2 fs1 101 vs3 1011011 SF.VC.FVV", daTypeVh},     // V
+		{"1111 op[1:0] 1 vs2 rs1 100 vs3 1011011 SF.VC.XVW", daTypeVh},     // V
+		{"1111 op[1:0] 1 vs2 simm5 011 vs3 1011011 SF.VC.IVW", daTypeVh},   // V
+		{"1111 op[1:0] 1 vs2 vs1 000 vs3 1011011 SF.VC.VVW", daTypeVh},     // V
+		{"1111 1 op[0] 1 vs2 fs1 101 vs3 1011011 SF.VC.FVW", daTypeVh},     // V
+		{"0000 op[1:0] 0 uimm5 rs1 100 vd 1011011 SF.VC.V.X", daTypeVh},    // V
+		{"0000 op[1:0] 0 uimm5 simm5 011 vd 1011011 SF.VC.V.I", daTypeVh},  // V
+		{"0010 op[1:0] 0 vs2 rs1 100 vd 1011011 SF.VC.V.XV", daTypeVh},     // V
+		{"0010 op[1:0] 0 vs2 simm5 011 vd 1011011 SF.VC.V.IV", daTypeVh},   // V
+		{"0010 op[1:0] 0 vs2 vs1 000 vd 1011011 SF.VC.V.VV", daTypeVh},     // V
+		{"0010 1 op[0] 0 vs2 fs1 101 vd 1011011 SF.VC.V.FV", daTypeVh},     // V
+		{"1010 op[1:0] 0 vs2 rs1 100 vd 1011011 SF.VC.V.XVV", daTypeVh},    // V
+		{"1010 op[1:0] 0 vs2 simm5 011 vd 1011011 SF.VC.V.IVV", daTypeVh},  // V
+		{"1010 op[1:0] 0 vs2 vs1 000 vd 1011011 SF.VC.V.VVV", daTypeVh},    // V
+		{"1010 1 op[0] 0 vs2 fs1 101 vd 1011011 SF.VC.V.FVV", daTypeVh},    // V
+		{"1111 op[1:0] 0 vs2 rs1 100 vd 1011011 SF.VC.V.XVW", daTypeVh},    // V
+		{"1111 op[1:0] 0 vs2 simm5 011 vd 1011011 SF.VC.V.IVW", daTypeVh},  // V
+		{"1111 op[1:0] 0 vs2 vs1 000 vd 1011011 SF.VC.V.VVW", daTypeVh},    // V
+		{"1111 1 op[0] 0 vs2 fs1 101 vd 1011011 SF.VC.V.FVW", daTypeVh},    // V
+	},
+}
+
+// isaXSfvqmaccdod int8 matrix multiply-accumulate (2x8x2).
+var isaXSfvqmaccdod = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"101100 1 vs2 vs1 010 vd 1011011 SF.VQMACCU.2X8X2", daTypeVf},  // V
+		{"101101 1 vs2 vs1 010 vd 1011011 SF.VQMACC.2X8X2", daTypeVf},   // V
+		{"101110 1 vs2 vs1 010 vd 1011011 SF.VQMACCUS.2X8X2", daTypeVf}, // V
+		{"101111 1 vs2 vs1 010 vd 1011011 SF.VQMACCSU.2X8X2", daTypeVf}, // V
+	},
+}
+
+// isaXSfvqmaccqoq int8 matrix multiply-accumulate (4x8x4).
+var isaXSfvqmaccqoq = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"111100 1 vs2 vs1 010 vd 1011011 SF.VQMACCU.4X8X4", daTypeVf},  // V
+		{"111101 1 vs2 vs1 010 vd 1011011 SF.VQMACC.4X8X4", daTypeVf},   // V
+		{"111110 1 vs2 vs1 010 vd 1011011 SF.VQMACCUS.4X8X4", daTypeVf}, // V
+		{"111111 1 vs2 vs1 010 vd 1011011 SF.VQMACCSU.4X8X4", daTypeVf}, // V
+	},
+}
+
+// isaXSfvfnrclipxfqf fp32 to int8 narrowing clip.
+var isaXSfvfnrclipxfqf = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"100010 vm vs2 fs1 101 vd 1011011 SF.VFNRCLIP.XU.F.QF", daTypeVg}, // V
+		{"100011 vm vs2 fs1 101 vd 1011011 SF.VFNRCLIP.X.F.QF", daTypeVg},  // V
+	},
+}
+
+// isaXSfcease core shutdown.
+var isaXSfcease = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0011000 00101 00000 000 00000 1110011 SF.CEASE", daTypeIi}, // I
+	},
+}
+
+// isaXSiFivecflushdlone L1 data cache flush.
+var isaXSiFivecflushdlone = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"1111110 00000 rs1 000 00000 1110011 SF.CFLUSH.D.L1", daTypeIw}, // I
+	},
+}
+
+// isaXSiFivecdiscarddlone L1 data cache discard.
+var isaXSiFivecdiscarddlone = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"1111110 00010 rs1 000 00000 1110011 SF.CDISCARD.D.L1", daTypeIw}, // I
 	},
 }
 
@@ -307,6 +1172,14 @@ var isaRV64m = isaModule{
 	},
 }
 
+// isaZicfiss64 64-bit shadow stack atomic swap.
+var isaZicfiss64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"01001 aq rl rs2 rs1 011 rd 0101111 SSAMOSWAP.D", daTypeRb}, // R
+	},
+}
+
 // isaRV64a Atomics
 var isaRV64a = isaModule{
 	ext:  ExtA,
@@ -367,6 +1240,151 @@ var isaRV64c = isaModule{
 	},
 }
 
+// isaZcb64Zba compressed zero extend word (requires Zba).
+var isaZcb64Zba = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"100 1 11 rs10/rd0 11 100 01 C.ZEXT.W", daTypeCUa}, // CU
+	},
+}
+
+// isaZbkb64 bit manipulation for cryptography (RV64).
+var isaZbkb64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0110101 11000 rs1 101 rd 0010011 REV8", daTypeRl},   // R
+		{"0000100 rs2 rs1 100 rd 0111011 PACKW", daTypeRa},    // R
+		{"0110000 rs2 rs1 001 rd 0111011 ROLW", daTypeRa},     // R
+		{"0110000 rs2 rs1 101 rd 0111011 RORW", daTypeRa},     // R
+		{"0110000 shamt5 rs1 101 rd 0011011 RORIW", daTypeId}, // I
+	},
+}
+
+// isaZknd64 AES decryption (RV64).
+var isaZknd64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0011101 rs2 rs1 000 rd 0110011 AES64DS", daTypeRa},     // R
+		{"0011111 rs2 rs1 000 rd 0110011 AES64DSM", daTypeRa},    // R
+		{"0011000 00000 rs1 001 rd 0010011 AES64IM", daTypeRl},   // R
+		{"00110001 rnum rs1 001 rd 0010011 AES64KS1I", daTypeIm}, // I
+		{"0111111 rs2 rs1 000 rd 0110011 AES64KS2", daTypeRa},    // R
+	},
+}
+
+// isaZkne64 AES encryption (RV64).
+var isaZkne64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0011001 rs2 rs1 000 rd 0110011 AES64ES", daTypeRa},     // R
+		{"0011011 rs2 rs1 000 rd 0110011 AES64ESM", daTypeRa},    // R
+		{"00110001 rnum rs1 001 rd 0010011 AES64KS1I", daTypeIm}, // I
+		{"0111111 rs2 rs1 000 rd 0110011 AES64KS2", daTypeRa},    // R
+	},
+}
+
+// isaZknh64 SHA-512 hash functions (RV64).
+var isaZknh64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0001000 00110 rs1 001 rd 0010011 SHA512SIG0", daTypeRl}, // R
+		{"0001000 00111 rs1 001 rd 0010011 SHA512SIG1", daTypeRl}, // R
+		{"0001000 00100 rs1 001 rd 0010011 SHA512SUM0", daTypeRl}, // R
+		{"0001000 00101 rs1 001 rd 0010011 SHA512SUM1", daTypeRl}, // R
+	},
+}
+
+// isaZcmp64 compressed push/pop (RV64 stack adjustment).
+var isaZcmp64 = isaModule{
+	ilen: 16,
+	defn: []insDefn{
+		{"101 11000 rlist spimm[5:4] 10 CM.PUSH", daTypeCMPPb},    // CMPP
+		{"101 11010 rlist spimm[5:4] 10 CM.POP", daTypeCMPPb},     // CMPP
+		{"101 11100 rlist spimm[5:4] 10 CM.POPRETZ", daTypeCMPPb}, // CMPP
+		{"101 11110 rlist spimm[5:4] 10 CM.POPRET", daTypeCMPPb},  // CMPP
+	},
+}
+
+//-----------------------------------------------------------------------------
+// T-Head vendor instructions (RV64)
+
+// isaXTheadBb64 64-bit basic bit manipulation.
+var isaXTheadBb64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0001010 shamt5 rs1 001 rd 0001011 TH.SRRIW", daTypeId}, // I
+		{"1001000 00000 rs1 001 rd 0001011 TH.REVW", daTypeRl},   // R
+	},
+}
+
+// isaXTheadMac64 64-bit multiply-accumulate.
+var isaXTheadMac64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0010010 rs2 rs1 001 rd 0001011 TH.MULAW", daTypeRa}, // R
+		{"0010011 rs2 rs1 001 rd 0001011 TH.MULSW", daTypeRa}, // R
+	},
+}
+
+// isaXTheadMemIdx64 64-bit indexed and update memory operations.
+var isaXTheadMemIdx64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"11011 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LWUIA", daTypeIt}, // I
+		{"11001 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LWUIB", daTypeIt}, // I
+		{"01111 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LDIA", daTypeIt},  // I
+		{"01101 imm2 imm5 rs1/rd!=0 100 rd 0001011 TH.LDIB", daTypeIt},  // I
+		{"01111 imm2 imm5 rs1/rd!=0 101 rs3 0001011 TH.SDIA", daTypeIt}, // S
+		{"01101 imm2 imm5 rs1/rd!=0 101 rs3 0001011 TH.SDIB", daTypeIt}, // S
+		{"11000 imm2 rs2 rs1 100 rd 0001011 TH.LRWU", daTypeRp},         // R
+		{"11010 imm2 rs2 rs1 100 rd 0001011 TH.LURWU", daTypeRp},        // R
+		{"01100 imm2 rs2 rs1 100 rd 0001011 TH.LRD", daTypeRp},          // R
+		{"01110 imm2 rs2 rs1 100 rd 0001011 TH.LURD", daTypeRp},         // R
+		{"01100 imm2 rs2 rs1 101 rs3 0001011 TH.SRD", daTypeRp},         // R
+		{"01110 imm2 rs2 rs1 101 rs3 0001011 TH.SURD", daTypeRp},        // R
+	},
+}
+
+// isaXTheadMemPair64 64-bit two register memory operations.
+var isaXTheadMemPair64 = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"11110 imm2 rd2 rs1 100 rd 0001011 TH.LWUD", daTypeRr}, // R
+		{"11111 imm2 rd2 rs1 100 rd 0001011 TH.LDD", daTypeRr},  // R
+		{"11111 imm2 rs2 rs1 101 rs3 0001011 TH.SDD", daTypeRr}, // R
+	},
+}
+
+// isaXTheadFMemIdx64F 64-bit indexed single precision memory operations.
+var isaXTheadFMemIdx64F = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"01010 imm2 rs2 rs1 110 fd 0001011 TH.FLURW", daTypeRq},  // R
+		{"01010 imm2 rs2 rs1 111 fs3 0001011 TH.FSURW", daTypeRq}, // R
+	},
+}
+
+// isaXTheadFMemIdx64D 64-bit indexed double precision memory operations.
+var isaXTheadFMemIdx64D = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"01110 imm2 rs2 rs1 110 fd 0001011 TH.FLURD", daTypeRq},  // R
+		{"01110 imm2 rs2 rs1 111 fs3 0001011 TH.FSURD", daTypeRq}, // R
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Ventana vendor instructions (RV64)
+
+// isaXVentanaCondOps conditional zero.
+var isaXVentanaCondOps = isaModule{
+	ilen: 32,
+	defn: []insDefn{
+		{"0000000 rs2 rs1 110 rd 1111011 VT.MASKC", daTypeRa},  // R
+		{"0000000 rs2 rs1 111 rd 1111011 VT.MASKCN", daTypeRa}, // R
+	},
+}
+
 //-----------------------------------------------------------------------------
 
 // isaRV128c Compressed
@@ -400,10 +1418,14 @@ var RV64gc = RV64g | ExtC
 // insMeta is instruction meta-data determined at runtime
 type insMeta struct {
 	defn      *insDefn   // the instruction definition
+	id        string     // instruction identifier (lower case definition mneumonic)
 	name      string     // instruction mneumonic
 	n         int        // instruction bit length
 	val, mask uint       // value and mask of fixed bits in the instruction
 	dt        decodeType // decode type
+	fields    []fieldPos // named fields within the instruction
+	fd        flowDecode // control flow (loaded instructions)
+	md        memAccess  // memory access (loaded instructions)
 }
 
 // lookup returns the instruction meta information for an instruction.
@@ -447,103 +1469,307 @@ func (isa *ISA) add(module []isaModule) error {
 
 //-----------------------------------------------------------------------------
 
-// ISA is an instruction set
-type ISA struct {
-	mxlen uint       // machine register length
-	ext   uint       // ISA extension bits per CSR misa
-	ins16 []*insMeta // the set of 16-bit instructions in the ISA
-	ins32 []*insMeta // the set of 32-bit instructions in the ISA
+// isaConfig is an ISA module and the configuration that enables it.
+type isaConfig struct {
+	module *isaModule
+	ext    uint   // required extensions
+	zext   string // required sub-extensions ("_" separated, "" for none)
+	xlen   uint   // required register length (0 for any)
 }
 
-func (isa *ISA) String() string {
-	return fmt.Sprintf("RV%d ext %s", isa.mxlen, fmtExt(isa.ext))
+// enabled returns true if the module is enabled for an ISA configuration.
+func (c *isaConfig) enabled(mxlen, ext uint, zext map[string]bool) bool {
+	return ext&c.ext == c.ext && len(c.missing(zext)) == 0 && (c.xlen == 0 || c.xlen == mxlen)
 }
 
-// New creates a new RISC-V instruction set.
-func New(mxlen, ext uint) (*ISA, error) {
-	if mxlen != 32 && mxlen != 64 {
-		return nil, fmt.Errorf("%d-bit register length is not supported", mxlen)
-	}
-	if ext == 0 {
-		return nil, errors.New("ext 0 invalid, add ISA modules")
+// missing returns the required sub-extensions that are not enabled.
+func (c *isaConfig) missing(zext map[string]bool) []string {
+	var s []string
+	if c.zext != "" {
+		for _, z := range strings.Split(c.zext, "_") {
+			if !zext[z] {
+				s = append(s, z)
+			}
+		}
 	}
-	// build the list of ISA modules
-	mod := []isaModule{}
+	return s
+}
 
-	// compression?
-	cEnable := checkExt(ext, 'c')
-
-	// RV32/64/128
-	if mxlen >= 32 {
-		// integer base
-		if checkExt(ext, 'i') {
-			mod = append(mod, isaRV32i)
-			if cEnable {
-				mod = append(mod, isaRV32c)
-				if mxlen == 32 {
-					mod = append(mod, isaRV32cOnly)
-				}
+// isaConfigs are the known ISA modules (in lookup order).
+var isaConfigs = []isaConfig{
+	// overlays of base encodings (looked up first)
+	{&isaZicbop, ExtI, "zicbop", 0},
+	{&isaZihintntl, ExtI, "zihintntl", 0},
+	{&isaZihintntlC, ExtI, "zihintntl_zca", 0},
+	{&isaZihintpause, ExtI, "zihintpause", 0},
+	{&isaZicfilp, ExtI, "zicfilp", 0},
+	{&isaZicfiss, ExtI, "zicfiss", 0},
+	{&isaZicfissC, ExtI, "zicfiss_zcmop", 0},
+	{&isaZcmop, ExtI, "zcmop", 0},
+	// RV32/64
+	{&isaRV32i, ExtI, "", 0},
+	{&isaRV32c, ExtI, "zca", 0},
+	{&isaRV32cOnly, ExtI, "zca", 32},
+	{&isaRV32m, ExtM, "", 0},
+	{&isaRV32f, ExtF, "", 0},
+	{&isaRV32fc, ExtF, "zcf", 32},
+	{&isaRV32d, ExtD, "", 0},
+	{&isaRV32dc, ExtD, "zcd", 0},
+	{&isaRV32a, ExtA, "", 0},
+	{&isaZcb, ExtI, "zcb", 0},
+	{&isaZcbM, ExtM, "zcb", 0},
+	{&isaZcbZbb, ExtI, "zcb_zbb", 0},
+	{&isaZcmp, ExtI, "zcmp", 0},
+	{&isaZcmp32, ExtI, "zcmp", 32},
+	{&isaZcmt, ExtI, "zcmt", 0},
+	{&isaZicbom, ExtI, "zicbom", 0},
+	{&isaZicboz, ExtI, "zicboz", 0},
+	{&isaZicond, ExtI, "zicond", 0},
+	{&isaZimop, ExtI, "zimop", 0},
+	{&isaZbkb, ExtI, "zbkb", 0},
+	{&isaZbkb32, ExtI, "zbkb", 32},
+	{&isaZbkc, ExtI, "zbkc", 0},
+	{&isaZbkx, ExtI, "zbkx", 0},
+	{&isaZknd32, ExtI, "zknd", 32},
+	{&isaZkne32, ExtI, "zkne", 32},
+	{&isaZknh, ExtI, "zknh", 0},
+	{&isaZknh32, ExtI, "zknh", 32},
+	{&isaZksed, ExtI, "zksed", 0},
+	{&isaZksh, ExtI, "zksh", 0},
+	{&isaZvbb, ExtI, "zvbb", 0},
+	{&isaZvkb, ExtI, "zvkb", 0},
+	{&isaZvbc, ExtI, "zvbc", 0},
+	{&isaZvkg, ExtI, "zvkg", 0},
+	{&isaZvkned, ExtI, "zvkned", 0},
+	{&isaZvknh, ExtI, "zvknha", 0},
+	{&isaZvksed, ExtI, "zvksed", 0},
+	{&isaZvksh, ExtI, "zvksh", 0},
+	// RV64
+	{&isaRV64i, ExtI, "", 64},
+	{&isaRV64c, ExtI, "zca", 64},
+	{&isaRV64m, ExtM, "", 64},
+	{&isaRV64f, ExtF, "", 64},
+	{&isaRV64d, ExtD, "", 64},
+	{&isaRV64a, ExtA, "", 64},
+	{&isaZcb64Zba, ExtI, "zcb_zba", 64},
+	{&isaZcmp64, ExtI, "zcmp", 64},
+	{&isaZicfiss64, ExtI, "zicfiss", 64},
+	{&isaZbkb64, ExtI, "zbkb", 64},
+	{&isaZknd64, ExtI, "zknd", 64},
+	{&isaZkne64, ExtI, "zkne", 64},
+	{&isaZknh64, ExtI, "zknh", 64},
+	// T-Head vendor extensions (custom-0 opcode space)
+	{&isaXTheadBa, ExtI, "xtheadba", 0},
+	{&isaXTheadBb, ExtI, "xtheadbb", 0},
+	{&isaXTheadBb64, ExtI, "xtheadbb", 64},
+	{&isaXTheadBs, ExtI, "xtheadbs", 0},
+	{&isaXTheadCondMov, ExtI, "xtheadcondmov", 0},
+	{&isaXTheadMac, ExtI, "xtheadmac", 0},
+	{&isaXTheadMac64, ExtI, "xtheadmac", 64},
+	{&isaXTheadMemIdx, ExtI, "xtheadmemidx", 0},
+	{&isaXTheadMemIdx64, ExtI, "xtheadmemidx", 64},
+	{&isaXTheadMemPair, ExtI, "xtheadmempair", 0},
+	{&isaXTheadMemPair64, ExtI, "xtheadmempair", 64},
+	{&isaXTheadFMemIdxF, ExtF, "xtheadfmemidx", 0},
+	{&isaXTheadFMemIdxD, ExtD, "xtheadfmemidx", 0},
+	{&isaXTheadFMemIdx64F, ExtF, "xtheadfmemidx", 64},
+	{&isaXTheadFMemIdx64D, ExtD, "xtheadfmemidx", 64},
+	{&isaXTheadSync, ExtI, "xtheadsync", 0},
+	{&isaXTheadCmo, ExtI, "xtheadcmo", 0},
+	// CORE-V vendor extensions (custom opcode space, RV32)
+	{&isaXCVHwlp, ExtI, "xcvhwlp", 32},
+	{&isaXCVMem, ExtI, "xcvmem", 32},
+	{&isaXCVElw, ExtI, "xcvelw", 32},
+	{&isaXCVBi, ExtI, "xcvbi", 32},
+	{&isaXCVBitmanip, ExtI, "xcvbitmanip", 32},
+	{&isaXCVAlu, ExtI, "xcvalu", 32},
+	{&isaXCVMac, ExtI, "xcvmac", 32},
+	{&isaXCVSimd, ExtI, "xcvsimd", 32},
+	// SiFive vendor extensions
+	{&isaXSfvcp, ExtI, "xsfvcp", 0},
+	{&isaXSfvqmaccdod, ExtI, "xsfvqmaccdod", 0},
+	{&isaXSfvqmaccqoq, ExtI, "xsfvqmaccqoq", 0},
+	{&isaXSfvfnrclipxfqf, ExtF, "xsfvfnrclipxfqf", 0},
+	{&isaXSfcease, ExtI, "xsfcease", 0},
+	{&isaXSiFivecflushdlone, ExtI, "xsifivecflushdlone", 0},
+	{&isaXSiFivecdiscarddlone, ExtI, "xsifivecdiscarddlone", 0},
+	// Ventana vendor extensions
+	{&isaXVentanaCondOps, ExtI, "xventanacondops", 64},
+}
+
+//-----------------------------------------------------------------------------
+// sub-extensions
+
+// zextImplies are the sub-extensions implied by a sub-extension.
+var zextImplies = map[string][]string{
+	"zce":     {"zca", "zcb", "zcmp", "zcmt"}, // and Zcf on RV32 with F (see zextSet)
+	"zcf":     {"zca"},
+	"zcd":     {"zca"},
+	"zcb":     {"zca"},
+	"zcmp":    {"zca"},
+	"zcmt":    {"zca"},
+	"zcmop":   {"zca"},
+	"zicfiss": {"zimop"},
+	"zk":      {"zkn", "zkr", "zkt"},
+	"zkn":     {"zbkb", "zbkc", "zbkx", "zkne", "zknd", "zknh"},
+	"zks":     {"zbkb", "zbkc", "zbkx", "zksed", "zksh"},
+	"zvbb":    {"zvkb"},
+	"zvknhb":  {"zvknha"},
+	"zvkn":    {"zvkned", "zvknhb", "zvkb", "zvkt"},
+	"zvknc":   {"zvkn", "zvbc"},
+	"zvkng":   {"zvkn", "zvkg"},
+	"zvks":    {"zvksed", "zvksh", "zvkb", "zvkt"},
+	"zvksc":   {"zvks", "zvbc"},
+	"zvksg":   {"zvks", "zvkg"},
+}
+
+// zextC are the sub-extensions implied by the C extension.
+var zextC = map[string]bool{
+	"zca": true,
+	"zcf": true,
+	"zcd": true,
+}
+
+// zextOther are the ratified sub-extensions without instructions of their
+// own (or with instructions in the base modules).
+var zextOther = map[string]bool{
+	"zicsr":    true,
+	"zifencei": true,
+	"zicntr":   true,
+	"zihpm":    true,
+	"zmmul":    true,
+	"zaamo":    true,
+	"zalrsc":   true,
+	"ztso":     true,
+}
+
+// zextKnown returns true if a sub-extension is covered by a module, an
+// implication or the other known sub-extensions. Only the standard (z*)
+// and vendor (x*) names are checked.
+func zextKnown(s string) bool {
+	if s == "" || (s[0] != 'z' && s[0] != 'x') {
+		return true
+	}
+	if zextOther[s] || zextC[s] || zextImplies[s] != nil {
+		return true
+	}
+	for _, x := range zextImplies {
+		for _, y := range x {
+			if y == s {
+				return true
 			}
 		}
-		// multiply divide
-		if checkExt(ext, 'm') {
-			mod = append(mod, isaRV32m)
-		}
-		// 32-bit floats
-		if checkExt(ext, 'f') {
-			mod = append(mod, isaRV32f)
-			if mxlen == 32 && cEnable {
-				mod = append(mod, isaRV32fc)
+	}
+	for _, c := range isaConfigs {
+		for _, y := range strings.Split(c.zext, "_") {
+			if y == s {
+				return true
 			}
 		}
-		// 64-bit floats
-		if checkExt(ext, 'd') {
-			mod = append(mod, isaRV32d)
-			if cEnable {
-				mod = append(mod, isaRV32dc)
-			}
+	}
+	return false
+}
+
+// zextSet returns the set of enabled sub-extensions.
+func zextSet(mxlen, ext uint, zext []string) (map[string]bool, error) {
+	z := make(map[string]bool)
+	var add func(s string)
+	add = func(s string) {
+		if z[s] {
+			return
 		}
-		// atomics
-		if checkExt(ext, 'a') {
-			mod = append(mod, isaRV32a)
+		z[s] = true
+		for _, x := range zextImplies[s] {
+			add(x)
 		}
 	}
-	// RV64
-	if mxlen >= 64 {
-		// integer base
-		if checkExt(ext, 'i') {
-			mod = append(mod, isaRV64i)
-			if cEnable {
-				mod = append(mod, isaRV64c)
-			}
-		}
-		// multiply divide
-		if checkExt(ext, 'm') {
-			mod = append(mod, isaRV64m)
+	for _, s := range zext {
+		s = strings.ToLower(s)
+		if !zextKnown(s) {
+			return nil, fmt.Errorf("unknown sub-extension \"%s\"", s)
 		}
-		// 32-bit floats
-		if checkExt(ext, 'f') {
-			mod = append(mod, isaRV64f)
+		add(s)
+	}
+	// C is Zca, plus Zcf (RV32) and Zcd with the floating point extensions
+	if ext&ExtC != 0 {
+		add("zca")
+		if ext&ExtD != 0 {
+			add("zcd")
 		}
-		// 64-bit floats
-		if checkExt(ext, 'd') {
-			mod = append(mod, isaRV64d)
+	}
+	// C and Zce include Zcf on RV32 with F
+	if (ext&ExtC != 0 || z["zce"]) && ext&ExtF != 0 && mxlen == 32 {
+		add("zcf")
+	}
+	// Zcmp and Zcmt reuse the Zcd encodings
+	for _, x := range []string{"zcmp", "zcmt"} {
+		if !z[x] {
+			continue
 		}
-		// atomics
-		if checkExt(ext, 'a') {
-			mod = append(mod, isaRV64a)
+		for _, s := range zext {
+			if strings.ToLower(s) == "zcd" {
+				return nil, fmt.Errorf("zcd is incompatible with %s", x)
+			}
 		}
+		// Zcd implied by C is replaced
+		delete(z, "zcd")
+	}
+	return z, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// ISA is an instruction set
+type ISA struct {
+	mxlen uint            // machine register length
+	ext   uint            // ISA extension bits per CSR misa
+	zext  map[string]bool // enabled sub-extensions
+	zname []string        // sub-extensions named at creation
+	ins16 []*insMeta      // the set of 16-bit instructions in the ISA
+	ins32 []*insMeta      // the set of 32-bit instructions in the ISA
+	jvt   uint            // jump vector table base address (CSR jvt)
+	jmem  *Code           // memory holding the jump vector table (nil if unknown)
+}
+
+func (isa *ISA) String() string {
+	s := fmt.Sprintf("RV%d ext %s", isa.mxlen, fmtExt(isa.ext))
+	if len(isa.zname) != 0 {
+		s += " " + strings.Join(isa.zname, "_")
 	}
-	// RV128
-	if mxlen >= 128 {
-		// TODO
+	return s
+}
+
+// New creates a new RISC-V instruction set. Sub-extensions (e.g. "zcb",
+// "zcmp") are enabled by name.
+func New(mxlen, ext uint, zext ...string) (*ISA, error) {
+	if mxlen != 32 && mxlen != 64 {
+		return nil, fmt.Errorf("%d-bit register length is not supported: %w", mxlen, ErrUnsupportedXLEN)
+	}
+	if ext == 0 {
+		return nil, errors.New("ext 0 invalid, add ISA modules")
+	}
+	z, err := zextSet(mxlen, ext, zext)
+	if err != nil {
+		return nil, err
+	}
+	// build the list of ISA modules
+	mod := []isaModule{}
+	for _, c := range isaConfigs {
+		if c.enabled(mxlen, ext, z) {
+			mod = append(mod, *c.module)
+		}
 	}
 	// create the ISA
 	isa := &ISA{
 		mxlen: mxlen,
+		zext:  z,
 		ins16: make([]*insMeta, 0),
 		ins32: make([]*insMeta, 0),
 	}
+	for _, s := range zext {
+		isa.zname = append(isa.zname, strings.ToLower(s))
+	}
+	sort.Strings(isa.zname)
 	// add the modules
 	isa.add(mod)
 	return isa, nil
@@ -554,4 +1780,9 @@ func (isa *ISA) GetExtensions() uint {
 	return isa.ext
 }
 
+// HasExtension returns true if a sub-extension (e.g. "zcb") is enabled.
+func (isa *ISA) HasExtension(name string) bool {
+	return isa.zext[strings.ToLower(name)]
+}
+
 //-----------------------------------------------------------------------------
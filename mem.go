@@ -0,0 +1,301 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Memory Access
+
+Describe the memory operands of load, store and atomic instructions.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import "fmt"
+
+//-----------------------------------------------------------------------------
+
+// MemAccess describes the memory access performed by an instruction.
+// Atomic accesses must be naturally aligned. Misaligned ordinary loads and
+// stores may be handled by the hardware or emulated by the execution
+// environment, so for them Align is the preferred alignment.
+type MemAccess struct {
+	Load   bool // memory is read
+	Store  bool // memory is written
+	Size   uint // access size in bytes
+	Signed bool // loaded value is sign extended (else zero extended)
+	Float  bool // the data register is a floating point register
+	Atomic bool // atomic access (AMO, LR, SC)
+	Base   Reg  // base address register
+	Offset int  // offset from the base address register
+	Align  uint // natural alignment in bytes
+	// register offset accesses (e.g. CORE-V "cv.lb rd,rs2(rs1)")
+	Indexed bool // the offset is the value of register Index (not Offset)
+	Index   Reg  // offset register
+}
+
+// Addr returns the effective address given the base register value. For
+// an indexed access add the value of the index register.
+func (m *MemAccess) Addr(base uint) uint {
+	return uint(int(base) + m.Offset)
+}
+
+func (m *MemAccess) String() string {
+	op := "load"
+	if m.Atomic && m.Load && m.Store {
+		op = "amo"
+	} else if m.Store {
+		op = "store"
+	}
+	if m.Indexed {
+		return fmt.Sprintf("%s%d %s(%s)", op, m.Size*8, m.Index, m.Base)
+	}
+	return fmt.Sprintf("%s%d %d(%s)", op, m.Size*8, m.Offset, m.Base)
+}
+
+//-----------------------------------------------------------------------------
+// offset/base decoders
+
+// memDecode sets the base register and offset of a memory access, and any
+// other fields that depend on the instruction or the register length.
+type memDecode func(m *MemAccess, ins, mxlen uint)
+
+// memAccess returns the memory access of an instruction (or nil).
+type memAccess func(ins, mxlen uint) *MemAccess
+
+func memTypeI(m *MemAccess, ins, mxlen uint) {
+	imm, rs1, _ := decodeIa(ins)
+	m.Base, m.Offset = Reg{RegX, rs1}, imm
+}
+
+func memTypeS(m *MemAccess, ins, mxlen uint) {
+	imm, _, rs1 := decodeS(ins)
+	m.Base, m.Offset = Reg{RegX, rs1}, imm
+}
+
+func memTypeR(m *MemAccess, ins, mxlen uint) {
+	_, rs1, _, _ := decodeR(ins)
+	m.Base = Reg{RegX, rs1}
+}
+
+func memTypeCSa(m *MemAccess, ins, mxlen uint) {
+	uimm, rs1, _ := decodeCS(ins)
+	m.Base, m.Offset = Reg{RegX, rs1}, int(uimm)
+}
+
+func memTypeCSb(m *MemAccess, ins, mxlen uint) {
+	uimm, rs1, _ := decodeCSa(ins)
+	m.Base, m.Offset = Reg{RegX, rs1}, int(uimm)
+}
+
+func memTypeCSSa(m *MemAccess, ins, mxlen uint) {
+	uimm, _ := decodeCSSa(ins)
+	m.Base, m.Offset = regSP, int(uimm)
+}
+
+func memTypeCSSb(m *MemAccess, ins, mxlen uint) {
+	uimm, _ := decodeCSSb(ins)
+	m.Base, m.Offset = regSP, int(uimm)
+}
+
+func memTypeCSSc(m *MemAccess, ins, mxlen uint) {
+	uimm, _ := decodeCSSc(ins)
+	m.Base, m.Offset = regSP, int(uimm)
+}
+
+func memTypeCLB(m *MemAccess, ins, mxlen uint) {
+	uimm, rs1, _ := decodeCLB(ins)
+	m.Base, m.Offset = Reg{RegX, rs1}, int(uimm)
+}
+
+func memTypeCLH(m *MemAccess, ins, mxlen uint) {
+	uimm, rs1, _ := decodeCLH(ins)
+	m.Base, m.Offset = Reg{RegX, rs1}, int(uimm)
+}
+
+func memTypeCIh(m *MemAccess, ins, mxlen uint) {
+	uimm, _ := decodeCIg(ins)
+	m.Base, m.Offset = regSP, int(uimm)
+}
+
+// memTypeTHa is a T-Head increment after access (at rs1).
+func memTypeTHa(m *MemAccess, ins, mxlen uint) {
+	m.Base = Reg{RegX, bitUnsigned(ins, 19, 15, 0)}
+}
+
+// memTypeTHb is a T-Head increment before access (at rs1 + imm5 << imm2).
+func memTypeTHb(m *MemAccess, ins, mxlen uint) {
+	imm5 := bitSigned(ins, 24, 20)
+	imm2 := bitUnsigned(ins, 26, 25, 0)
+	m.Base, m.Offset = Reg{RegX, bitUnsigned(ins, 19, 15, 0)}, imm5<<imm2
+}
+
+// memTypeTHc is a T-Head register pair access (at rs1 + imm2 << 3/4).
+func memTypeTHc(m *MemAccess, ins, mxlen uint) {
+	imm2 := bitUnsigned(ins, 26, 25, 0)
+	shift := 3 + bitUnsigned(ins, 27, 27, 0)
+	m.Base, m.Offset = Reg{RegX, bitUnsigned(ins, 19, 15, 0)}, int(imm2<<shift)
+}
+
+//-----------------------------------------------------------------------------
+
+// memory operation flags
+const (
+	memLoad   = 1 << iota // memory is read
+	memStore              // memory is written
+	memSigned             // sign extended load
+	memFloat              // floating point data register
+	memAtomic             // atomic access
+)
+
+// memOp describes the memory access for an instruction.
+type memOp struct {
+	flags int       // memory operation flags
+	size  uint      // access size in bytes (0 if set by the decoder)
+	da    memDecode // offset/base decoder
+}
+
+var memOps = map[string]memOp{
+	// integer loads
+	"lb":  {memLoad | memSigned, 1, memTypeI},
+	"lh":  {memLoad | memSigned, 2, memTypeI},
+	"lw":  {memLoad | memSigned, 4, memTypeI},
+	"ld":  {memLoad | memSigned, 8, memTypeI},
+	"lbu": {memLoad, 1, memTypeI},
+	"lhu": {memLoad, 2, memTypeI},
+	"lwu": {memLoad, 4, memTypeI},
+	// integer stores
+	"sb": {memStore, 1, memTypeS},
+	"sh": {memStore, 2, memTypeS},
+	"sw": {memStore, 4, memTypeS},
+	"sd": {memStore, 8, memTypeS},
+	// floating point loads/stores
+	"flw": {memLoad | memFloat, 4, memTypeI},
+	"fld": {memLoad | memFloat, 8, memTypeI},
+	"fsw": {memStore | memFloat, 4, memTypeS},
+	"fsd": {memStore | memFloat, 8, memTypeS},
+	// atomics
+	"lr.w":      {memLoad | memSigned | memAtomic, 4, memTypeR},
+	"sc.w":      {memStore | memAtomic, 4, memTypeR},
+	"amoswap.w": {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"amoadd.w":  {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"amoxor.w":  {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"amoand.w":  {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"amoor.w":   {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"amomin.w":  {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"amomax.w":  {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"amominu.w": {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"amomaxu.w": {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"lr.d":      {memLoad | memSigned | memAtomic, 8, memTypeR},
+	"sc.d":      {memStore | memAtomic, 8, memTypeR},
+	"amoswap.d": {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"amoadd.d":  {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"amoxor.d":  {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"amoand.d":  {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"amoor.d":   {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"amomin.d":  {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"amomax.d":  {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"amominu.d": {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"amomaxu.d": {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	// T-Head update and pair
+	"th.lbia":  {memLoad | memSigned, 1, memTypeTHa},
+	"th.lbib":  {memLoad | memSigned, 1, memTypeTHb},
+	"th.lbuia": {memLoad, 1, memTypeTHa},
+	"th.lbuib": {memLoad, 1, memTypeTHb},
+	"th.lhia":  {memLoad | memSigned, 2, memTypeTHa},
+	"th.lhib":  {memLoad | memSigned, 2, memTypeTHb},
+	"th.lhuia": {memLoad, 2, memTypeTHa},
+	"th.lhuib": {memLoad, 2, memTypeTHb},
+	"th.lwia":  {memLoad | memSigned, 4, memTypeTHa},
+	"th.lwib":  {memLoad | memSigned, 4, memTypeTHb},
+	"th.lwuia": {memLoad, 4, memTypeTHa},
+	"th.lwuib": {memLoad, 4, memTypeTHb},
+	"th.ldia":  {memLoad | memSigned, 8, memTypeTHa},
+	"th.ldib":  {memLoad | memSigned, 8, memTypeTHb},
+	"th.sbia":  {memStore, 1, memTypeTHa},
+	"th.sbib":  {memStore, 1, memTypeTHb},
+	"th.shia":  {memStore, 2, memTypeTHa},
+	"th.shib":  {memStore, 2, memTypeTHb},
+	"th.swia":  {memStore, 4, memTypeTHa},
+	"th.swib":  {memStore, 4, memTypeTHb},
+	"th.sdia":  {memStore, 8, memTypeTHa},
+	"th.sdib":  {memStore, 8, memTypeTHb},
+	"th.lwd":   {memLoad | memSigned, 8, memTypeTHc},
+	"th.lwud":  {memLoad, 8, memTypeTHc},
+	"th.ldd":   {memLoad | memSigned, 16, memTypeTHc},
+	"th.swd":   {memStore, 8, memTypeTHc},
+	"th.sdd":   {memStore, 16, memTypeTHc},
+	// CORE-V post-increment and register offset
+	"cv.lb":  {memLoad | memSigned, 1, memTypeXCV},
+	"cv.lbu": {memLoad, 1, memTypeXCV},
+	"cv.lh":  {memLoad | memSigned, 2, memTypeXCV},
+	"cv.lhu": {memLoad, 2, memTypeXCV},
+	"cv.lw":  {memLoad | memSigned, 4, memTypeXCV},
+	"cv.sb":  {memStore, 1, memTypeXCV},
+	"cv.sh":  {memStore, 2, memTypeXCV},
+	"cv.sw":  {memStore, 4, memTypeXCV},
+	// CORE-V event load
+	"cv.elw": {memLoad | memSigned, 4, memTypeI},
+	// shadow stack
+	"ssamoswap.w": {memLoad | memStore | memSigned | memAtomic, 4, memTypeR},
+	"ssamoswap.d": {memLoad | memStore | memSigned | memAtomic, 8, memTypeR},
+	"sspush":      {memStore, 0, memTypeSSPush},
+	"sspopchk":    {memLoad | memSigned, 0, memTypeSSPop},
+	"c.sspush":    {memStore, 0, memTypeSSPush},
+	"c.sspopchk":  {memLoad | memSigned, 0, memTypeSSPop},
+	// compressed
+	"c.lw":    {memLoad | memSigned, 4, memTypeCSa},
+	"c.sw":    {memStore, 4, memTypeCSa},
+	"c.ld":    {memLoad | memSigned, 8, memTypeCSb},
+	"c.sd":    {memStore, 8, memTypeCSb},
+	"c.flw":   {memLoad | memFloat, 4, memTypeCSa},
+	"c.fsw":   {memStore | memFloat, 4, memTypeCSa},
+	"c.fld":   {memLoad | memFloat, 8, memTypeCSb},
+	"c.fsd":   {memStore | memFloat, 8, memTypeCSb},
+	"c.lwsp":  {memLoad | memSigned, 4, memTypeCSSa},
+	"c.flwsp": {memLoad | memFloat, 4, memTypeCSSa},
+	"c.ldsp":  {memLoad | memSigned, 8, memTypeCIh},
+	"c.fldsp": {memLoad | memFloat, 8, memTypeCIh},
+	"c.swsp":  {memStore, 4, memTypeCSSb},
+	"c.fswsp": {memStore | memFloat, 4, memTypeCSSb},
+	"c.sdsp":  {memStore, 8, memTypeCSSc},
+	"c.fsdsp": {memStore | memFloat, 8, memTypeCSSc},
+	// Zcb
+	"c.lbu": {memLoad, 1, memTypeCLB},
+	"c.lhu": {memLoad, 2, memTypeCLH},
+	"c.lh":  {memLoad | memSigned, 2, memTypeCLH},
+	"c.sb":  {memStore, 1, memTypeCLB},
+	"c.sh":  {memStore, 2, memTypeCLH},
+	// Zcmp push/pop (the register save area)
+	"cm.push":    {memStore, 0, memTypeCMPush},
+	"cm.pop":     {memLoad | memSigned, 0, memTypeCMPop},
+	"cm.popret":  {memLoad | memSigned, 0, memTypeCMPop},
+	"cm.popretz": {memLoad | memSigned, 0, memTypeCMPop},
+}
+
+// memAccess returns the memory access descriptor for an instruction (or nil).
+func (im *insMeta) memAccess(ins, mxlen uint) *MemAccess {
+	if im.md != nil {
+		return im.md(ins, mxlen)
+	}
+	op, ok := memOps[im.id]
+	if !ok {
+		return nil
+	}
+	m := &MemAccess{
+		Load:   op.flags&memLoad != 0,
+		Store:  op.flags&memStore != 0,
+		Size:   op.size,
+		Signed: op.flags&memSigned != 0,
+		Float:  op.flags&memFloat != 0,
+		Atomic: op.flags&memAtomic != 0,
+		Align:  op.size,
+	}
+	op.da(m, ins, mxlen)
+	if m.Size == 0 {
+		// no access (e.g. a reserved register list)
+		return nil
+	}
+	return m
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,1145 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Opcode Tables
+
+Build instruction sets from the upstream instruction data files:
+
+riscv-opcodes extension files (e.g. rv_i, rv64_zba). One instruction per
+line: the mnemonic, the argument names (see arg_lut.csv) and the fixed
+bit ranges (e.g. "31..25=0x20").
+
+riscv-unified-db instruction files (e.g. arch/inst/I/add.yaml). The
+encoding match string, the variable locations and the assembly format.
+
+Instructions with the same encoding as a built-in instruction use the
+built-in disassembly, register usage, memory access and control flow.
+Other instructions are disassembled from their arguments. An instruction
+with a pc relative target argument is a branch (with source registers), a
+call (with rd = ra/t0) or a jump. The memory access of a unified-db
+instruction is taken from its operation (read_memory<N>/write_memory<N>)
+and the offset(base) of its assembly format.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+// instruction arguments
+
+// argKind is the kind of an instruction argument.
+type argKind int
+
+const (
+	argImm    argKind = iota // unsigned immediate
+	argSigned                // signed immediate
+	argTarget                // pc relative target
+	argReg                   // register
+	argCSR                   // control and status register
+	argMask                  // vector mask (vm)
+	argPart                  // part of another argument (e.g. bimm12lo)
+)
+
+// argPiece places the instruction bits msb..lsb at bit "at" of a value.
+type argPiece struct {
+	msb, lsb, at uint
+}
+
+// opcodeArg is an instruction argument.
+type opcodeArg struct {
+	name   string     // argument name (as used in the data file)
+	kind   argKind    // argument kind
+	field  string     // register field name (see regFields)
+	pieces []argPiece // the instruction bits of the argument
+}
+
+// argLayout returns the pieces of a value held in the instruction bits
+// from msb down, e.g. argLayout(12, "5:4|9:6|2|3") for nzuimm[5:4|9:6|2|3].
+func argLayout(msb uint, layout string) []argPiece {
+	var p []argPiece
+	for _, s := range strings.Split(layout, "|") {
+		var hi, lo uint
+		if n, _ := fmt.Sscanf(s, "%d:%d", &hi, &lo); n != 2 {
+			lo = hi
+		}
+		w := hi - lo + 1
+		p = append(p, argPiece{msb, msb + 1 - w, lo})
+		msb -= w
+	}
+	return p
+}
+
+func regArg(field string, msb, lsb uint) opcodeArg {
+	return opcodeArg{kind: argReg, field: field, pieces: []argPiece{{msb, lsb, 0}}}
+}
+
+func immArg(kind argKind, msb uint, layout string) opcodeArg {
+	return opcodeArg{kind: kind, pieces: argLayout(msb, layout)}
+}
+
+// with adds more instruction bits to an argument.
+func (a opcodeArg) with(msb uint, layout string) opcodeArg {
+	a.pieces = append(append([]argPiece{}, a.pieces...), argLayout(msb, layout)...)
+	return a
+}
+
+// opcodeArgs are the riscv-opcodes arguments (arg_lut.csv).
+// Split immediates are held by the "hi" argument.
+var opcodeArgs = map[string]opcodeArg{
+	// registers
+	"rd":        regArg("rd", 11, 7),
+	"rs1":       regArg("rs1", 19, 15),
+	"rs2":       regArg("rs2", 24, 20),
+	"rs3":       regArg("rs3", 31, 27),
+	"vd":        regArg("vd", 11, 7),
+	"vs1":       regArg("vs1", 19, 15),
+	"vs2":       regArg("vs2", 24, 20),
+	"vs3":       regArg("vs3", 11, 7),
+	"rd_p":      regArg("rd0", 4, 2),
+	"rs1_p":     regArg("rs10", 9, 7),
+	"rs2_p":     regArg("rs20", 4, 2),
+	"rd_rs1_p":  regArg("rs10/rd0", 9, 7),
+	"rd_rs1_n0": regArg("rs1/rd!=0", 11, 7),
+	"rd_rs1":    regArg("rs1/rd!=0", 11, 7),
+	"rd_n0":     regArg("rd!=0", 11, 7),
+	"rd_n2":     regArg("rd!={0,2}", 11, 7),
+	"rs1_n0":    regArg("rs1!=0", 11, 7),
+	"c_rs1_n0":  regArg("rs1!=0", 11, 7),
+	"c_rs2_n0":  regArg("rs2!=0", 6, 2),
+	"c_rs2":     regArg("rs2", 6, 2),
+	"vm":        {kind: argMask, pieces: []argPiece{{25, 25, 0}}},
+	"csr":       {kind: argCSR, pieces: []argPiece{{31, 20, 0}}},
+	// immediates
+	"imm12":    immArg(argSigned, 31, "11:0"),
+	"imm12hi":  immArg(argSigned, 31, "11:5").with(11, "4:0"),
+	"imm12lo":  immArg(argPart, 11, "4:0"),
+	"bimm12hi": immArg(argTarget, 31, "12|10:5").with(11, "4:1|11"),
+	"bimm12lo": immArg(argPart, 11, "4:1|11"),
+	"imm20":    immArg(argImm, 31, "19:0"),
+	"jimm20":   immArg(argTarget, 31, "20|10:1|11|19:12"),
+	"shamtq":   immArg(argImm, 26, "6:0"),
+	"shamtd":   immArg(argImm, 25, "5:0"),
+	"shamtw":   immArg(argImm, 24, "4:0"),
+	"shamtw4":  immArg(argImm, 23, "3:0"),
+	"zimm":     immArg(argImm, 19, "4:0"),
+	"zimm5":    immArg(argImm, 19, "4:0"),
+	"simm5":    immArg(argSigned, 19, "4:0"),
+	"zimm10":   immArg(argImm, 29, "9:0"),
+	"zimm11":   immArg(argImm, 30, "10:0"),
+	"zimm6hi":  immArg(argImm, 26, "5").with(19, "4:0"),
+	"zimm6lo":  immArg(argPart, 19, "4:0"),
+	"rm":       immArg(argImm, 14, "2:0"),
+	"fm":       immArg(argImm, 31, "3:0"),
+	"pred":     immArg(argImm, 27, "3:0"),
+	"succ":     immArg(argImm, 23, "3:0"),
+	"aq":       immArg(argImm, 26, "0"),
+	"rl":       immArg(argImm, 25, "0"),
+	"aqrl":     immArg(argImm, 26, "1:0"),
+	"bs":       immArg(argImm, 31, "1:0"),
+	"rnum":     immArg(argImm, 23, "3:0"),
+	"imm2":     immArg(argImm, 21, "1:0"),
+	"imm3":     immArg(argImm, 22, "2:0"),
+	"imm4":     immArg(argImm, 23, "3:0"),
+	"imm5":     immArg(argImm, 24, "4:0"),
+	"imm6":     immArg(argImm, 25, "5:0"),
+	"nf":       immArg(argImm, 31, "2:0"),
+	"wd":       immArg(argImm, 26, "0"),
+	// may-be-operations
+	"mop_r_t_30":     immArg(argImm, 30, "4").with(27, "3:2").with(21, "1:0"),
+	"mop_r_t_27_26":  immArg(argPart, 27, "3:2"),
+	"mop_r_t_21_20":  immArg(argPart, 21, "1:0"),
+	"mop_rr_t_30":    immArg(argImm, 30, "2").with(27, "1:0"),
+	"mop_rr_t_27_26": immArg(argPart, 27, "1:0"),
+	"c_mop_t":        immArg(argImm, 10, "2:0"),
+	// compressed immediates
+	"c_nzuimm10":   immArg(argImm, 12, "5:4|9:6|2|3"),
+	"c_uimm7hi":    immArg(argImm, 12, "5:3").with(6, "2|6"),
+	"c_uimm7lo":    immArg(argPart, 6, "2|6"),
+	"c_uimm8hi":    immArg(argImm, 12, "5:3").with(6, "7:6"),
+	"c_uimm8lo":    immArg(argPart, 6, "7:6"),
+	"c_uimm9hi":    immArg(argImm, 12, "5:4|8").with(6, "7:6"),
+	"c_uimm9lo":    immArg(argPart, 6, "7:6"),
+	"c_nzimm6hi":   immArg(argSigned, 12, "5").with(6, "4:0"),
+	"c_nzimm6lo":   immArg(argPart, 6, "4:0"),
+	"c_imm6hi":     immArg(argSigned, 12, "5").with(6, "4:0"),
+	"c_imm6lo":     immArg(argPart, 6, "4:0"),
+	"c_nzimm10hi":  immArg(argSigned, 12, "9").with(6, "4|6|8:7|5"),
+	"c_nzimm10lo":  immArg(argPart, 6, "4|6|8:7|5"),
+	"c_nzimm18hi":  immArg(argSigned, 12, "17").with(6, "16:12"),
+	"c_nzimm18lo":  immArg(argPart, 6, "16:12"),
+	"c_imm12":      immArg(argTarget, 12, "11|4|9:8|10|6|7|3:1|5"),
+	"c_bimm9hi":    immArg(argTarget, 12, "8|4:3").with(6, "7:6|2:1|5"),
+	"c_bimm9lo":    immArg(argPart, 6, "7:6|2:1|5"),
+	"c_nzuimm5":    immArg(argImm, 6, "4:0"),
+	"c_nzuimm6hi":  immArg(argImm, 12, "5").with(6, "4:0"),
+	"c_nzuimm6lo":  immArg(argPart, 6, "4:0"),
+	"c_uimm8sphi":  immArg(argImm, 12, "5").with(6, "4:2|7:6"),
+	"c_uimm8splo":  immArg(argPart, 6, "4:2|7:6"),
+	"c_uimm9sphi":  immArg(argImm, 12, "5").with(6, "4:3|8:6"),
+	"c_uimm9splo":  immArg(argPart, 6, "4:3|8:6"),
+	"c_uimm10sphi": immArg(argImm, 12, "5").with(6, "4|9:6"),
+	"c_uimm10splo": immArg(argPart, 6, "4|9:6"),
+	"c_uimm8sp_s":  immArg(argImm, 12, "5:2|7:6"),
+	"c_uimm9sp_s":  immArg(argImm, 12, "5:3|8:6"),
+	"c_uimm10sp_s": immArg(argImm, 12, "5:4|9:6"),
+	"c_uimm2":      immArg(argImm, 6, "0|1"),
+	"c_uimm1":      immArg(argImm, 5, "1"),
+	"c_rlist":      immArg(argImm, 7, "3:0"),
+	"c_spimm":      immArg(argImm, 3, "1:0"),
+	"c_index":      immArg(argImm, 9, "7:0"),
+	"c_sreg1":      immArg(argImm, 9, "2:0"),
+	"c_sreg2":      immArg(argImm, 4, "2:0"),
+}
+
+// value returns the value of an argument.
+func (a *opcodeArg) value(ins uint) uint {
+	var x uint
+	for _, p := range a.pieces {
+		x |= bitUnsigned(ins, p.msb, p.lsb, p.at)
+	}
+	return x
+}
+
+// width returns the bit width of the argument value.
+func (a *opcodeArg) width() uint {
+	var n uint
+	for _, p := range a.pieces {
+		if w := p.at + p.msb - p.lsb + 1; w > n {
+			n = w
+		}
+	}
+	return n
+}
+
+// format returns the assembly string of an argument. Targets are reduced
+// to the register length.
+func (a *opcodeArg) format(id string, pc, ins, xlen uint) string {
+	x := a.value(ins)
+	switch a.kind {
+	case argSigned:
+		return fmt.Sprintf("%d", bitSex(int(x), a.width()-1))
+	case argTarget:
+		target := uint(int(pc) + a.signed(ins))
+		if xlen == 32 {
+			target = uint(uint32(target))
+		}
+		return fmt.Sprintf("%x", target)
+	case argReg:
+		return fieldReg(id, a.field, x).String()
+	case argCSR:
+		return csrName(x)
+	case argMask:
+		if x == 0 {
+			return "v0.t"
+		}
+		return ""
+	case argPart:
+		return ""
+	}
+	return fmt.Sprintf("%d", x)
+}
+
+// signed returns the value of a signed or unsigned immediate argument.
+func (a *opcodeArg) signed(ins uint) int {
+	x := a.value(ins)
+	if a.kind == argSigned || a.kind == argTarget {
+		return bitSex(int(x), a.width()-1)
+	}
+	return int(x)
+}
+
+// order returns the assembly operand order of an argument
+// (destination registers, source registers, immediates, mask).
+func (a *opcodeArg) order() int {
+	switch a.kind {
+	case argReg:
+		if regFields[a.field].role&roleRd != 0 {
+			return 0
+		}
+		return 1
+	case argMask:
+		return 3
+	}
+	return 2
+}
+
+//-----------------------------------------------------------------------------
+// instructions
+
+// opcodeIns is an instruction loaded from a data file.
+type opcodeIns struct {
+	id     string      // instruction identifier (lower case mneumonic)
+	defn   string      // the definition (from the data file)
+	n      int         // instruction bit length
+	bits   string      // bit pattern (0, 1 or don't care), msb first
+	args   []opcodeArg // instruction arguments
+	format string      // assembly format (e.g. "xd, imm(xs1)")
+	pseudo bool        // pseudo instruction (a special case of another)
+	imp    string      // imported instruction ("ext::name")
+	mem    *opcodeMem  // memory access (or nil)
+}
+
+// opcodeMem is the memory access of a loaded instruction.
+type opcodeMem struct {
+	access       MemAccess // load/store, size and data register flags
+	base, offset string    // base register and offset argument names
+}
+
+// arg returns the named argument (or nil).
+func (oi *opcodeIns) arg(name string) *opcodeArg {
+	for i := range oi.args {
+		if oi.args[i].name == name {
+			return &oi.args[i]
+		}
+	}
+	return nil
+}
+
+// isIdent returns true if the byte is part of an identifier.
+func isIdent(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// operands returns the operand string of an instruction.
+func (oi *opcodeIns) operands(id string, pc, ins, xlen uint) string {
+	if oi.format == "" {
+		var s [4][]string
+		for i := range oi.args {
+			a := &oi.args[i]
+			if x := a.format(id, pc, ins, xlen); x != "" {
+				s[a.order()] = append(s[a.order()], x)
+			}
+		}
+		return strings.Join(append(append(append(s[0], s[1]...), s[2]...), s[3]...), ",")
+	}
+	// substitute the arguments named in the format
+	var sb strings.Builder
+	f := oi.format
+	for i := 0; i < len(f); {
+		j := i
+		for j < len(f) && isIdent(f[j]) {
+			j++
+		}
+		if j == i {
+			if f[i] != ' ' {
+				sb.WriteByte(f[i])
+			}
+			i++
+			continue
+		}
+		if a := oi.arg(f[i:j]); a != nil {
+			sb.WriteString(a.format(id, pc, ins, xlen))
+		} else {
+			sb.WriteString(f[i:j])
+		}
+		i = j
+	}
+	return strings.Trim(sb.String(), ",")
+}
+
+// builtin returns the built-in instruction with the same encoding (or nil).
+func builtin(id string, n int, val, mask uint) *insMeta {
+	for _, pm := range probe() {
+		for _, im := range pm.ins {
+			if im.id == id && im.n == n && im.val == val && im.mask == mask {
+				return im
+			}
+		}
+	}
+	return nil
+}
+
+// flow returns the control flow decoder of an instruction with a pc
+// relative target argument (or nil).
+func (oi *opcodeIns) flow() flowDecode {
+	var target, rd *opcodeArg
+	sources := false
+	for i := range oi.args {
+		a := &oi.args[i]
+		switch {
+		case a.kind == argTarget:
+			target = a
+		case a.kind != argReg:
+		case regFields[a.field].role&roleRd != 0:
+			rd = a
+		default:
+			sources = true
+		}
+	}
+	if target == nil {
+		return nil
+	}
+	return func(ins uint) (Flow, int) {
+		offset := target.signed(ins)
+		if sources {
+			return FlowBranch, offset
+		}
+		if rd != nil {
+			if r := fieldReg(oi.id, rd.field, rd.value(ins)); r.File == RegX && isLink(r.Num) {
+				return FlowCall, offset
+			}
+		}
+		return FlowJump, offset
+	}
+}
+
+// access returns the memory access decoder of an instruction (or nil).
+func (oi *opcodeIns) access() memAccess {
+	if oi.mem == nil {
+		return nil
+	}
+	base, offset := oi.arg(oi.mem.base), oi.arg(oi.mem.offset)
+	if base == nil || base.kind != argReg {
+		return nil
+	}
+	return func(ins, mxlen uint) *MemAccess {
+		m := oi.mem.access
+		m.Base = fieldReg(oi.id, base.field, base.value(ins))
+		if offset != nil {
+			m.Offset = offset.signed(ins)
+		}
+		return &m
+	}
+}
+
+// meta returns the meta-data of the instruction for a register length.
+func (oi *opcodeIns) meta(xlen uint) *insMeta {
+	val, mask := bits2vm(oi.bits)
+	if im := builtin(oi.id, oi.n, val, mask); im != nil {
+		return im
+	}
+	im := &insMeta{
+		id:   oi.id,
+		name: nameRemap(oi.id),
+		n:    oi.n,
+		val:  val,
+		mask: mask,
+		fd:   oi.flow(),
+		md:   oi.access(),
+	}
+	for _, a := range oi.args {
+		if a.kind == argReg {
+			p := a.pieces[0]
+			im.fields = append(im.fields, fieldPos{a.field, p.msb, p.lsb})
+		}
+	}
+	im.defn = &insDefn{
+		defn: oi.defn,
+		da: func(name string, pc, ins uint) string {
+			if s := oi.operands(oi.id, pc, ins, xlen); s != "" {
+				return name + " " + s
+			}
+			return name
+		},
+	}
+	return im
+}
+
+//-----------------------------------------------------------------------------
+
+// Opcodes is a set of instructions loaded from instruction data files.
+type Opcodes struct {
+	xlen  uint                  // register length
+	args  map[string]opcodeArg  // riscv-opcodes arguments
+	ins   []*opcodeIns          // instructions (in load order)
+	named map[string]*opcodeIns // instructions by "ext::name" (for imports)
+	exts  []string              // extension names
+}
+
+// NewOpcodes returns an empty set of instructions for a register length.
+func NewOpcodes(xlen uint) (*Opcodes, error) {
+	if xlen != 32 && xlen != 64 {
+		return nil, fmt.Errorf("%d-bit register length is not supported: %w", xlen, ErrUnsupportedXLEN)
+	}
+	op := &Opcodes{
+		xlen:  xlen,
+		args:  map[string]opcodeArg{},
+		named: map[string]*opcodeIns{},
+	}
+	for k, v := range opcodeArgs {
+		v.name = k
+		op.args[k] = v
+	}
+	return op, nil
+}
+
+// addExtension records the name of a loaded extension.
+func (op *Opcodes) addExtension(name string) {
+	name = strings.ToLower(name)
+	for _, s := range op.exts {
+		if s == name {
+			return
+		}
+	}
+	op.exts = append(op.exts, name)
+}
+
+//-----------------------------------------------------------------------------
+// riscv-opcodes
+
+// ReadArgLut reads a riscv-opcodes argument table (arg_lut.csv). Arguments
+// that are not known are added as unsigned immediates.
+func (op *Opcodes) ReadArgLut(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		x := strings.Split(line, ",")
+		if len(x) != 3 {
+			return fmt.Errorf("arg_lut:%d: bad argument \"%s\"", n, line)
+		}
+		name := strings.Trim(strings.TrimSpace(x[0]), "\"")
+		msb, err0 := strconv.ParseUint(strings.TrimSpace(x[1]), 10, 5)
+		lsb, err1 := strconv.ParseUint(strings.TrimSpace(x[2]), 10, 5)
+		if err0 != nil || err1 != nil || lsb > msb {
+			return fmt.Errorf("arg_lut:%d: bad bit range \"%s\"", n, line)
+		}
+		if _, ok := op.args[name]; !ok {
+			a := immArg(argImm, uint(msb), fmt.Sprintf("%d:0", msb-lsb))
+			a.name = name
+			op.args[name] = a
+		}
+	}
+	return sc.Err()
+}
+
+// opcodeExt returns the register length (0 for any) and extension name
+// of a riscv-opcodes file name (e.g. "rv64_zba" is 64, "zba").
+func opcodeExt(ext string) (uint, string, error) {
+	x := strings.SplitN(strings.ToLower(ext), "_", 2)
+	if len(x) == 2 {
+		switch x[0] {
+		case "rv":
+			return 0, x[1], nil
+		case "rv32":
+			return 32, x[1], nil
+		case "rv64":
+			return 64, x[1], nil
+		case "rv128":
+			return 128, x[1], nil
+		}
+	}
+	return 0, "", fmt.Errorf("bad extension file name \"%s\"", ext)
+}
+
+// parseRange parses a bit range (e.g. "31..25" or "12").
+func parseRange(s string) (uint, uint, error) {
+	hi, lo, ok := strings.Cut(s, "..")
+	if !ok {
+		lo = hi
+	}
+	msb, err0 := strconv.ParseUint(hi, 10, 5)
+	lsb, err1 := strconv.ParseUint(lo, 10, 5)
+	if err0 != nil || err1 != nil || lsb > msb {
+		return 0, 0, fmt.Errorf("bad bit range \"%s\"", s)
+	}
+	return uint(msb), uint(lsb), nil
+}
+
+// parseOpcode parses a riscv-opcodes instruction ("name arg... hi..lo=val").
+func (op *Opcodes) parseOpcode(f []string) (*opcodeIns, error) {
+	oi := &opcodeIns{
+		id:   strings.ToLower(f[0]),
+		defn: strings.Join(f, " "),
+	}
+	bits := []byte(dontCare(32))
+	top := uint(0) // most significant bit used
+	for _, s := range f[1:] {
+		if r, v, ok := strings.Cut(s, "="); ok {
+			msb, lsb, err := parseRange(r)
+			if err != nil {
+				return nil, err
+			}
+			if msb > top {
+				top = msb
+			}
+			if v == "ignore" {
+				continue
+			}
+			val, err := strconv.ParseUint(v, 0, 32)
+			if err != nil || val>>(msb-lsb+1) != 0 {
+				return nil, fmt.Errorf("bad value \"%s\"", s)
+			}
+			for i := lsb; i <= msb; i++ {
+				bits[31-i] = byte('0' + (val>>(i-lsb))&1)
+			}
+			continue
+		}
+		a, ok := op.args[s]
+		if !ok {
+			return nil, fmt.Errorf("argument not recognised \"%s\"", s)
+		}
+		for _, p := range a.pieces {
+			if p.msb > top {
+				top = p.msb
+			}
+		}
+		oi.args = append(oi.args, a)
+	}
+	oi.n = 32
+	if string(bits[30:]) != "11" {
+		oi.n = 16
+		if top > 15 {
+			return nil, fmt.Errorf("16-bit instruction uses bit %d", top)
+		}
+	}
+	oi.bits = string(bits[32-oi.n:])
+	return oi, nil
+}
+
+// ReadOpcodes reads a riscv-opcodes extension file. The extension is the
+// file name (e.g. "rv_i", "rv64_zba"). Instructions for a different
+// register length are not added, but may be imported by other files.
+func (op *Opcodes) ReadOpcodes(ext string, r io.Reader) error {
+	xlen, name, err := opcodeExt(ext)
+	if err != nil {
+		return err
+	}
+	add := xlen == 0 || xlen == op.xlen
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		var oi *opcodeIns
+		switch f[0] {
+		case "$import":
+			// $import ext::name
+			if len(f) != 2 || !strings.Contains(f[1], "::") {
+				return fmt.Errorf("%s:%d: bad import \"%s\"", ext, n, line)
+			}
+			_, id, _ := strings.Cut(f[1], "::")
+			oi = &opcodeIns{id: id, imp: f[1]}
+		case "$pseudo_op":
+			// $pseudo_op ext::name pseudo_name args...
+			if len(f) < 3 {
+				return fmt.Errorf("%s:%d: bad pseudo op \"%s\"", ext, n, line)
+			}
+			oi, err = op.parseOpcode(f[2:])
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", ext, n, err)
+			}
+			oi.pseudo = true
+		default:
+			oi, err = op.parseOpcode(f)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", ext, n, err)
+			}
+		}
+		if oi.imp == "" && !oi.pseudo {
+			op.named[strings.ToLower(ext)+"::"+oi.id] = oi
+		}
+		if add {
+			op.ins = append(op.ins, oi)
+		}
+	}
+	if add {
+		op.addExtension(name)
+	}
+	return sc.Err()
+}
+
+//-----------------------------------------------------------------------------
+// riscv-unified-db
+
+// udbRegs maps unified-db variable names to register fields. The x* and
+// f* variables name their register file, rd/rs1/rs2/rs3 don't.
+var udbRegs = map[string]string{
+	"xd":  "xd",
+	"xs1": "xs1",
+	"xs2": "xs2",
+	"xs3": "xs3",
+	"rd":  "rd",
+	"rs1": "rs1",
+	"rs2": "rs2",
+	"rs3": "rs3",
+	"fd":  "fd",
+	"fs1": "fs1",
+	"fs2": "fs2",
+	"fs3": "fs3",
+	"vd":  "vd",
+	"vs1": "vs1",
+	"vs2": "vs2",
+	"vs3": "vs3",
+}
+
+// udbCompressed maps register fields to their 3-bit (x8..x15, f8..f15) forms.
+var udbCompressed = map[string]string{
+	"xd":  "xd0",
+	"xs1": "xs10",
+	"xs2": "xs20",
+	"rd":  "rd0",
+	"rs1": "rs10",
+	"rs2": "rs20",
+	"fd":  "fd0",
+	"fs2": "fs20",
+}
+
+// udbArg returns an instruction argument from a unified-db variable.
+func udbArg(v map[string]interface{}) (opcodeArg, error) {
+	name, _ := v["name"].(string)
+	loc, _ := v["location"].(string)
+	a := opcodeArg{name: name, kind: argImm}
+	if name == "" || loc == "" {
+		return a, fmt.Errorf("bad variable \"%s\" location \"%s\"", name, loc)
+	}
+	shift, _ := strconv.ParseUint(yamlString(v["left_shift"]), 10, 5)
+	// bit ranges (msb first) are concatenated
+	var w uint
+	for _, s := range strings.Split(loc, "|") {
+		msb, lsb, err := parseRange(strings.Replace(s, "-", "..", 1))
+		if err != nil {
+			return a, err
+		}
+		a.pieces = append(a.pieces, argPiece{msb, lsb, 0})
+		w += msb - lsb + 1
+	}
+	for i := range a.pieces {
+		p := &a.pieces[i]
+		w -= p.msb - p.lsb + 1
+		p.at = w + uint(shift)
+	}
+	if field, ok := udbRegs[name]; ok {
+		if a.width() == 3 {
+			field = udbCompressed[field]
+		}
+		if field == "" || len(a.pieces) != 1 {
+			return a, fmt.Errorf("bad register variable \"%s\" location \"%s\"", name, loc)
+		}
+		a.kind, a.field = argReg, field
+	} else if name == "vm" {
+		a.kind = argMask
+	} else if name == "csr" {
+		a.kind = argCSR
+	} else if yamlString(v["sign_extend"]) == "true" {
+		a.kind = argSigned
+		if shift == 1 {
+			// a halfword aligned branch/jump offset
+			a.kind = argTarget
+		}
+	}
+	return a, nil
+}
+
+// udbMem returns the memory access of an instruction from its operation
+// and assembly format (or nil).
+func udbMem(operation, format string, args []opcodeArg) *opcodeMem {
+	op := "read_memory"
+	i := strings.Index(operation, op)
+	if i < 0 {
+		op = "write_memory"
+		i = strings.Index(operation, op)
+	}
+	if i < 0 {
+		return nil
+	}
+	// read_memory<N>, read_memory_aligned<N>
+	var bits uint
+	s := strings.TrimPrefix(operation[i+len(op):], "_aligned")
+	if n, _ := fmt.Sscanf(s, "<%d>", &bits); n != 1 || bits == 0 || bits%8 != 0 {
+		return nil
+	}
+	// offset(base)
+	j := strings.IndexByte(format, '(')
+	k := strings.IndexByte(format, ')')
+	if j < 0 || k < j {
+		return nil
+	}
+	m := &opcodeMem{
+		base:   strings.TrimSpace(format[j+1 : k]),
+		offset: strings.TrimSpace(format[strings.LastIndexAny(format[:j], ", ")+1 : j]),
+	}
+	m.access = MemAccess{
+		Load:   op == "read_memory",
+		Store:  op == "write_memory",
+		Size:   bits / 8,
+		Signed: strings.Contains(operation[strings.LastIndexByte(operation[:i], '\n')+1:i], "sext("),
+		Align:  bits / 8,
+	}
+	for _, a := range args {
+		if a.kind == argReg && a.name[0] == 'f' {
+			m.access.Float = true
+		}
+	}
+	return m
+}
+
+// udbDefinedBy returns the extensions required by a definedBy value. The
+// value is an extension name, a mapping with a "name" (and a version), or a
+// mapping with one "extension", "allOf", "anyOf" or "oneOf" key. A choice
+// of several extensions doesn't require any one of them.
+func udbDefinedBy(v interface{}) ([]string, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if x == "" {
+			break
+		}
+		return []string{x}, nil
+	case map[string]interface{}:
+		if name, ok := x["name"]; ok {
+			return udbDefinedBy(name)
+		}
+		if len(x) != 1 {
+			break
+		}
+		for k, y := range x {
+			if k == "extension" {
+				return udbDefinedBy(y)
+			}
+			seq, ok := y.([]interface{})
+			if !ok || len(seq) == 0 || (k != "allOf" && k != "anyOf" && k != "oneOf") {
+				break
+			}
+			var exts []string
+			for _, z := range seq {
+				e, err := udbDefinedBy(z)
+				if err != nil {
+					return nil, err
+				}
+				exts = append(exts, e...)
+			}
+			if k != "allOf" && len(seq) != 1 {
+				return nil, nil
+			}
+			return exts, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported definedBy \"%v\"", v)
+}
+
+// ReadUDB reads a riscv-unified-db instruction file. Instructions that are
+// not defined for the register length are ignored.
+func (op *Opcodes) ReadUDB(r io.Reader) error {
+	y, err := yamlParse(r)
+	if err != nil {
+		return err
+	}
+	name, _ := y["name"].(string)
+	if name == "" {
+		return fmt.Errorf("instruction name not found")
+	}
+	if base := yamlString(y["base"]); base != "" && base != fmt.Sprintf("%d", op.xlen) {
+		return nil
+	}
+	enc, _ := y["encoding"].(map[string]interface{})
+	if x, ok := enc[fmt.Sprintf("RV%d", op.xlen)].(map[string]interface{}); ok {
+		enc = x
+	}
+	match, _ := enc["match"].(string)
+	if match == "" {
+		if enc["RV32"] != nil || enc["RV64"] != nil {
+			// not defined for this register length
+			return nil
+		}
+		return fmt.Errorf("%s: encoding match not found", name)
+	}
+	if (len(match) != 16 && len(match) != 32) || strings.Trim(match, "01-") != "" {
+		return fmt.Errorf("%s: bad encoding match \"%s\"", name, match)
+	}
+	oi := &opcodeIns{
+		id:     strings.ToLower(name),
+		defn:   match + " " + name,
+		n:      len(match),
+		bits:   match,
+		format: yamlString(y["assembly"]),
+	}
+	vars, _ := enc["variables"].([]interface{})
+	for _, x := range vars {
+		v, _ := x.(map[string]interface{})
+		a, err := udbArg(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if a.pieces[0].msb >= uint(oi.n) {
+			return fmt.Errorf("%s: variable \"%s\" is outside the instruction", name, a.name)
+		}
+		oi.args = append(oi.args, a)
+	}
+	oi.mem = udbMem(yamlString(y["operation()"]), oi.format, oi.args)
+	exts, err := udbDefinedBy(y["definedBy"])
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	op.ins = append(op.ins, oi)
+	for _, ext := range exts {
+		op.addExtension(ext)
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// YAML (the subset used by the riscv-unified-db instruction files)
+//
+// A single document of block mappings and block sequences. The scalars are
+// plain or quoted (a plain scalar may continue on more indented lines),
+// literal (|) or folded (>) block scalars, or single line flow sequences of
+// scalars (e.g. "anyOf: [Zbb, Zbkb]"). Flow mappings, anchors, aliases,
+// tags and multiple documents are errors.
+
+// yamlLine is a non-empty line of a YAML file.
+type yamlLine struct {
+	indent int    // leading spaces
+	text   string // line text (without the indent)
+}
+
+// yamlKey splits a "key: value" line.
+func yamlKey(s string) (string, string, bool) {
+	if s == "" || strings.ContainsRune("\"'[{", rune(s[0])) {
+		return "", "", false
+	}
+	if k, v, ok := strings.Cut(s, ": "); ok {
+		return k, strings.TrimSpace(v), true
+	}
+	if strings.HasSuffix(s, ":") {
+		return s[:len(s)-1], "", true
+	}
+	return "", "", false
+}
+
+// yamlScalar returns the value of a plain or quoted scalar.
+func yamlScalar(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') {
+		if i := strings.LastIndexByte(s, s[0]); i > 0 {
+			return s[1:i]
+		}
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// yamlValue returns the value of a scalar or a flow sequence of scalars.
+func yamlValue(s string) (interface{}, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch s[0] {
+	case '[':
+		i := strings.IndexByte(s, ']')
+		if i < 0 || strings.ContainsAny(s[1:i], "[{") || yamlScalar(s[i+1:]) != "" {
+			return nil, fmt.Errorf("unsupported YAML flow sequence \"%s\"", s)
+		}
+		seq := []interface{}{}
+		if x := strings.TrimSpace(s[1:i]); x != "" {
+			for _, v := range strings.Split(x, ",") {
+				seq = append(seq, yamlScalar(v))
+			}
+		}
+		return seq, nil
+	case '{':
+		return nil, fmt.Errorf("unsupported YAML flow mapping \"%s\"", s)
+	case '&', '*', '!':
+		return nil, fmt.Errorf("unsupported YAML anchor, alias or tag \"%s\"", s)
+	}
+	return yamlScalar(s), nil
+}
+
+// yamlString returns a scalar value (or "").
+func yamlString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// yamlItem returns true if the line is a block sequence item.
+func yamlItem(l yamlLine) bool {
+	return l.text == "-" || strings.HasPrefix(l.text, "- ")
+}
+
+// yamlBlock parses the block mapping or sequence starting at line i.
+// It returns the value and the index of the following line.
+func yamlBlock(lines []yamlLine, i int) (interface{}, int, error) {
+	indent := lines[i].indent
+	if yamlItem(lines[i]) {
+		// block sequence
+		seq := []interface{}{}
+		for i < len(lines) && lines[i].indent == indent && yamlItem(lines[i]) {
+			text := lines[i].text
+			item := strings.TrimLeft(text[1:], " ")
+			var v interface{}
+			var err error
+			switch _, _, ok := yamlKey(item); {
+			case item == "":
+				i++
+				if i < len(lines) && lines[i].indent > indent {
+					v, i, err = yamlBlock(lines, i)
+				}
+			case ok:
+				// a mapping starting on the item line
+				lines[i] = yamlLine{indent + len(text) - len(item), item}
+				v, i, err = yamlBlock(lines, i)
+			default:
+				v, err = yamlValue(item)
+				i++
+			}
+			if err != nil {
+				return nil, i, err
+			}
+			seq = append(seq, v)
+		}
+		return seq, i, nil
+	}
+	// block mapping
+	m := map[string]interface{}{}
+	for i < len(lines) && lines[i].indent == indent {
+		k, v, ok := yamlKey(lines[i].text)
+		if !ok {
+			break
+		}
+		k = yamlScalar(k)
+		if k == "<<" {
+			return nil, i, fmt.Errorf("unsupported YAML merge key \"%s\"", lines[i].text)
+		}
+		if _, ok := m[k]; ok {
+			return nil, i, fmt.Errorf("duplicate YAML key \"%s\"", k)
+		}
+		i++
+		nested := i < len(lines) && (lines[i].indent > indent ||
+			(lines[i].indent == indent && yamlItem(lines[i])))
+		var err error
+		switch {
+		case v == "" && nested:
+			m[k], i, err = yamlBlock(lines, i)
+		case strings.HasPrefix(v, "|") || strings.HasPrefix(v, ">"):
+			// block scalar
+			var s []string
+			for i < len(lines) && lines[i].indent > indent {
+				s = append(s, lines[i].text)
+				i++
+			}
+			m[k] = strings.Join(s, "\n")
+		default:
+			// a plain scalar continues on the more indented lines
+			s := []string{v}
+			for i < len(lines) && lines[i].indent > indent {
+				s = append(s, lines[i].text)
+				i++
+			}
+			m[k], err = yamlValue(strings.Join(s, " "))
+		}
+		if err != nil {
+			return nil, i, err
+		}
+	}
+	return m, i, nil
+}
+
+// yamlParse parses a YAML file with a top level mapping.
+func yamlParse(r io.Reader) (map[string]interface{}, error) {
+	var lines []yamlLine
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		s := strings.TrimRight(sc.Text(), " \t\r")
+		t := strings.TrimLeft(s, " ")
+		if t == "" || t[0] == '#' {
+			continue
+		}
+		if s == "---" || s == "..." || strings.HasPrefix(s, "--- ") {
+			if s == "---" && len(lines) == 0 {
+				// start of the document
+				continue
+			}
+			return nil, fmt.Errorf("unsupported YAML document marker \"%s\"", s)
+		}
+		if s[0] == '%' {
+			return nil, fmt.Errorf("unsupported YAML directive \"%s\"", s)
+		}
+		lines = append(lines, yamlLine{len(s) - len(t), t})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty YAML file")
+	}
+	v, i, err := yamlBlock(lines, 0)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok || i != len(lines) {
+		return nil, fmt.Errorf("bad YAML line \"%s\"", lines[i%len(lines)].text)
+	}
+	return m, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// meta returns the meta-data of the loaded instructions (pseudo
+// instructions are looked up first).
+func (op *Opcodes) meta() ([]*insMeta, []*insMeta, error) {
+	var ins16, ins32 []*insMeta
+	for _, pseudo := range []bool{true, false} {
+		for _, oi := range op.ins {
+			if oi.pseudo != pseudo {
+				continue
+			}
+			if oi.imp != "" {
+				x, ok := op.named[strings.ToLower(oi.imp)]
+				if !ok {
+					return nil, nil, fmt.Errorf("unresolved import \"%s\"", oi.imp)
+				}
+				oi = x
+			}
+			im := oi.meta(op.xlen)
+			if im.n == 16 {
+				ins16 = append(ins16, im)
+			} else {
+				ins32 = append(ins32, im)
+			}
+		}
+	}
+	return ins16, ins32, nil
+}
+
+// AddOpcodes adds a set of loaded instructions to the ISA. They take
+// precedence over the instructions already in the ISA.
+func (isa *ISA) AddOpcodes(op *Opcodes) error {
+	if op.xlen != isa.mxlen {
+		return fmt.Errorf("%d-bit instructions for a %d-bit ISA: %w", op.xlen, isa.mxlen, ErrUnsupportedXLEN)
+	}
+	ins16, ins32, err := op.meta()
+	if err != nil {
+		return err
+	}
+	isa.ins16 = append(ins16, isa.ins16...)
+	isa.ins32 = append(ins32, isa.ins32...)
+	for _, s := range op.exts {
+		isa.addExtension(s)
+	}
+	return nil
+}
+
+// ISA returns an instruction set containing only the loaded instructions.
+func (op *Opcodes) ISA() (*ISA, error) {
+	isa := &ISA{
+		mxlen: op.xlen,
+		zext:  map[string]bool{},
+		ins16: make([]*insMeta, 0),
+		ins32: make([]*insMeta, 0),
+	}
+	if err := isa.AddOpcodes(op); err != nil {
+		return nil, err
+	}
+	return isa, nil
+}
+
+//-----------------------------------------------------------------------------
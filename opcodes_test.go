@@ -0,0 +1,477 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Opcode Tables Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+// riscv-opcodes extension files (excerpts)
+
+const rvI = `
+add     rd rs1 rs2 31..25=0  14..12=0 6..2=0x0C 1..0=3
+lw      rd rs1       imm12 14..12=2 6..2=0x00 1..0=3
+beq     bimm12hi rs1 rs2 bimm12lo 14..12=0 6..2=0x18 1..0=3
+jal     rd jimm20                          6..2=0x1b 1..0=3
+csrrs   rd rs1 csr 14..12=2 6..2=0x1C 1..0=3
+$pseudo_op rv64_i::slli slli rd rs1 shamtw 31..25=0 14..12=1 6..2=0x04 1..0=3
+`
+
+const rvC = `
+c.lw rd_p rs1_p c_uimm7lo c_uimm7hi 1..0=0 15..13=2
+# reserved quadrant 0 encoding used as a test instruction
+c.acme rd_p rs1_p c_uimm7lo c_uimm7hi 1..0=0 15..13=4
+`
+
+const rv64Zba = `
+add.uw      rd rs1 rs2 31..25=4  14..12=0 6..2=0x0E 1..0=3
+slli.uw     rd rs1 31..26=2 shamtd 14..12=1 6..2=0x06 1..0=3
+`
+
+const rv32Zba = `
+sh1add.uw   rd rs1 rs2 31..25=16 14..12=2 6..2=0x0E 1..0=3
+`
+
+const rvZbb = `
+andn    rd rs1 rs2 31..25=32 14..12=7 6..2=0x0C 1..0=3
+`
+
+const rvZbkb = `
+$import rv_zbb::andn
+`
+
+const rvV = `
+vadd.vv 31..26=0x00 vm vs2 vs1 14..12=0x0 vd 6..0=0x57
+vadd.vi 31..26=0x00 vm vs2 simm5 14..12=0x3 vd 6..0=0x57
+`
+
+const rvZfa = `
+fminm.s  rd rs1 rs2 31..27=0x05 14..12=2 26..25=0 6..2=0x14 1..0=3
+`
+
+func Test_Opcodes(t *testing.T) {
+	op, err := NewOpcodes(64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []struct {
+		ext, src string
+	}{
+		{"rv_i", rvI},
+		{"rv_c", rvC},
+		{"rv64_zba", rv64Zba},
+		{"rv32_zba", rv32Zba},
+		{"rv_zbkb", rvZbkb},
+		{"rv_zbb", rvZbb},
+		{"rv_v", rvV},
+		{"rv_zfa", rvZfa},
+	} {
+		if err := op.ReadOpcodes(f.ext, strings.NewReader(f.src)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	isa, err := op.ISA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		ins        uint
+		da         string
+		uses, defs string
+	}{
+		// built-in
+		{0x00c58533, "add a0,a1,a2", "{a1,a2}", "{a0}"},
+		{0xffc5a503, "lw a0,-4(a1)", "{a1}", "{a0}"},
+		{0x00b50463, "beq a0,a1,8", "{a0,a1}", "{}"},
+		{0x008000ef, "jal ra,8", "{}", "{ra}"},
+		{0x30002573, "csrr a0,mstatus", "{mstatus}", "{a0}"},
+		{0x00359513, "slli a0,a1,3", "{a1}", "{a0}"},
+		{0x41c8, "lw a0,4(a1)", "{a1}", "{a0}"},
+		// loaded
+		{0x81c8, "acme a0,a1,4", "{a1}", "{a0}"},
+		{0x08c5853b, "add.uw a0,a1,a2", "{a1,a2}", "{a0}"},
+		{0x0a35951b, "slli.uw a0,a1,35", "{a1}", "{a0}"},
+		{0x40c5f533, "andn a0,a1,a2", "{a1,a2}", "{a0}"},
+		{0x002180d7, "vadd.vv v1,v2,v3,v0.t", "{v3,v2}", "{v1}"},
+		{0x022fb0d7, "vadd.vi v1,v2,-1", "{v2}", "{v1}"},
+		{0x28c5a553, "fminm.s fa0,fa1,fa2", "{fa1,fa2}", "{fa0,fflags}"},
+		// not loaded (rv32 only)
+		{0x20c5a53b, "illegal", "{}", "{}"},
+	} {
+		da := isa.Disassemble(0, v.ins)
+		if da.Assembly != v.da || da.Uses.String() != v.uses || da.Defs.String() != v.defs {
+			t.Errorf("%08x: \"%s\" uses %s defs %s (expected \"%s\" %s %s)",
+				v.ins, da.Assembly, da.Uses, da.Defs, v.da, v.uses, v.defs)
+		}
+	}
+	for _, x := range []string{"zba", "zbb", "zbkb", "zfa"} {
+		if !isa.HasExtension(x) {
+			t.Errorf("%s: %s not enabled", isa, x)
+		}
+	}
+	if isa.GetExtensions() != ExtI|ExtC|ExtV {
+		t.Errorf("%s: bad extensions", isa)
+	}
+}
+
+func Test_OpcodesTarget(t *testing.T) {
+	// a custom-0 branch to a negative target address
+	const src = "acme.bz rs1 bimm12hi bimm12lo 24..20=0 14..12=0 6..2=0x02 1..0=3"
+	for _, v := range []struct {
+		xlen uint
+		da   string
+	}{
+		{32, "acme.bz a0,fffffff8"},
+		{64, "acme.bz a0,fffffffffffffff8"},
+	} {
+		op, err := NewOpcodes(v.xlen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := op.ReadOpcodes("rv_acme", strings.NewReader(src)); err != nil {
+			t.Fatal(err)
+		}
+		isa, err := op.ISA()
+		if err != nil {
+			t.Fatal(err)
+		}
+		da := isa.Disassemble(0, 0xfe050c8b)
+		if da.Assembly != v.da || da.Flow != FlowBranch || da.Target != isa.addr(^uint(7)) {
+			t.Errorf("rv%d: \"%s\" flow %s target %x (expected \"%s\")", v.xlen, da.Assembly, da.Flow, da.Target, v.da)
+		}
+	}
+}
+
+func Test_OpcodesErrors(t *testing.T) {
+	for _, v := range []struct {
+		ext, src string
+	}{
+		{"zba", "add.uw rd rs1 rs2 31..25=4 14..12=0 6..2=0x0E 1..0=3"},
+		{"rv_i", "add rd rs1 rs2 31..25=0 14..12=8 6..2=0x0C 1..0=3"},
+		{"rv_i", "add rd rs1 foo 31..25=0 14..12=0 6..2=0x0C 1..0=3"},
+		{"rv_i", "add rd rs1 rs2 31..25=0 14..12=0 6..2=0x0C 1..0=3 2..5=0"},
+		{"rv_c", "c.acme rd rs1_p c_uimm7lo c_uimm7hi 1..0=0 15..13=4 16=1"},
+		{"rv_i", "$pseudo_op rv_i::add"},
+	} {
+		op, _ := NewOpcodes(32)
+		if err := op.ReadOpcodes(v.ext, strings.NewReader(v.src)); err == nil {
+			t.Errorf("%s \"%s\": expected an error", v.ext, v.src)
+		}
+	}
+	// unresolved import
+	op, _ := NewOpcodes(32)
+	if err := op.ReadOpcodes("rv_zbkb", strings.NewReader(rvZbkb)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := op.ISA(); err == nil {
+		t.Error("expected an unresolved import error")
+	}
+	// register length
+	if _, err := NewOpcodes(128); err == nil {
+		t.Error("expected a register length error")
+	}
+	isa, _ := Parse("rv64gc")
+	if err := isa.AddOpcodes(op); err == nil {
+		t.Error("expected a register length error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+// riscv-opcodes argument table (excerpt)
+
+const argLut = `
+"rd", 11, 7
+"acc", 27, 25
+`
+
+func Test_ArgLut(t *testing.T) {
+	op, _ := NewOpcodes(32)
+	if err := op.ReadArgLut(strings.NewReader(argLut)); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.ReadOpcodes("rv_xacme", strings.NewReader("acme.mac rd rs1 rs2 acc 31..28=0 14..12=0 6..2=0x02 1..0=3")); err != nil {
+		t.Fatal(err)
+	}
+	isa, _ := Parse("rv32imc")
+	if err := isa.AddOpcodes(op); err != nil {
+		t.Fatal(err)
+	}
+	da := isa.Disassemble(0, 0x0ac5850b)
+	if da.Assembly != "acme.mac a0,a1,a2,5" {
+		t.Errorf("\"%s\" (expected \"acme.mac a0,a1,a2,5\")", da.Assembly)
+	}
+	if !isa.HasExtension("xacme") {
+		t.Errorf("%s: xacme not enabled", isa)
+	}
+	if err := op.ReadArgLut(strings.NewReader(`"acc", 25, 27`)); err == nil {
+		t.Error("expected a bad bit range error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+// riscv-unified-db instruction files (excerpts)
+
+const udbAddUw = `
+# yaml-language-server: $schema=../../../schemas/inst_schema.json
+
+$schema: "inst_schema.json#"
+kind: instruction
+name: add.uw
+long_name: Add unsigned word
+description: |
+  This instruction performs an XLEN-wide addition between rs2 and the
+  zero-extended least-significant word of rs1.
+definedBy: Zba
+base: 64
+assembly: xd, xs1, xs2
+encoding:
+  match: 0000100----------000-----0111011
+  variables:
+    - name: xs2
+      location: 24-20
+    - name: xs1
+      location: 19-15
+    - name: xd
+      location: 11-7
+access:
+  s: always
+  u: always
+operation(): |
+  X[xd] = X[xs2] + X[xs1][31:0];
+`
+
+const udbAcmeBr = `
+name: acme.br
+definedBy: Xacme
+assembly: xs1, xs2, imm
+encoding:
+  match: -----------------100-----0001011
+  variables:
+  - name: imm
+    location: 31|7|30-25|11-8
+    left_shift: 1
+    sign_extend: true
+  - name: xs2
+    location: 24-20
+  - name: xs1
+    location: 19-15
+`
+
+const udbAcmeLd = `
+name: acme.ld
+definedBy: Xacme
+assembly: xd, imm(xs1)
+encoding:
+  RV32:
+    match: -----------------010-----0101011
+    variables:
+    - name: imm
+      location: 31-20
+      sign_extend: true
+    - name: xs1
+      location: 19-15
+    - name: xd
+      location: 11-7
+operation(): |
+  XReg virtual_address = X[xs1] + $signed(imm);
+  X[xd] = sext(read_memory<32>(virtual_address, $encoding), 32);
+`
+
+const udbAcmeSt = `
+name: acme.st
+definedBy: Xacme
+assembly: xs2, imm(xs1)
+encoding:
+  match: -----------------001-----0101011
+  variables:
+  - name: imm
+    location: 31-25|11-7
+    sign_extend: true
+  - name: xs2
+    location: 24-20
+  - name: xs1
+    location: 19-15
+operation(): |
+  XReg virtual_address = X[xs1] + $signed(imm);
+  write_memory<16>(virtual_address, X[xs2][15:0], $encoding);
+`
+
+const udbAcmeJal = `
+name: acme.jal
+definedBy:
+  extension:
+    name: Xacme
+    version: ">= 1.0"
+assembly: xd, imm
+encoding:
+  match: -------------------------1111011
+  variables:
+  - name: imm
+    location: 31|19-12|20|30-21
+    left_shift: 1
+    sign_extend: true
+  - name: xd
+    location: 11-7
+`
+
+const udbCAcme = `
+name: c.acme
+definedBy: Xacme
+assembly: xd, xs1
+encoding:
+  match: 100-----------00
+  variables:
+  - name: xd
+    location: 4-2
+  - name: xs1
+    location: 9-7
+`
+
+// a floating point compare with an integer destination
+const udbFleqS = `
+name: fleq.s
+definedBy: Zfa
+assembly: xd, fs1, fs2
+encoding:
+  match: 1010000----------100-----1010011
+  variables:
+  - name: fs2
+    location: 24-20
+  - name: fs1
+    location: 19-15
+  - name: xd
+    location: 11-7
+`
+
+// the layout of the riscv-unified-db arch/inst/B/andn.yaml file
+const udbAndn = `
+# yaml-language-server: $schema=../../../schemas/inst_schema.json
+
+$schema: "inst_schema.json#"
+kind: instruction
+name: andn
+long_name: AND with inverted operand
+description: |
+  This instruction performs the bitwise logical AND operation between
+  xs1 and the bitwise inversion of xs2.
+definedBy:
+  anyOf: [Zbb, Zbkb]
+assembly: xd, xs1, xs2
+encoding:
+  match: 0100000----------111-----0110011
+  variables:
+    - name: xs2
+      location: 24-20
+    - name: xs1
+      location: 19-15
+    - name: xd
+      location: 11-7
+access:
+  s: always
+  u: always
+  vs: always
+  vu: always
+data_independent_timing: true
+operation(): |
+  if (implemented?(ExtensionName::B) && (CSR[misa].B == 1'b0)) {
+    raise (ExceptionCode::IllegalInstruction, mode(), $encoding);
+  }
+
+  X[xd] = X[xs2] & ~X[xs1];
+`
+
+func Test_UDB(t *testing.T) {
+	op32, _ := NewOpcodes(32)
+	op64, _ := NewOpcodes(64)
+	for _, s := range []string{udbAddUw, udbAcmeBr, udbAcmeLd, udbAcmeSt, udbAcmeJal, udbCAcme, udbAndn, udbFleqS} {
+		for _, op := range []*Opcodes{op32, op64} {
+			if err := op.ReadUDB(strings.NewReader(s)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := op32.ReadUDB(strings.NewReader(strings.Replace(udbCAcme, "100---", "100-", 1))); err == nil {
+		t.Error("expected a bad encoding match error")
+	}
+	for _, v := range []struct {
+		op         *Opcodes
+		ins        uint
+		da         string
+		uses, defs string
+		flow       Flow
+		target     uint
+		mem        string
+	}{
+		{op64, 0x08c5853b, "add.uw a0,a1,a2", "{a1,a2}", "{a0}", FlowNext, 0, "<nil>"},
+		{op32, 0x08c5853b, "illegal", "{}", "{}", FlowNext, 0, "<nil>"},
+		{op32, 0xfeb54c8b, "acme.br a0,a1,ff8", "{a0,a1}", "{}", FlowBranch, 0xff8, "<nil>"},
+		{op32, 0x008000fb, "acme.jal ra,1008", "{}", "{ra}", FlowCall, 0x1008, "<nil>"},
+		{op32, 0x0080007b, "acme.jal zero,1008", "{}", "{}", FlowJump, 0x1008, "<nil>"},
+		{op32, 0xffc5a52b, "acme.ld a0,-4(a1)", "{a1}", "{a0}", FlowNext, 0, "load32 -4(a1)"},
+		{op64, 0xffc5a52b, "illegal", "{}", "{}", FlowNext, 0, "<nil>"},
+		{op64, 0xfea59e2b, "acme.st a0,-4(a1)", "{a1,a0}", "{}", FlowNext, 0, "store16 -4(a1)"},
+		{op32, 0x8188, "acme a0,a1", "{a1}", "{a0}", FlowNext, 0, "<nil>"},
+		{op64, 0x40c5f533, "andn a0,a1,a2", "{a1,a2}", "{a0}", FlowNext, 0, "<nil>"},
+		{op32, 0xa020c553, "fleq.s a0,ft1,ft2", "{ft1,ft2}", "{a0,fflags}", FlowNext, 0, "<nil>"},
+	} {
+		isa, err := v.op.ISA()
+		if err != nil {
+			t.Fatal(err)
+		}
+		da := isa.Disassemble(0x1000, v.ins)
+		mem := fmt.Sprintf("%v", da.Mem)
+		if da.Assembly != v.da || da.Uses.String() != v.uses || da.Defs.String() != v.defs {
+			t.Errorf("%08x: \"%s\" uses %s defs %s (expected \"%s\" %s %s)",
+				v.ins, da.Assembly, da.Uses, da.Defs, v.da, v.uses, v.defs)
+		}
+		if da.Flow != v.flow || da.Target != v.target || mem != v.mem {
+			t.Errorf("%s: flow %s target %x mem %s (expected %s %x %s)", da, da.Flow, da.Target, mem, v.flow, v.target, v.mem)
+		}
+	}
+	isa, _ := op32.ISA()
+	if !isa.HasExtension("xacme") || isa.HasExtension("zbb") || isa.HasExtension("zbkb") {
+		t.Errorf("%s: bad extensions", isa)
+	}
+	// the control flow graph follows a loaded branch
+	g := NewCFG(isa, testCode(0x1000, 0x00b5400b), []uint{0x1000}) // acme.br a0,a1,1000
+	if len(g.Edges) != 1 || g.Edges[0] != (Edge{0x1000, 0x1000, EdgeTaken}) {
+		t.Errorf("edges %v (expected a self loop)", g.Edges)
+	}
+}
+
+func Test_UDBErrors(t *testing.T) {
+	for _, s := range []string{
+		// flow mapping
+		"name: acme\nencoding: {match: 0000000----------000-----0001011}\n",
+		// anchor and alias
+		"name: acme\nencoding: &enc\n  match: 0000000----------000-----0001011\n",
+		"name: acme\nencoding: *enc\n",
+		// merge key
+		"name: acme\nencoding:\n  <<: x\n",
+		// multiple documents
+		"name: acme\n---\nname: acme2\n",
+		// duplicate key
+		"name: acme\nname: acme2\n",
+		// nested flow sequence
+		"name: acme\ndefinedBy:\n  anyOf: [[Zbb], Zbkb]\n",
+		// definedBy
+		"name: acme\ndefinedBy:\n  noneOf: [Zbb]\nencoding:\n  match: 0000000----------000-----0001011\n",
+		"name: acme\ndefinedBy: []\nencoding:\n  match: 0000000----------000-----0001011\n",
+	} {
+		op, _ := NewOpcodes(32)
+		if err := op.ReadUDB(strings.NewReader(s)); err == nil {
+			t.Errorf("\"%s\": expected an error", s)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
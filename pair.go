@@ -0,0 +1,141 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V auipc Pairing
+
+An auipc is normally followed by a jalr/addi/load/store using the same
+register to form a 32-bit pc-relative address. Pair these instructions,
+resolve the address and render them as call/tail/la pseudo instructions
+or annotate them with the address and symbol.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import "fmt"
+
+//-----------------------------------------------------------------------------
+
+// auipcPair returns the address formed by an "auipc rd,hi" followed by a
+// jalr, addi, load or store with rd as the base register. The address is
+// reduced to the register length of the ISA.
+func (isa *ISA) auipcPair(prev, da *Disassembly) (uint, bool) {
+	if prev == nil || prev.id() != "auipc" || da.Addr != prev.Addr+prev.InsLength {
+		return 0, false
+	}
+	hi, rd := decodeU(prev.Ins)
+	if rd == 0 {
+		return 0, false
+	}
+	var lo int
+	switch {
+	case da.id() == "jalr" || da.id() == "addi":
+		var rs1 uint
+		lo, rs1, _ = decodeIa(da.Ins)
+		if rs1 != rd {
+			return 0, false
+		}
+	case da.Mem != nil && !da.Mem.Atomic:
+		if da.Mem.Base != (Reg{RegX, rd}) {
+			return 0, false
+		}
+		lo = da.Mem.Offset
+	default:
+		return 0, false
+	}
+	return isa.addr(uint(int(prev.Addr) + (hi << 12) + lo)), true
+}
+
+// pseudo returns the call/tail/la pseudo instruction for an auipc pair
+// (or "" if there is none).
+func pseudo(da *Disassembly, target string) string {
+	_, rs1, rd := decodeIa(da.Ins)
+	switch da.id() {
+	case "jalr":
+		if rd == 1 && rs1 == 1 {
+			return fmt.Sprintf("call %s", target)
+		}
+		if rd == 0 {
+			return fmt.Sprintf("tail %s", target)
+		}
+	case "addi":
+		if rd == rs1 {
+			return fmt.Sprintf("la %s,%s", abiXName[rd], target)
+		}
+	}
+	return ""
+}
+
+// merge returns the disassembly of an auipc pair as a single instruction
+// (e.g. call/tail) with the combined register usage and control flow.
+func merge(prev, da *Disassembly, addr uint) Disassembly {
+	x := *prev
+	x.InsLength += da.InsLength
+	x.Flow, x.Target = da.Flow, da.Target
+	switch da.Flow {
+	case FlowIndirectCall:
+		x.Flow, x.Target = FlowCall, addr
+	case FlowIndirectJump:
+		x.Flow, x.Target = FlowJump, addr
+	}
+	// registers defined by the auipc are not used by the pair
+	x.Uses = append(RegSet{}, prev.Uses...)
+	for _, r := range da.Uses {
+		if !prev.Defs.Contains(r) {
+			x.Uses = x.Uses.add(r)
+		}
+	}
+	x.Defs = append(RegSet{}, prev.Defs...)
+	for _, r := range da.Defs {
+		x.Defs = x.Defs.add(r)
+	}
+	return x
+}
+
+// Resolve pairs the auipc instructions in a listing with the following
+// instruction. With pseudo set call/tail/la pairs are merged into a single
+// pseudo instruction line. Other pairs are annotated with the address
+// and symbol, e.g. "# 0x80004020 <buffer>".
+func (l *Listing) Resolve(isa *ISA, syms *Symbols, pseudoIns bool) {
+	lines := make([]*Line, 0, len(l.Lines))
+	for i := 0; i < len(l.Lines); i++ {
+		line := l.Lines[i]
+		lines = append(lines, line)
+		if line.Da == nil || i+1 == len(l.Lines) || l.Lines[i+1].Da == nil {
+			continue
+		}
+		next := l.Lines[i+1]
+		addr, ok := isa.auipcPair(line.Da, next.Da)
+		if !ok {
+			continue
+		}
+		sym := syms.Symbolize(addr)
+		if pseudoIns {
+			target := fmt.Sprintf("0x%x", addr)
+			if sym != "" {
+				target = sym
+			}
+			if s := pseudo(next.Da, target); s != "" {
+				da := merge(line.Da, next.Da, addr)
+				da.Assembly = s
+				lines[len(lines)-1] = &Line{
+					Addr:       line.Addr,
+					AddrLength: line.AddrLength,
+					Size:       line.Size + next.Size,
+					Da:         &da,
+					Seq:        []*Disassembly{next.Da},
+				}
+				i++
+				continue
+			}
+		}
+		next.Da.Comment = fmt.Sprintf("0x%x", addr)
+		if sym != "" {
+			next.Da.Comment += fmt.Sprintf(" <%s>", sym)
+		}
+	}
+	l.Lines = lines
+}
+
+//-----------------------------------------------------------------------------
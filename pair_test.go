@@ -0,0 +1,126 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V auipc Pairing Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+var pairTestCode = []uint{
+	0x00000097, // 00: auipc ra,0x0
+	0x028080e7, // 04: jalr 40(ra) (call 28)
+	0x00000317, // 08: auipc t1,0x0
+	0x02030067, // 0c: jr 32(t1) (tail 28)
+	0x00000517, // 10: auipc a0,0x0
+	0x01c50513, // 14: addi a0,a0,28 (la a0,2c)
+	0x00000597, // 18: auipc a1,0x0
+	0x0145a583, // 1c: lw a1,20(a1) (2c)
+	0x00000297, // 20: auipc t0,0x0
+	0x00b2a623, // 24: sw a1,12(t0) (2c)
+	0x8082,     // 28: ret
+	0x0001,     // 2a: nop
+}
+
+func pairListing(t *testing.T, pseudo bool) string {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syms := NewSymbols()
+	syms.Add(0x28, "foo")
+	syms.Add(0x2c, "buffer")
+	l := Sweep(isa, testCode(0, pairTestCode...))
+	l.Resolve(isa, syms, pseudo)
+	s := []string{}
+	for _, line := range l.Lines {
+		s = append(s, line.String())
+	}
+	return strings.Join(s, "\n")
+}
+
+func Test_Resolve(t *testing.T) {
+	expected := []string{
+		"00000000: 00000097 028080e7 \tcall foo",
+		"00000008: 00000317 02030067 \ttail foo",
+		"00000010: 00000517 01c50513 \tla a0,buffer",
+		"00000018: 00000597 \tauipc a1,0x0",
+		"0000001c: 0145a583 \tlw a1,20(a1) # 0x2c <buffer>",
+		"00000020: 00000297 \tauipc t0,0x0",
+		"00000024: 00b2a623 \tsw a1,12(t0) # 0x2c <buffer>",
+		"00000028: 8082     \tret",
+		"0000002a: 0001     \tnop",
+	}
+	if s := pairListing(t, true); s != strings.Join(expected, "\n") {
+		t.Errorf("pseudo listing\n%s\n(expected)\n%s", s, strings.Join(expected, "\n"))
+	}
+
+	expected = []string{
+		"00000000: 00000097 \tauipc ra,0x0",
+		"00000004: 028080e7 \tjalr 40(ra) # 0x28 <foo>",
+		"00000008: 00000317 \tauipc t1,0x0",
+		"0000000c: 02030067 \tjalr zero,32(t1) # 0x28 <foo>",
+	}
+	s := pairListing(t, false)
+	if !strings.HasPrefix(s, strings.Join(expected, "\n")) {
+		t.Errorf("annotated listing\n%s\n(expected)\n%s", s, strings.Join(expected, "\n"))
+	}
+}
+
+func Test_ResolveFlow(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := Sweep(isa, testCode(0, pairTestCode...))
+	l.Resolve(isa, NewSymbols(), true)
+	for i, v := range []struct {
+		flow       Flow
+		target     uint
+		uses, defs string
+	}{
+		{FlowCall, 0x28, "{}", "{ra}"},
+		{FlowJump, 0x28, "{}", "{t1}"},
+		{FlowNext, 0, "{}", "{a0}"},
+	} {
+		da := l.Lines[i].Da
+		if da.Flow != v.flow || da.Target != v.target || da.InsLength != 8 ||
+			da.Uses.String() != v.uses || da.Defs.String() != v.defs {
+			t.Errorf("%x: \"%s\" flow %s target %x uses %s defs %s (expected %s %x %s %s)",
+				da.Addr, da.Assembly, da.Flow, da.Target, da.Uses, da.Defs, v.flow, v.target, v.uses, v.defs)
+		}
+		if s := da.Successors(da.Addr); v.flow == FlowCall && (len(s) != 2 || s[0] != 8 || s[1] != 0x28) {
+			t.Errorf("%x: successors %v", da.Addr, s)
+		}
+	}
+}
+
+func Test_AuipcWrap(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		pc, hi, lo uint
+		target     uint
+	}{
+		{0x00000000, 0xfffff097, 0xf00080e7, 0xffffef00}, // auipc ra,0xfffff; jalr -256(ra)
+		{0xfffff000, 0x00001097, 0x004080e7, 0x00000004}, // auipc ra,0x1; jalr 4(ra)
+	} {
+		prev := isa.Disassemble(v.pc, v.hi)
+		da := isa.Disassemble(isa.addr(v.pc+4), v.lo)
+		if target, ok := isa.auipcTarget(prev, da); !ok || target != v.target {
+			t.Errorf("%x: target %x (expected %x)", v.pc, target, v.target)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
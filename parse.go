@@ -0,0 +1,132 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V ISA String Parsing
+
+Parse ISA naming strings (e.g. "rv32imac_zcb_zcmp", "rv64gc" or the
+versioned "rv32i2p1_m2p0_c2p0_zca1p0" form from ELF attributes).
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// isDigit returns true if the byte is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// skipVersion returns the index after an extension version (e.g. "2p1").
+func skipVersion(s string, i int) int {
+	j := i
+	for j < len(s) && isDigit(s[j]) {
+		j++
+	}
+	if j > i && j+1 < len(s) && s[j] == 'p' && isDigit(s[j+1]) {
+		j++
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+	}
+	return j
+}
+
+// trimVersion removes a trailing extension version (e.g. "1p0").
+func trimVersion(s string) string {
+	i := len(s)
+	for i > 0 && isDigit(s[i-1]) {
+		i--
+	}
+	if i == len(s) || i < 2 || s[i-1] != 'p' || !isDigit(s[i-2]) {
+		return s
+	}
+	i--
+	for i > 0 && isDigit(s[i-1]) {
+		i--
+	}
+	return s[:i]
+}
+
+// ParseISA parses an ISA naming string and returns the register length,
+// extension bits and sub-extension names.
+func ParseISA(s string) (uint, uint, []string, error) {
+	x := strings.ToLower(strings.TrimSpace(s))
+	var mxlen uint
+	switch {
+	case strings.HasPrefix(x, "rv32"):
+		mxlen = 32
+	case strings.HasPrefix(x, "rv64"):
+		mxlen = 64
+	default:
+		return 0, 0, nil, fmt.Errorf("bad ISA string \"%s\"", s)
+	}
+	var ext uint
+	var zext []string
+	for i, part := range strings.Split(x[4:], "_") {
+		if part == "" {
+			continue
+		}
+		if i != 0 && strings.ContainsRune("zsx", rune(part[0])) {
+			// multi-letter extension
+			zext = append(zext, trimVersion(part))
+			continue
+		}
+		// single letter extensions
+		for j := 0; j < len(part); {
+			c := part[j]
+			switch {
+			case c == 'g':
+				ext |= RV32g
+			case c == 'e':
+				ext |= ExtE | ExtI
+			case c >= 'a' && c <= 'z':
+				if strings.ContainsRune("zsx", rune(c)) {
+					// multi-letter extension without a separator
+					zext = append(zext, trimVersion(part[j:]))
+					j = len(part)
+					continue
+				}
+				ext |= 1 << (c - 'a')
+			default:
+				return 0, 0, nil, fmt.Errorf("bad extension \"%s\" in ISA string \"%s\"", part, s)
+			}
+			j = skipVersion(part, j+1)
+		}
+	}
+	return mxlen, ext, zext, nil
+}
+
+// Parse creates a new RISC-V instruction set from an ISA naming string.
+func Parse(s string) (*ISA, error) {
+	mxlen, ext, zext, err := ParseISA(s)
+	if err != nil {
+		return nil, err
+	}
+	return New(mxlen, ext, zext...)
+}
+
+// ParseArch creates a new RISC-V instruction set from the ISA string of the
+// ELF attributes. Unlike Parse, sub-extensions that rvda doesn't know (e.g.
+// ratified extensions it doesn't decode) are ignored.
+func ParseArch(s string) (*ISA, error) {
+	mxlen, ext, zext, err := ParseISA(s)
+	if err != nil {
+		return nil, err
+	}
+	known := []string{}
+	for _, x := range zext {
+		if zextKnown(x) {
+			known = append(known, x)
+		}
+	}
+	return New(mxlen, ext, known...)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,139 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V ISA String Parsing Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_ParseISA(t *testing.T) {
+	testCases := []struct {
+		s     string
+		mxlen uint
+		ext   uint
+		zext  string
+	}{
+		{"rv32imac", 32, ExtI | ExtM | ExtA | ExtC, ""},
+		{"RV64GC", 64, RV64gc, ""},
+		{"rv32imc_zcb_zcmp", 32, ExtI | ExtM | ExtC, "zcb,zcmp"},
+		{"rv32i2p1_m2p0_c2p0_zicsr2p0_zca1p0_zcb1p0", 32, ExtI | ExtM | ExtC, "zicsr,zca,zcb"},
+		{"rv64imaczcmt", 64, ExtI | ExtM | ExtA | ExtC, "zcmt"},
+		{"rv32e", 32, ExtE | ExtI, ""},
+	}
+	for _, v := range testCases {
+		mxlen, ext, zext, err := ParseISA(v.s)
+		if err != nil {
+			t.Errorf("%s: %s", v.s, err)
+			continue
+		}
+		if mxlen != v.mxlen || ext != v.ext || strings.Join(zext, ",") != v.zext {
+			t.Errorf("%s: %d %s %v (expected %d %s %s)", v.s, mxlen, fmtExt(ext), zext, v.mxlen, fmtExt(v.ext), v.zext)
+		}
+	}
+	for _, s := range []string{"rv128i", "x86", "rv32i+m"} {
+		if _, _, _, err := ParseISA(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+func Test_Zext(t *testing.T) {
+	testCases := []struct {
+		s    string
+		has  string
+		hasn string
+	}{
+		{"rv32gc", "zca,zcf,zcd", "zcb"},
+		{"rv64gc", "zca,zcd", "zcf"},
+		{"rv32gc_zcmp", "zca,zcf,zcmp", "zcd"},
+		{"rv32imf_zce", "zca,zcb,zcf,zcmp,zcmt", "zcd"},
+		{"rv32if_zce", "zca,zcf", "zcd"},
+		{"rv64if_zce", "zca,zcb", "zcf"},
+		{"rv32i_zcb", "zca,zcb", "zcf"},
+	}
+	for _, v := range testCases {
+		isa, err := Parse(v.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, z := range strings.Split(v.has, ",") {
+			if !isa.HasExtension(z) {
+				t.Errorf("%s: %s not enabled", v.s, z)
+			}
+		}
+		for _, z := range strings.Split(v.hasn, ",") {
+			if isa.HasExtension(z) {
+				t.Errorf("%s: %s enabled", v.s, z)
+			}
+		}
+	}
+	// an explicit Zcd can't be replaced
+	for _, s := range []string{"rv32gc_zcd_zcmp", "rv64gc_zcmt_zcd", "rv32imafd_zcd_zce"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+	// unknown standard and vendor sub-extensions
+	for _, s := range []string{"rv64gc_xtheadbaa", "rv32imc_zcbb", "rv64gc_zfh"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+	if _, err := Parse("rv64gc_zicsr_zifencei_svinval"); err != nil {
+		t.Error(err)
+	}
+	isa, err := ParseArch("rv64i2p1_m2p0_c2p0_zicsr2p0_zfh1p0_zcb1p0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isa.HasExtension("zcb") || isa.HasExtension("zfh") {
+		t.Errorf("%s: bad extensions", isa)
+	}
+	isa, _ = New(32, ExtI|ExtC, "ZCMP", "zcb")
+	if s := isa.String(); s != "RV32 ext \"ci\" zcb_zcmp" {
+		t.Errorf("isa \"%s\"", s)
+	}
+}
+
+func Test_ElfArch(t *testing.T) {
+	// .attribute arch, "rv32i2p0_m2p0_c2p0"; .attribute stack_align, 16
+	attr := []byte{
+		0x41, 0x25, 0x00, 0x00, 0x00, 0x72, 0x69, 0x73, 0x63, 0x76, 0x00, 0x01, 0x1b, 0x00, 0x00, 0x00,
+		0x05, 0x72, 0x76, 0x33, 0x32, 0x69, 0x32, 0x70, 0x30, 0x5f, 0x6d, 0x32, 0x70, 0x30, 0x5f, 0x63,
+		0x32, 0x70, 0x30, 0x00, 0x04, 0x10,
+	}
+	if s := elfArch(attr); s != "rv32i2p0_m2p0_c2p0" {
+		t.Errorf("arch \"%s\" (expected \"rv32i2p0_m2p0_c2p0\")", s)
+	}
+	if s := elfArch(attr[:20]); s != "" {
+		t.Errorf("truncated arch \"%s\"", s)
+	}
+	// unterminated tag, tag longer than the attribute size
+	for _, sub := range [][]byte{
+		{0x81, 0x81, 0x81, 0x81, 0x81, 0x81},
+		{0x81, 0x00, 0x05, 0x00, 0x00, 0x00},
+	} {
+		b := append([]byte{0x41, byte(10 + len(sub)), 0x00, 0x00, 0x00, 0x72, 0x69, 0x73, 0x63, 0x76, 0x00}, sub...)
+		if s := elfArch(b); s != "" {
+			t.Errorf("corrupt arch \"%s\"", s)
+		}
+	}
+	// truncated and corrupted bytes must not panic
+	for i := range attr {
+		elfArch(attr[:i])
+		b := append([]byte{}, attr...)
+		b[i] = 0xff
+		elfArch(b)
+	}
+}
+
+//-----------------------------------------------------------------------------
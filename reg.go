@@ -0,0 +1,409 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Register Usage
+
+Determine the registers read (uses) and written (defs) by an instruction.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// RegFile is a RISC-V register file.
+type RegFile int
+
+// Register files.
+const (
+	RegX   RegFile = iota // integer registers
+	RegF                  // floating point registers
+	RegV                  // vector registers
+	RegCSR                // control and status registers
+)
+
+// Reg is a register within a register file.
+type Reg struct {
+	File RegFile // register file
+	Num  uint    // register number
+}
+
+// Implicitly accessed registers.
+var (
+	regRA     = Reg{RegX, 1}
+	regSP     = Reg{RegX, 2}
+	regFFLAGS = Reg{RegCSR, csrFFLAGS}
+	regFRM    = Reg{RegCSR, csrFRM}
+	regSSP    = Reg{RegCSR, csrSSP}
+)
+
+func (r Reg) String() string {
+	switch r.File {
+	case RegX:
+		return abiXName[r.Num&31]
+	case RegF:
+		return abiFName[r.Num&31]
+	case RegV:
+		return fmt.Sprintf("v%d", r.Num)
+	case RegCSR:
+		return csrName(r.Num)
+	}
+	return fmt.Sprintf("?%d", r.Num)
+}
+
+// RegSet is a set of registers.
+type RegSet []Reg
+
+// Contains returns true if the register is in the set.
+func (rs RegSet) Contains(r Reg) bool {
+	for _, x := range rs {
+		if x == r {
+			return true
+		}
+	}
+	return false
+}
+
+// add a register to the set. x0 is hardwired to zero and is not tracked.
+func (rs RegSet) add(r Reg) RegSet {
+	if r.File == RegX && r.Num == 0 {
+		return rs
+	}
+	if rs.Contains(r) {
+		return rs
+	}
+	return append(rs, r)
+}
+
+func (rs RegSet) String() string {
+	s := make([]string, len(rs))
+	for i, r := range rs {
+		s[i] = r.String()
+	}
+	return "{" + strings.Join(s, ",") + "}"
+}
+
+//-----------------------------------------------------------------------------
+// register operand roles
+
+const (
+	roleRd  = 1 << iota // destination register
+	roleRs1             // source register 1
+	roleRs2             // source register 2
+	roleRs3             // source register 3
+)
+
+// regField describes a register field of an instruction definition.
+type regField struct {
+	role   int  // operand role(s)
+	offset uint // added to the field value to give the register number
+}
+
+// regFields maps instruction definition fields to register operand roles.
+var regFields = map[string]regField{
+	"rd":        {roleRd, 0},
+	"rd!=0":     {roleRd, 0},
+	"rd!={0,2}": {roleRd, 0},
+	"rs1":       {roleRs1, 0},
+	"rs1!=0":    {roleRs1, 0},
+	"rs2":       {roleRs2, 0},
+	"rs2!=0":    {roleRs2, 0},
+	"rs3":       {roleRs3, 0},
+	"rs1/rd!=0": {roleRs1 | roleRd, 0},
+	"rd0":       {roleRd, 8},
+	"rs10":      {roleRs1, 8},
+	"rs20":      {roleRs2, 8},
+	"rs10/rd0":  {roleRs1 | roleRd, 8},
+	"rd2":       {roleRd, 0},
+	"fd":        {roleRd, 0},
+	"fs3":       {roleRs3, 0},
+	"vd":        {roleRd, 0},
+	"vs1":       {roleRs1, 0},
+	"vs2":       {roleRs2, 0},
+	"vs3":       {roleRs3, 0},
+	"fs1":       {roleRs1, 0},
+	"fs2":       {roleRs2, 0},
+	"fd0":       {roleRd, 8},
+	"fs20":      {roleRs2, 8},
+	// explicitly typed integer registers (unified-db)
+	"xd":   {roleRd, 0},
+	"xs1":  {roleRs1, 0},
+	"xs2":  {roleRs2, 0},
+	"xs3":  {roleRs3, 0},
+	"xd0":  {roleRd, 8},
+	"xs10": {roleRs1, 8},
+	"xs20": {roleRs2, 8},
+}
+
+// fieldFile returns the register file named by a field (xd, xs1, ...:
+// integer, fd, fs1, ...: floating point, vd, vs1, ...: vector).
+func fieldFile(name string) (RegFile, bool) {
+	switch name[0] {
+	case 'x':
+		return RegX, true
+	case 'f':
+		return RegF, true
+	case 'v':
+		return RegV, true
+	}
+	return RegX, false
+}
+
+// fieldReg returns the register named by the value of a register field.
+func fieldReg(id, name string, x uint) Reg {
+	rf := regFields[name]
+	file, ok := fieldFile(name)
+	if !ok {
+		file = regFile(id, rf.role)
+	}
+	return Reg{file, x + rf.offset}
+}
+
+//-----------------------------------------------------------------------------
+// register files for floating point operands
+
+// fpFormat returns the register file for a floating point conversion format.
+func fpFormat(s string) RegFile {
+	switch s {
+	case "w", "wu", "l", "lu":
+		return RegX
+	}
+	return RegF
+}
+
+// isFloat returns true if the instruction operates on floating point registers.
+func isFloat(id string) bool {
+	id = strings.TrimPrefix(id, "c.")
+	return strings.HasPrefix(id, "f") && !strings.HasPrefix(id, "fence")
+}
+
+// isFloatMem returns true for a floating point load/store.
+func isFloatMem(id string) bool {
+	id = strings.TrimPrefix(id, "c.")
+	for _, s := range []string{"flw", "fld", "flq", "fsw", "fsd", "fsq"} {
+		if strings.HasPrefix(id, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// regFile returns the register file for an operand of an instruction.
+func regFile(id string, role int) RegFile {
+	if !isFloat(id) {
+		return RegX
+	}
+	id = strings.TrimPrefix(id, "c.")
+	// loads/stores: the base address is an integer register
+	if isFloatMem(id) {
+		if role == roleRs1 {
+			return RegX
+		}
+		return RegF
+	}
+	x := strings.Split(id, ".")
+	switch x[0] {
+	case "fcvt":
+		// fcvt.<dst>.<src>
+		if len(x) == 3 {
+			if role == roleRd {
+				return fpFormat(x[1])
+			}
+			return fpFormat(x[2])
+		}
+	case "fmv":
+		// fmv.<dst>.<src>
+		if len(x) == 3 {
+			if role == roleRd {
+				if x[1] == "x" {
+					return RegX
+				}
+				return RegF
+			}
+			if x[2] == "x" {
+				return RegX
+			}
+			return RegF
+		}
+	case "feq", "flt", "fle", "fclass":
+		if role == roleRd {
+			return RegX
+		}
+	}
+	return RegF
+}
+
+// fpSetsFlags returns true if the floating point instruction can set fflags.
+func fpSetsFlags(id string) bool {
+	if !isFloat(id) || isFloatMem(id) {
+		return false
+	}
+	x := strings.Split(id, ".")
+	switch x[0] {
+	case "fsgnj", "fsgnjn", "fsgnjx", "fmv", "fclass":
+		return false
+	}
+	return true
+}
+
+//-----------------------------------------------------------------------------
+
+// regUsage returns the registers used and defined by an instruction.
+func (im *insMeta) regUsage(ins uint) (RegSet, RegSet) {
+	var uses, defs RegSet
+	if im.id == "fence" {
+		// rd and rs1 are reserved (ignored by implementations)
+		return uses, defs
+	}
+
+	// explicit register operands (in operand order)
+	for _, role := range []int{roleRs1, roleRs2, roleRs3, roleRd} {
+		for _, f := range im.fields {
+			rf, ok := regFields[f.name]
+			if !ok || rf.role&role == 0 {
+				continue
+			}
+			file, ok := fieldFile(f.name)
+			if !ok {
+				file = regFile(im.id, role)
+			}
+			r := Reg{file, bitUnsigned(ins, f.msb, f.lsb, 0) + rf.offset}
+			if role == roleRd {
+				defs = defs.add(r)
+			} else {
+				uses = uses.add(r)
+			}
+		}
+	}
+
+	// implicit register operands
+	switch im.id {
+	case "c.lwsp", "c.ldsp", "c.lqsp", "c.flwsp", "c.fldsp",
+		"c.swsp", "c.sdsp", "c.sqsp", "c.fswsp", "c.fsdsp",
+		"c.addi4spn":
+		uses = uses.add(regSP)
+	case "c.addi16sp":
+		uses = uses.add(regSP)
+		defs = defs.add(regSP)
+	case "c.jal", "c.jalr":
+		defs = defs.add(regRA)
+	case "cm.jt":
+		uses = uses.add(Reg{RegCSR, csrJVT})
+	case "cm.jalt":
+		uses = uses.add(Reg{RegCSR, csrJVT})
+		defs = defs.add(regRA)
+	case "cm.push":
+		rlist, _ := decodeCMPP(ins)
+		uses = uses.add(regSP)
+		for _, r := range rlistRegs(rlist) {
+			uses = uses.add(Reg{RegX, r})
+		}
+		defs = defs.add(regSP)
+	case "cm.pop", "cm.popret", "cm.popretz":
+		rlist, _ := decodeCMPP(ins)
+		uses = uses.add(regSP)
+		defs = defs.add(regSP)
+		for _, r := range rlistRegs(rlist) {
+			defs = defs.add(Reg{RegX, r})
+		}
+		if im.id == "cm.popretz" {
+			defs = defs.add(Reg{RegX, 10})
+		}
+	case "cm.mvsa01":
+		r1s, r2s := decodeCMMV(ins)
+		uses = uses.add(Reg{RegX, 10}).add(Reg{RegX, 11})
+		defs = defs.add(Reg{RegX, r1s}).add(Reg{RegX, r2s})
+	case "cm.mva01s":
+		r1s, r2s := decodeCMMV(ins)
+		uses = uses.add(Reg{RegX, r1s}).add(Reg{RegX, r2s})
+		defs = defs.add(Reg{RegX, 10}).add(Reg{RegX, 11})
+	case "vaesdf.vv", "vaesdf.vs", "vaesdm.vv", "vaesdm.vs",
+		"vaesef.vv", "vaesef.vs", "vaesem.vv", "vaesem.vs",
+		"vaesz.vs", "vaeskf2.vi", "vghsh.vv", "vgmul.vv",
+		"vsha2ms.vv", "vsha2ch.vv", "vsha2cl.vv",
+		"vsm4r.vv", "vsm4r.vs", "vsm3c.vi":
+		// vd holds the cipher/hash state (read and written)
+		_, _, _, vd := decodeV(ins)
+		uses = uses.add(Reg{RegV, vd})
+	case "sf.vqmaccu.2x8x2", "sf.vqmacc.2x8x2", "sf.vqmaccus.2x8x2", "sf.vqmaccsu.2x8x2",
+		"sf.vqmaccu.4x8x4", "sf.vqmacc.4x8x4", "sf.vqmaccus.4x8x4", "sf.vqmaccsu.4x8x4",
+		"sf.vc.v.xvv", "sf.vc.v.ivv", "sf.vc.v.vvv", "sf.vc.v.fvv",
+		"sf.vc.v.xvw", "sf.vc.v.ivw", "sf.vc.v.vvw", "sf.vc.v.fvw":
+		// vd is an accumulator (read and written)
+		_, _, _, vd := decodeV(ins)
+		uses = uses.add(Reg{RegV, vd})
+	case "lpad":
+		// a non-zero label is checked against t2
+		if ins>>12 != 0 {
+			uses = uses.add(Reg{RegX, 7})
+		}
+	case "sspush", "c.sspush", "sspopchk", "c.sspopchk":
+		// the register is rs2 (sspush), rs1 (sspopchk) or rd (compressed)
+		r := bitUnsigned(ins, 24, 20, 0)
+		if im.id == "sspopchk" {
+			r = bitUnsigned(ins, 19, 15, 0)
+		} else if im.n == 16 {
+			r = bitUnsigned(ins, 11, 7, 0)
+		}
+		uses = uses.add(Reg{RegX, r}).add(regSSP)
+		defs = defs.add(regSSP)
+	case "ssrdp":
+		uses = uses.add(regSSP)
+	case "cv.insert", "cv.insertr", "cv.mac", "cv.msu",
+		"cv.macsn", "cv.machhsn", "cv.macsrn", "cv.machhsrn",
+		"cv.macun", "cv.machhun", "cv.macurn", "cv.machhurn",
+		"cv.addnr", "cv.addunr", "cv.addrnr", "cv.addurnr",
+		"cv.subnr", "cv.subunr", "cv.subrnr", "cv.suburnr",
+		"cv.sdotup", "cv.sdotusp", "cv.sdotsp":
+		// rd is an accumulator (read and written)
+		uses = uses.add(Reg{RegX, bitUnsigned(ins, 11, 7, 0)})
+	case "cv.starti", "cv.start", "cv.endi", "cv.end",
+		"cv.counti", "cv.count", "cv.setupi", "cv.setup":
+		// lpstart, lpend and lpcount of hardware loop L
+		csr := csrLPSTART0 + 4*bitUnsigned(ins, 7, 7, 0)
+		switch strings.TrimSuffix(im.id, "i") {
+		case "cv.start":
+			defs = defs.add(Reg{RegCSR, csr})
+		case "cv.end":
+			defs = defs.add(Reg{RegCSR, csr + 1})
+		case "cv.count":
+			defs = defs.add(Reg{RegCSR, csr + 2})
+		default:
+			defs = defs.add(Reg{RegCSR, csr}).add(Reg{RegCSR, csr + 1}).add(Reg{RegCSR, csr + 2})
+		}
+	case "csrrw", "csrrs", "csrrc", "csrrwi", "csrrsi", "csrrci":
+		csr, rs1, rd := decodeIb(ins)
+		r := Reg{RegCSR, csr}
+		// csrrw* only reads the csr when rd != 0
+		if im.id[4] != 'w' || rd != 0 {
+			uses = uses.add(r)
+		}
+		// csrr[sc]* only writes the csr when rs1/zimm != 0
+		if im.id[4] == 'w' || rs1 != 0 {
+			defs = defs.add(r)
+		}
+	}
+
+	// vector mask
+	if vm, ok := im.field(ins, "vm"); ok && vm == 0 {
+		uses = uses.add(Reg{RegV, 0})
+	}
+
+	// floating point status
+	if fpSetsFlags(im.id) {
+		defs = defs.add(regFFLAGS)
+	}
+	if rm, ok := im.field(ins, "rm"); ok && rm == frmDYN {
+		uses = uses.add(regFRM)
+	}
+
+	return uses, defs
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,100 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Semihosting
+
+Semihosting traps to the debugger with the "slli x0,x0,0x1f; ebreak;
+srai x0,x0,7" sequence. The operation number is in a0.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import "fmt"
+
+//-----------------------------------------------------------------------------
+
+// semihosting sequence
+const (
+	semihostEntry = 0x01f01013 // slli x0,x0,0x1f
+	semihostTrap  = 0x00100073 // ebreak
+	semihostExit  = 0x40705013 // srai x0,x0,7
+)
+
+// semihostOps are the semihosting operation names.
+var semihostOps = map[uint]string{
+	0x01: "SYS_OPEN",
+	0x02: "SYS_CLOSE",
+	0x03: "SYS_WRITEC",
+	0x04: "SYS_WRITE0",
+	0x05: "SYS_WRITE",
+	0x06: "SYS_READ",
+	0x07: "SYS_READC",
+	0x08: "SYS_ISERROR",
+	0x09: "SYS_ISTTY",
+	0x0a: "SYS_SEEK",
+	0x0c: "SYS_FLEN",
+	0x0d: "SYS_TMPNAM",
+	0x0e: "SYS_REMOVE",
+	0x0f: "SYS_RENAME",
+	0x10: "SYS_CLOCK",
+	0x11: "SYS_TIME",
+	0x12: "SYS_SYSTEM",
+	0x13: "SYS_ERRNO",
+	0x15: "SYS_GET_CMDLINE",
+	0x16: "SYS_HEAPINFO",
+	0x18: "SYS_EXIT",
+	0x20: "SYS_EXIT_EXTENDED",
+	0x30: "SYS_ELAPSED",
+	0x31: "SYS_TICKFREQ",
+}
+
+// SemihostingName returns the name of a semihosting operation.
+func SemihostingName(op uint) (string, bool) {
+	name, ok := semihostOps[op]
+	return name, ok
+}
+
+// isSemihosting returns true if the line at index i is the ebreak of a
+// semihosting sequence.
+func (l *Listing) isSemihosting(i int) bool {
+	if i < 1 || i+1 >= len(l.Lines) {
+		return false
+	}
+	seq := []uint{semihostEntry, semihostTrap, semihostExit}
+	for j, ins := range seq {
+		line := l.Lines[i-1+j]
+		if line.Da == nil || line.Da.Ins != ins || line.Da.InsLength != 4 || len(line.Seq) != 0 {
+			return false
+		}
+		if j != 0 && line.Addr != l.Lines[i-2+j].Addr+4 {
+			return false
+		}
+	}
+	return true
+}
+
+// Semihosting annotates the semihosting sequences in a listing. The
+// operation is named when a0 is set by a preceding instruction in the
+// same basic block.
+func (l *Listing) Semihosting(isa *ISA, syms *Symbols) {
+	l.consts(isa, syms, nil, func(i int, regs *regTracker) {
+		if !l.isSemihosting(i) {
+			return
+		}
+		comment := "semihosting"
+		if op, ok := regs.value(10); ok {
+			if name, ok := SemihostingName(op); ok {
+				comment += " " + name
+			} else {
+				comment += fmt.Sprintf(" 0x%x", op)
+			}
+		}
+		l.Lines[i-1].Da.Comment = "semihosting entry"
+		l.Lines[i].Da.Comment = comment
+		l.Lines[i+1].Da.Comment = "semihosting exit"
+	})
+}
+
+//-----------------------------------------------------------------------------
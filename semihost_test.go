@@ -0,0 +1,53 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Semihosting Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Semihosting(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := Sweep(isa, testCode(0,
+		0x01800513, // 00: li a0,24
+		0x01f01013, // 04: slli zero,zero,0x1f
+		0x00100073, // 08: ebreak
+		0x40705013, // 0c: srai zero,zero,0x7
+		0x01f01013, // 10: slli zero,zero,0x1f
+		0x00100073, // 14: ebreak
+		0x40705013, // 18: srai zero,zero,0x7
+		0x00100073, // 1c: ebreak
+	))
+	l.Semihosting(isa, nil)
+	s := []string{}
+	for _, line := range l.Lines {
+		s = append(s, line.Da.Comment)
+	}
+	expected := []string{
+		"",
+		"semihosting entry",
+		"semihosting SYS_EXIT",
+		"semihosting exit",
+		"semihosting entry",
+		"semihosting",
+		"semihosting exit",
+		"",
+	}
+	if strings.Join(s, ",") != strings.Join(expected, ",") {
+		t.Errorf("comments %q (expected %q)", s, expected)
+	}
+}
+
+//-----------------------------------------------------------------------------
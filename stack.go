@@ -0,0 +1,290 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Stack Usage Analysis
+
+Compute the stack frame size of each function from the stack pointer
+adjustments, and the worst case stack depth along the direct call graph.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// StackCall is a call site within a function.
+type StackCall struct {
+	Addr     uint // address of the call instruction
+	Target   uint // call target
+	Depth    uint // stack allocated by the caller at the call
+	Tail     bool // tail call
+	Indirect bool // indirect call (the target is unknown)
+}
+
+// FuncStack is the stack usage of a function.
+type FuncStack struct {
+	Func    *Func       // the function
+	Frame   uint        // maximum stack allocated by the function
+	Dynamic bool        // the stack pointer is modified by an unknown amount
+	Calls   []StackCall // call sites
+}
+
+// StackDepth is the worst case stack depth from a root function.
+type StackDepth struct {
+	Root      uint   // root function address
+	Depth     uint   // worst case stack depth in bytes
+	Bounded   bool   // the depth is a bound (no recursion, indirect calls, ...)
+	Recursive bool   // recursion was found
+	Indirect  bool   // indirect calls were found
+	Dynamic   bool   // dynamic stack allocation was found
+	Unknown   bool   // calls to unknown functions were found
+	Path      []uint // function addresses of the worst case call chain
+}
+
+// StackUsage is the stack usage of a set of functions.
+type StackUsage struct {
+	Funcs map[uint]*FuncStack // stack usage per function address
+}
+
+//-----------------------------------------------------------------------------
+
+// frame computes the stack usage of a function.
+func (ff *funcFinder) frame(f *Func) *FuncStack {
+	fs := &FuncStack{Func: f}
+	tail := make(map[uint]bool)
+	for _, pc := range f.TailCalls {
+		tail[pc] = true
+	}
+
+	type state struct {
+		pc    uint
+		depth int
+	}
+	depth := make(map[uint]int) // stack allocation before each instruction
+	work := []state{{f.Addr, 0}}
+
+	for len(work) > 0 {
+		s := work[len(work)-1]
+		work = work[:len(work)-1]
+		pc, d := s.pc, s.depth
+		var prev *Disassembly
+		for {
+			if x, ok := depth[pc]; ok {
+				if x != d {
+					// different stack allocations on paths to this instruction
+					fs.Dynamic = true
+				}
+				break
+			}
+			depth[pc] = d
+			da := ff.decode(pc)
+			if da == nil {
+				break
+			}
+			if n, ok := da.spAdjust(); ok {
+				d -= n
+			} else if da.Defs.Contains(regSP) {
+				fs.Dynamic = true
+			}
+			if d > 0 && uint(d) > fs.Frame {
+				fs.Frame = uint(d)
+			}
+			cd := uint(0)
+			if d > 0 {
+				cd = uint(d)
+			}
+			end := false
+			switch da.Flow {
+			case FlowBranch:
+				work = append(work, state{da.Target, d})
+			case FlowJump:
+				if tail[pc] {
+					fs.Calls = append(fs.Calls, StackCall{Addr: pc, Target: da.Target, Depth: cd, Tail: true})
+				} else {
+					work = append(work, state{da.Target, d})
+				}
+				end = true
+			case FlowCall:
+				fs.Calls = append(fs.Calls, StackCall{Addr: pc, Target: da.Target, Depth: cd})
+			case FlowIndirectCall:
+				if target, ok := ff.isa.auipcTarget(prev, da); ok {
+					fs.Calls = append(fs.Calls, StackCall{Addr: pc, Target: target, Depth: cd})
+				} else {
+					fs.Calls = append(fs.Calls, StackCall{Addr: pc, Depth: cd, Indirect: true})
+				}
+			case FlowIndirectJump:
+				if tail[pc] {
+					target, ok := ff.isa.auipcTarget(prev, da)
+					fs.Calls = append(fs.Calls, StackCall{Addr: pc, Target: target, Depth: cd, Tail: true, Indirect: !ok})
+				}
+				end = true
+			case FlowReturn, FlowTrapReturn:
+				end = true
+			}
+			if end {
+				break
+			}
+			prev = da
+			pc += da.InsLength
+		}
+	}
+	return fs
+}
+
+// NewStackUsage computes the stack usage of a set of functions (see FindFuncs).
+func NewStackUsage(isa *ISA, code *Code, funcs []*Func) *StackUsage {
+	ff := &funcFinder{
+		isa:  isa,
+		code: code,
+		ins:  make(map[uint]*Disassembly),
+	}
+	su := &StackUsage{
+		Funcs: make(map[uint]*FuncStack),
+	}
+	for _, f := range funcs {
+		su.Funcs[f.Addr] = ff.frame(f)
+	}
+	return su
+}
+
+//-----------------------------------------------------------------------------
+
+// depth returns the worst case stack depth of a function and its callees.
+// Every result is memoized, so each function is walked once. A result
+// within a recursive cycle depends on where the cycle was entered, but it
+// is flagged as recursive (and so unbounded) wherever it is used.
+func (su *StackUsage) depth(addr uint, active map[uint]bool, memo map[uint]*StackDepth) *StackDepth {
+	if sd, ok := memo[addr]; ok {
+		return sd
+	}
+	sd := &StackDepth{Root: addr, Bounded: true}
+	fs := su.Funcs[addr]
+	if fs == nil {
+		sd.Unknown = true
+		sd.Bounded = false
+		return sd
+	}
+	if active[addr] {
+		sd.Recursive = true
+		sd.Bounded = false
+		return sd
+	}
+	active[addr] = true
+
+	sd.Depth = fs.Frame
+	sd.Path = []uint{addr}
+	sd.Dynamic = fs.Dynamic
+	for _, c := range fs.Calls {
+		if c.Indirect {
+			sd.Indirect = true
+			continue
+		}
+		x := su.depth(c.Target, active, memo)
+		sd.Recursive = sd.Recursive || x.Recursive
+		sd.Indirect = sd.Indirect || x.Indirect
+		sd.Dynamic = sd.Dynamic || x.Dynamic
+		sd.Unknown = sd.Unknown || x.Unknown
+		if c.Depth+x.Depth > sd.Depth {
+			sd.Depth = c.Depth + x.Depth
+			sd.Path = append([]uint{addr}, x.Path...)
+		}
+	}
+	sd.Bounded = !(sd.Recursive || sd.Indirect || sd.Dynamic || sd.Unknown)
+
+	delete(active, addr)
+	memo[addr] = sd
+	return sd
+}
+
+// Depth returns the worst case stack depth starting from a root function
+// (e.g. the reset vector or a trap handler).
+func (su *StackUsage) Depth(root uint) *StackDepth {
+	return su.depth(root, make(map[uint]bool), make(map[uint]*StackDepth))
+}
+
+// Report returns a stack depth report for a set of root functions.
+func (su *StackUsage) Report(roots []uint, syms *Symbols) string {
+	name := func(addr uint) string {
+		if s := syms.Symbolize(addr); s != "" {
+			return s
+		}
+		return fmt.Sprintf("%x", addr)
+	}
+	s := []string{}
+	for _, root := range roots {
+		sd := su.Depth(root)
+		flags := []string{}
+		if sd.Recursive {
+			flags = append(flags, "recursion")
+		}
+		if sd.Indirect {
+			flags = append(flags, "indirect calls")
+		}
+		if sd.Dynamic {
+			flags = append(flags, "dynamic allocation")
+		}
+		if sd.Unknown {
+			flags = append(flags, "unknown functions")
+		}
+		bound := "bounded"
+		if !sd.Bounded {
+			bound = "unbounded: " + strings.Join(flags, ", ")
+		}
+		path := make([]string, len(sd.Path))
+		for i, addr := range sd.Path {
+			path[i] = name(addr)
+		}
+		s = append(s, fmt.Sprintf("%s: %d bytes (%s) %s", name(root), sd.Depth, bound, strings.Join(path, " -> ")))
+	}
+	return strings.Join(s, "\n")
+}
+
+//-----------------------------------------------------------------------------
+// trap handlers
+
+// TrapHandlers returns the trap handler addresses written to the trap
+// vector CSRs (mtvec, stvec, utvec) by an "la rd,handler; csrw xtvec,rd"
+// (auipc/addi) or "li rd,handler; csrw xtvec,rd" (lui/addi) sequence.
+func TrapHandlers(isa *ISA, code *Code) []uint {
+	handlers := []uint{}
+	lines := Sweep(isa, code).Lines
+	for i, l := range lines {
+		if l.Da == nil || l.Da.id() != "csrrw" {
+			continue
+		}
+		csr, rs1, _ := decodeIb(l.Da.Ins)
+		if csr != 0x305 && csr != 0x105 && csr != 0x005 {
+			continue
+		}
+		// look back for the value of rs1
+		if i < 2 || lines[i-1].Da == nil || lines[i-2].Da == nil {
+			continue
+		}
+		hi, lo := lines[i-2].Da, lines[i-1].Da
+		imm, rs, rd := decodeIa(lo.Ins)
+		if lo.id() != "addi" || rd != rs1 || rs != rs1 {
+			continue
+		}
+		uimm, urd := decodeU(hi.Ins)
+		if urd != rs1 {
+			continue
+		}
+		addr := uint((uimm << 12) + imm)
+		if hi.id() == "auipc" {
+			addr += hi.Addr
+		} else if hi.id() != "lui" {
+			continue
+		}
+		// the low bits are the trap vector mode
+		handlers = append(handlers, isa.addr(addr)&^3)
+	}
+	return handlers
+}
+
+//-----------------------------------------------------------------------------
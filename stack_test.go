@@ -0,0 +1,100 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Stack Usage Analysis Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+var stackTestCode = []uint{
+	0x00000297, // 00: auipc t0,0x0
+	0x03428293, // 04: addi t0,t0,52
+	0x30529073, // 08: csrw mtvec,t0
+	0x1141,     // 0c: addi sp,sp,-16
+	0x00000097, // 0e: auipc ra,0x0
+	0x00c080e7, // 12: jalr 12(ra) (call 1a)
+	0x0141,     // 16: addi sp,sp,16
+	0xa001,     // 18: j 18
+	0x1101,     // 1a: addi sp,sp,-32
+	0xce06,     // 1c: sw ra,28(sp)
+	0x00000097, // 1e: auipc ra,0x0
+	0x00e080e7, // 22: jalr 14(ra) (call 2c)
+	0x40f2,     // 26: lw ra,28(sp)
+	0x6105,     // 28: addi sp,sp,32
+	0x8082,     // 2a: ret
+	0x7179,     // 2c: addi sp,sp,-48
+	0x6145,     // 2e: addi sp,sp,48
+	0x8082,     // 30: ret
+	0x0001,     // 32: nop
+	0x7139,     // 34: addi sp,sp,-64 (trap handler)
+	0x9502,     // 36: jalr a0
+	0x6121,     // 38: addi sp,sp,64
+	0x30200073, // 3a: mret
+}
+
+func Test_StackUsage(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0, stackTestCode...)
+
+	handlers := TrapHandlers(isa, code)
+	if fmt.Sprintf("%x", handlers) != "[34]" {
+		t.Errorf("trap handlers %x (expected [34])", handlers)
+	}
+
+	roots := append([]uint{0}, handlers...)
+	su := NewStackUsage(isa, code, FindFuncs(isa, code, roots, nil))
+	if su.Funcs[0x1a].Frame != 32 {
+		t.Errorf("frame %d (expected 32)", su.Funcs[0x1a].Frame)
+	}
+
+	sd := su.Depth(0)
+	if sd.Depth != 96 || !sd.Bounded || fmt.Sprintf("%x", sd.Path) != "[0 1a 2c]" {
+		t.Errorf("depth %d bounded %v path %x (expected 96 true [0 1a 2c])", sd.Depth, sd.Bounded, sd.Path)
+	}
+	sd = su.Depth(0x34)
+	if sd.Depth != 64 || sd.Bounded || !sd.Indirect {
+		t.Errorf("depth %d bounded %v indirect %v (expected 64 false true)", sd.Depth, sd.Bounded, sd.Indirect)
+	}
+
+	// recursion
+	code = testCode(0,
+		0x1141,     // 00: addi sp,sp,-16
+		0xfffff0ef, // 02: jal ra,0
+		0x0141,     // 06: addi sp,sp,16
+		0x8082,     // 08: ret
+	)
+	su = NewStackUsage(isa, code, FindFuncs(isa, code, []uint{0}, nil))
+	sd = su.Depth(0)
+	if sd.Depth != 16 || sd.Bounded || !sd.Recursive {
+		t.Errorf("depth %d bounded %v recursive %v (expected 16 false true)", sd.Depth, sd.Bounded, sd.Recursive)
+	}
+
+	// a chain of diamonds (each function calls the next two) over a recursive
+	// leaf: 2^n call paths reach the leaf
+	const n = 64
+	su = &StackUsage{Funcs: make(map[uint]*FuncStack)}
+	for i := uint(0); i < 2*n; i += 2 {
+		// i calls i+1 and i+2, i+1 calls i+2
+		su.Funcs[i] = &FuncStack{Frame: 16, Calls: []StackCall{{Target: i + 1, Depth: 16}, {Target: i + 2, Depth: 16}}}
+		su.Funcs[i+1] = &FuncStack{Frame: 32, Calls: []StackCall{{Target: i + 2, Depth: 32}}}
+	}
+	su.Funcs[2*n] = &FuncStack{Frame: 8, Calls: []StackCall{{Target: 2 * n, Depth: 8}}}
+	sd = su.Depth(0)
+	if sd.Depth != n*48+8 || sd.Bounded || !sd.Recursive || len(sd.Path) != 2*n+1 {
+		t.Errorf("depth %d bounded %v recursive %v path %d (expected %d false true %d)", sd.Depth, sd.Bounded, sd.Recursive, len(sd.Path), n*48+8, 2*n+1)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,89 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Symbols
+
+Map addresses to symbol names.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// Symbols is a set of address to name mappings.
+type Symbols struct {
+	names map[uint]string // address to name
+	addrs []uint          // sorted addresses (nil if stale)
+}
+
+// NewSymbols returns an empty symbol table.
+func NewSymbols() *Symbols {
+	return &Symbols{
+		names: make(map[uint]string),
+	}
+}
+
+// Add a symbol. The first name added for an address is kept.
+func (s *Symbols) Add(addr uint, name string) {
+	if _, ok := s.names[addr]; ok {
+		return
+	}
+	s.names[addr] = name
+	s.addrs = nil
+}
+
+// Name returns the symbol name at an address.
+func (s *Symbols) Name(addr uint) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	name, ok := s.names[addr]
+	return name, ok
+}
+
+// Addrs returns the sorted symbol addresses.
+func (s *Symbols) Addrs() []uint {
+	if s == nil {
+		return nil
+	}
+	if s.addrs == nil {
+		s.addrs = make([]uint, 0, len(s.names))
+		for addr := range s.names {
+			s.addrs = append(s.addrs, addr)
+		}
+		sort.Slice(s.addrs, func(i, j int) bool { return s.addrs[i] < s.addrs[j] })
+	}
+	return s.addrs
+}
+
+// Lookup returns the closest symbol at or below an address and the offset from it.
+func (s *Symbols) Lookup(addr uint) (string, uint, bool) {
+	addrs := s.Addrs()
+	i := sort.Search(len(addrs), func(i int) bool { return addrs[i] > addr })
+	if i == 0 {
+		return "", 0, false
+	}
+	base := addrs[i-1]
+	return s.names[base], addr - base, true
+}
+
+// Symbolize returns an address as "name" or "name+0xoffset" (or "" if unknown).
+func (s *Symbols) Symbolize(addr uint) string {
+	name, offset, ok := s.Lookup(addr)
+	if !ok {
+		return ""
+	}
+	if offset == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s+0x%x", name, offset)
+}
+
+//-----------------------------------------------------------------------------
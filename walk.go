@@ -0,0 +1,180 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Recursive Descent Disassembly
+
+Disassemble code by following the control flow from a set of entry points.
+Bytes that are not reached are treated as data.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// Line is a line of a disassembly listing (an instruction or data).
+type Line struct {
+	Addr       uint           // address
+	AddrLength uint           // address length in bits
+	Size       uint           // size in bytes
+	Da         *Disassembly   // instruction (nil for data)
+	Data       uint           // data value (if not an instruction)
+	Seq        []*Disassembly // further instructions of a pseudo instruction (see Resolve, Fold)
+}
+
+func (l *Line) String() string {
+	if len(l.Seq) != 0 {
+		// the first instruction is the line less the rest of the sequence
+		n := l.Size
+		for _, da := range l.Seq {
+			n -= da.InsLength
+		}
+		ins := fmtIns(l.Da.Ins, n)
+		for _, da := range l.Seq {
+			ins += " " + fmtIns(da.Ins, da.InsLength)
+		}
+		return fmt.Sprintf("%s: %s \t%s", fmtAddr(l.Addr, l.AddrLength), ins, l.Da.asm())
+	}
+	if l.Da != nil {
+		return l.Da.String()
+	}
+	addrStr := fmtAddr(l.Addr, l.AddrLength)
+	switch l.Size {
+	case 4:
+		return fmt.Sprintf("%s: %08x \t.word 0x%08x", addrStr, l.Data, l.Data)
+	case 2:
+		return fmt.Sprintf("%s: %04x     \t.half 0x%04x", addrStr, l.Data, l.Data)
+	}
+	return fmt.Sprintf("%s: %02x       \t.byte 0x%02x", addrStr, l.Data, l.Data)
+}
+
+// Conflict is an instruction decode that overlaps a previously decoded instruction.
+type Conflict struct {
+	Addr  uint // address of the conflicting decode
+	Owner uint // address of the instruction that owns the overlapping bytes
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%x: overlaps instruction at %x", c.Addr, c.Owner)
+}
+
+// Listing is a disassembly listing of a code region.
+type Listing struct {
+	Lines     []*Line    // instructions and data in address order
+	Conflicts []Conflict // overlapping/conflicting decodes
+}
+
+//-----------------------------------------------------------------------------
+
+// dataLine returns a data line for the unowned bytes at an address.
+func (isa *ISA) dataLine(code *Code, addr uint, free func(addr, n uint) bool) *Line {
+	for _, n := range []uint{4, 2} {
+		if addr%n == 0 && free(addr, n) {
+			x, _ := code.read(addr, n)
+			return &Line{Addr: addr, AddrLength: isa.mxlen, Size: n, Data: x}
+		}
+	}
+	x, _ := code.read(addr, 1)
+	return &Line{Addr: addr, AddrLength: isa.mxlen, Size: 1, Data: x}
+}
+
+// Sweep disassembles a code region with a linear sweep.
+func Sweep(isa *ISA, code *Code) *Listing {
+	l := &Listing{}
+	addr := code.Addr
+	for addr < code.End() {
+		ins, ok := code.Fetch(addr)
+		if !ok {
+			l.Lines = append(l.Lines, isa.dataLine(code, addr, code.Contains))
+		} else {
+			da := isa.Disassemble(addr, ins)
+			l.Lines = append(l.Lines, &Line{Addr: addr, AddrLength: isa.mxlen, Size: da.InsLength, Da: da})
+		}
+		addr += l.Lines[len(l.Lines)-1].Size
+	}
+	return l
+}
+
+// Traverse disassembles a code region with a recursive descent from the
+// entry addresses. Direct control flow is followed, unreached bytes are
+// listed as data.
+func Traverse(isa *ISA, code *Code, entry []uint) *Listing {
+	l := &Listing{}
+	ins := make(map[uint]*Disassembly)
+	owner := make(map[uint]uint) // byte address to instruction address
+	conflict := make(map[uint]bool)
+
+	// stack the entry points so the first is processed first
+	work := []uint{}
+	for i := len(entry) - 1; i >= 0; i-- {
+		if code.Contains(entry[i], 2) {
+			work = append(work, entry[i])
+		}
+	}
+
+	for len(work) > 0 {
+		pc := work[len(work)-1]
+		work = work[:len(work)-1]
+		for ins[pc] == nil {
+			x, ok := code.Fetch(pc)
+			if !ok || !isa.legal(x) {
+				// out of range or illegal: leave it as data
+				break
+			}
+			da := isa.Disassemble(pc, x)
+			// check for overlap with existing instructions
+			for i := uint(0); i < da.InsLength; i++ {
+				if a, ok := owner[pc+i]; ok {
+					if !conflict[pc] {
+						l.Conflicts = append(l.Conflicts, Conflict{pc, a})
+					}
+					conflict[pc] = true
+					break
+				}
+			}
+			if conflict[pc] {
+				break
+			}
+			ins[pc] = da
+			for i := uint(0); i < da.InsLength; i++ {
+				owner[pc+i] = pc
+			}
+			if da.Flow == FlowNext {
+				pc += da.InsLength
+				continue
+			}
+			work = append(work, da.Successors(pc)...)
+			break
+		}
+	}
+
+	// build the listing
+	free := func(addr, n uint) bool {
+		for i := uint(0); i < n; i++ {
+			if _, ok := owner[addr+i]; ok || !code.Contains(addr+i, 1) {
+				return false
+			}
+		}
+		return true
+	}
+	addr := code.Addr
+	for addr < code.End() {
+		if da := ins[addr]; da != nil {
+			l.Lines = append(l.Lines, &Line{Addr: addr, AddrLength: isa.mxlen, Size: da.InsLength, Da: da})
+		} else {
+			l.Lines = append(l.Lines, isa.dataLine(code, addr, free))
+		}
+		addr += l.Lines[len(l.Lines)-1].Size
+	}
+
+	sort.Slice(l.Conflicts, func(i, j int) bool { return l.Conflicts[i].Addr < l.Conflicts[j].Addr })
+	return l
+}
+
+//-----------------------------------------------------------------------------
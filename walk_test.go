@@ -0,0 +1,55 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Recursive Descent Disassembly Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Traverse(t *testing.T) {
+	isa, err := New(32, RV32gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0x1000,
+		0x0080006f, // 1000: j 1008
+		0xffffffff, // 1004: data
+		0x00008067, // 1008: ret
+		0x0001,     // 100c: unreached
+	)
+
+	l := Traverse(isa, code, []uint{0x1000, 0x1002})
+	lines := []string{}
+	for _, x := range l.Lines {
+		lines = append(lines, x.String())
+	}
+	expected := []string{
+		"00001000: 0080006f \tj 1008",
+		"00001004: ffffffff \t.word 0xffffffff",
+		"00001008: 00008067 \tret",
+		"0000100c: 0001     \t.half 0x0001",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("listing\n%s\n(expected)\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+	}
+	if len(l.Conflicts) != 1 || l.Conflicts[0] != (Conflict{0x1002, 0x1000}) {
+		t.Errorf("conflicts %v", l.Conflicts)
+	}
+
+	// a linear sweep decodes everything
+	l = Sweep(isa, code)
+	if len(l.Lines) != 4 || l.Lines[3].Da == nil || l.Lines[3].Da.Assembly != "nop" {
+		t.Errorf("bad linear sweep %v", l.Lines)
+	}
+}
+
+//-----------------------------------------------------------------------------
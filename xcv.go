@@ -0,0 +1,77 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V CORE-V Extensions
+
+The OpenHW CORE-V cores (CV32E40P, CV32E40PX) add hardware loops,
+post-increment and register offset loads/stores, immediate branches,
+multiply-accumulate, bit manipulation and packed SIMD instructions in the
+custom opcode space (XCV*).
+
+A hardware loop repeats the instructions from lpstart up to (not including)
+lpend, so the control flow graph has an edge from the end of the loop body
+back to lpstart.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+//-----------------------------------------------------------------------------
+
+// memTypeXCV is a CORE-V load/store. The post-increment forms access
+// memory at rs1 (and then update it). The register offset forms access
+// memory at rs1 + rs2 (loads) or rs1 + rs3 (stores).
+func memTypeXCV(m *MemAccess, ins, mxlen uint) {
+	m.Base = Reg{RegX, bitUnsigned(ins, 19, 15, 0)}
+	// register offset: custom-1, funct3 011, funct7[2] set
+	if ins&0x707f == 0x302b && ins&(1<<27) != 0 {
+		m.Indexed = true
+		if m.Store {
+			m.Index = Reg{RegX, bitUnsigned(ins, 11, 7, 0)}
+		} else {
+			m.Index = Reg{RegX, bitUnsigned(ins, 24, 20, 0)}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// hwLoops tracks the CORE-V hardware loops set up along the control flow.
+type hwLoops struct {
+	start, end [2]uint   // lpstart/lpend of each loop (0 if unknown)
+	loops      [][2]uint // complete loops (lpstart, lpend)
+}
+
+// set updates the loop addresses with a hardware loop instruction.
+func (h *hwLoops) set(da *Disassembly) {
+	uimm, rs1, l := decodeXCVHwlp(da.Ins)
+	switch da.id() {
+	case "cv.starti":
+		h.start[l] = da.Addr + uimm<<2
+	case "cv.start":
+		h.start[l] = 0
+	case "cv.endi":
+		h.end[l] = da.Addr + uimm<<2
+	case "cv.end":
+		h.end[l] = 0
+	case "cv.setupi":
+		h.start[l], h.end[l] = da.Addr+4, da.Addr+rs1<<2
+	case "cv.setup":
+		h.start[l], h.end[l] = da.Addr+4, da.Addr+uimm<<2
+	default:
+		return
+	}
+	if h.start[l] == 0 || h.end[l] <= h.start[l] {
+		return
+	}
+	loop := [2]uint{h.start[l], h.end[l]}
+	for _, x := range h.loops {
+		if x == loop {
+			return
+		}
+	}
+	h.loops = append(h.loops, loop)
+}
+
+//-----------------------------------------------------------------------------
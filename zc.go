@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Code Size Reduction Extensions
+
+Zcmp push/pop instructions save/restore a list of registers ({ra,s0-sN})
+and adjust the stack pointer. Zcmt table jumps (cm.jt, cm.jalt) jump
+through a table of addresses at the base address held in CSR jvt.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import "fmt"
+
+//-----------------------------------------------------------------------------
+// push/pop register lists
+
+// rlistRegs returns the registers in a push/pop register list.
+func rlistRegs(rlist uint) []uint {
+	if rlist < 4 {
+		// reserved
+		return nil
+	}
+	n := rlist - 4
+	if rlist == 15 {
+		// s10 is not saved without s11
+		n = 12
+	}
+	regs := []uint{1}
+	for i := uint(0); i < n; i++ {
+		regs = append(regs, sreg(i))
+	}
+	return regs
+}
+
+// fmtRlist returns the string for a push/pop register list, e.g. {ra,s0-s2}.
+func fmtRlist(rlist uint) string {
+	switch {
+	case rlist < 4:
+		return "{}"
+	case rlist == 4:
+		return "{ra}"
+	case rlist == 5:
+		return "{ra,s0}"
+	}
+	regs := rlistRegs(rlist)
+	return fmt.Sprintf("{ra,s0-%s}", abiXName[regs[len(regs)-1]])
+}
+
+// cmStackAdj returns the stack adjustment of a push/pop instruction.
+func cmStackAdj(ins, mxlen uint) uint {
+	rlist, spimm := decodeCMPP(ins)
+	// the register save area is rounded up to 16 bytes
+	n := uint(len(rlistRegs(rlist))) * (mxlen >> 3)
+	return ((n + 15) &^ 15) + spimm
+}
+
+// memTypeCMPush is the register save area stored by a push (below sp).
+func memTypeCMPush(m *MemAccess, ins, mxlen uint) {
+	rlist, _ := decodeCMPP(ins)
+	m.Size = uint(len(rlistRegs(rlist))) * (mxlen >> 3)
+	m.Base, m.Offset, m.Align = regSP, -int(m.Size), mxlen>>3
+}
+
+// memTypeCMPop is the register save area loaded by a pop (at the top of
+// the stack frame).
+func memTypeCMPop(m *MemAccess, ins, mxlen uint) {
+	memTypeCMPush(m, ins, mxlen)
+	m.Offset = int(cmStackAdj(ins, mxlen) - m.Size)
+}
+
+//-----------------------------------------------------------------------------
+// table jumps
+
+// SetJVT sets the jump vector table base address (the value of CSR jvt) and
+// the memory holding the table. cm.jt/cm.jalt targets are then resolved.
+func (isa *ISA) SetJVT(jvt uint, mem *Code) {
+	isa.jvt = jvt &^ 0x3f
+	isa.jmem = mem
+}
+
+// jvtTarget returns the target of a table jump (if the table is known).
+func (isa *ISA) jvtTarget(ins uint) (uint, bool) {
+	if isa.jmem == nil {
+		return 0, false
+	}
+	n := isa.mxlen >> 3
+	x, ok := isa.jmem.read(isa.jvt+decodeCMJT(ins)*n, n)
+	if !ok {
+		return 0, false
+	}
+	return x &^ 1, true
+}
+
+//-----------------------------------------------------------------------------
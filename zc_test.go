@@ -0,0 +1,147 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Code Size Reduction Extensions Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rvda
+
+import (
+	"fmt"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+var rv32ZcTest = []daTest{
+	{0, 0x81ec, "lbu a1,3(a1)"},
+	{0, 0x86b0, "lhu a2,2(a3)"},
+	{0, 0x87d8, "lh a4,0(a5)"},
+	{0, 0x88c0, "sb s0,1(s1)"},
+	{0, 0x8da8, "sh a0,2(a1)"},
+	{0, 0x9d61, "zext.b a0,a0"},
+	{0, 0x9de5, "sext.b a1,a1"},
+	{0, 0x9e69, "zext.h a2,a2"},
+	{0, 0x9eed, "sext.h a3,a3"},
+	{0, 0x9f75, "not a4,a4"},
+	{0, 0x9d4d, "mul a0,a0,a1"},
+	{0, 0xb842, "cm.push {ra},-16"},
+	{0, 0xb872, "cm.push {ra,s0-s2},-16"},
+	{0, 0xb886, "cm.push {ra,s0-s3},-48"},
+	{0, 0xb8fe, "cm.push {ra,s0-s11},-112"},
+	{0, 0xba52, "cm.pop {ra,s0},16"},
+	{0, 0xbe86, "cm.popret {ra,s0-s3},48"},
+	{0, 0xbc62, "cm.popretz {ra,s0-s1},16"},
+	{0, 0xac2a, "cm.mvsa01 s0,s2"},
+	{0, 0xafe6, "cm.mva01s s7,s1"},
+	{0, 0xa00e, "cm.jt 3"},
+	{0, 0xa0a2, "cm.jalt 40"},
+}
+
+var rv64ZcTest = []daTest{
+	{0, 0x9d71, "zext.w a0,a0"},
+	{0, 0xb842, "cm.push {ra},-16"},
+	{0, 0xb872, "cm.push {ra,s0-s2},-32"},
+	{0, 0xb8fe, "cm.push {ra,s0-s11},-160"},
+	{0, 0xbe86, "cm.popret {ra,s0-s3},64"},
+}
+
+func Test_Zc(t *testing.T) {
+	testISA(t, "rv32imc_zcb_zbb_zcmp_zcmt", rv32ZcTest)
+	testISA(t, "rv64imc_zcb_zba_zcmp", rv64ZcTest)
+	// Zcd encodings
+	testISA(t, "rv32gc", []daTest{{0, 0xb842, "fsd fa6,48(sp)"}, {0, 0x9d61, "illegal"}})
+	// Zcmp replaces Zcd
+	testISA(t, "rv32gc_zcmp", []daTest{{0, 0xb842, "cm.push {ra},-16"}, {0, 0x2000, "illegal"}})
+	// Zbb is required for sext.b
+	testISA(t, "rv32imc_zcb", []daTest{{0, 0x9d61, "zext.b a0,a0"}, {0, 0x9de5, "illegal"}})
+}
+
+func Test_ZcUsage(t *testing.T) {
+	isa, err := Parse("rv32imc_zcb_zcmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		ins  uint
+		uses string
+		defs string
+		mem  string
+		flow Flow
+	}{
+		{0x81ec, "{a1}", "{a1}", "load8 3(a1)", FlowNext},
+		{0xb872, "{sp,ra,s0,s1,s2}", "{sp}", "store128 -16(sp)", FlowNext},
+		{0xbc62, "{sp}", "{sp,ra,s0,s1,a0}", "load96 4(sp)", FlowReturn},
+		{0xac2a, "{a0,a1}", "{s0,s2}", "<nil>", FlowNext},
+	}
+	for _, v := range testCases {
+		da := isa.Disassemble(0, v.ins)
+		mem := "<nil>"
+		if da.Mem != nil {
+			mem = da.Mem.String()
+		}
+		if da.Uses.String() != v.uses || da.Defs.String() != v.defs || mem != v.mem || da.Flow != v.flow {
+			t.Errorf("%s: uses %s defs %s mem %s flow %s (expected %s %s %s %s)",
+				da, da.Uses, da.Defs, mem, da.Flow, v.uses, v.defs, v.mem, v.flow)
+		}
+	}
+	if da := isa.Disassemble(0, 0xb802); da.Class != ClassReserved {
+		t.Errorf("%s: class %s (expected reserved)", da, da.Class)
+	}
+}
+
+func Test_JVT(t *testing.T) {
+	isa, err := Parse("rv32imc_zcmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0,
+		0xa00e, // 00: cm.jt 3
+		0x8082, // 02: ret
+		0x8082, // 04: ret
+		0x8082, // 06: ret
+	)
+	da := isa.Disassemble(0, 0xa00e)
+	if da.Flow != FlowIndirectJump {
+		t.Errorf("%s: flow %s (expected indirect jump)", da, da.Flow)
+	}
+	// jump table at 0x100 (entry 3 = 0x6)
+	table := &Code{Addr: 0x100, Data: make([]byte, 16)}
+	table.Data[12] = 6
+	isa.SetJVT(0x100, table)
+	da = isa.Disassemble(0, 0xa00e)
+	if da.Flow != FlowJump || da.Target != 6 || da.String() != "00000000: a00e     \tcm.jt 3 # 0x6" {
+		t.Errorf("%s: flow %s target %x (expected jump 6)", da, da.Flow, da.Target)
+	}
+	// the returns at 0x2 and 0x4 are not reached
+	l := Traverse(isa, code, []uint{0})
+	if n := len(l.Lines); n != 4 || l.Lines[1].Da != nil || l.Lines[3].Da == nil {
+		t.Errorf("traverse %d lines (expected 4, 0x2-0x5 as data)", n)
+	}
+}
+
+func Test_ZcStack(t *testing.T) {
+	isa, err := Parse("rv32imc_zcmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := testCode(0,
+		0xb886, // 00: cm.push {ra,s0-s3},-48
+		0x2021, // 02: jal 0a
+		0xbe86, // 04: cm.popret {ra,s0-s3},48
+		0x0001, // 06: nop
+		0x0001, // 08: nop
+		0xb852, // 0a: cm.push {ra,s0},-16
+		0xbe52, // 0c: cm.popret {ra,s0},16
+	)
+	funcs := FindFuncs(isa, code, []uint{0}, nil)
+	su := NewStackUsage(isa, code, funcs)
+	sd := su.Depth(0)
+	if sd.Depth != 64 || !sd.Bounded || fmt.Sprintf("%x", sd.Path) != "[0 a]" {
+		t.Errorf("depth %d bounded %v path %x (expected 64 true [0 a])", sd.Depth, sd.Bounded, sd.Path)
+	}
+}
+
+//-----------------------------------------------------------------------------